@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blamarvt/factory-planner/internal/data"
+)
+
+// dataFlags holds the data file options shared by all commands. Each option
+// is a comma-separated list of JSON files, layered in order; when empty the
+// built-in data is used.
+type dataFlags struct {
+	Items        string
+	Recipes      string
+	Technologies string
+	ModList      string
+	ModsDir      string
+	Locale       string
+	LocaleDir    string
+}
+
+// registerDataFlags adds the data file options to a flag set.
+func registerDataFlags(flags *flag.FlagSet) *dataFlags {
+	files := &dataFlags{}
+	flags.StringVar(&files.Items, "items", "", "Comma-separated item JSON files, later files override earlier ones")
+	flags.StringVar(&files.Recipes, "recipes", "", "Comma-separated recipe JSON files, later files override earlier ones")
+	flags.StringVar(&files.Technologies, "technologies", "", "Comma-separated technology JSON files, later files override earlier ones")
+	flags.StringVar(&files.ModList, "mod-list", "", "mod-list.json file listing mods to apply in load order")
+	flags.StringVar(&files.ModsDir, "mods-dir", "mods", "Directory holding a <mod>.json data overlay per mod")
+	flags.StringVar(&files.Locale, "locale", "", "Language of display names (e.g. 'de'), empty for internal names")
+	flags.StringVar(&files.LocaleDir, "locale-dir", "locale", "Directory holding <language>/*.cfg locale files")
+	return files
+}
+
+// gameData holds all loaded game data.
+type gameData struct {
+	Items        *data.ItemDatabase
+	Recipes      *data.RecipeData
+	Entities     *data.EntityData
+	Technologies *data.TechnologyData
+
+	Locale         *data.Locale      // display names, nil for internal names
+	ModDiagnostics []data.Diagnostic // conflicts found while applying mods
+}
+
+// loadGameData loads items, recipes, entities and technologies, reading
+// JSON files where given and built-in data otherwise, then applies the
+// mods of the mod list in load order and reads the display names of the
+// chosen locale.
+func loadGameData(files *dataFlags) (*gameData, error) {
+	var items *data.ItemDatabase
+	var err error
+	if files.Items != "" {
+		items, err = data.LoadItemsFromFiles(strings.Split(files.Items, ",")...)
+	} else {
+		items, err = data.LoadItems()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load items: %w", err)
+	}
+
+	var recipes *data.RecipeData
+	if files.Recipes != "" {
+		recipes, err = data.LoadRecipesFromFiles(strings.Split(files.Recipes, ",")...)
+	} else {
+		recipes, err = data.LoadRecipes()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recipes: %w", err)
+	}
+
+	entities, err := data.LoadEntities()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load entities: %w", err)
+	}
+
+	var technologies *data.TechnologyData
+	if files.Technologies != "" {
+		technologies, err = data.LoadTechnologiesFromFiles(strings.Split(files.Technologies, ",")...)
+	} else {
+		technologies, err = data.LoadTechnologies()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load technologies: %w", err)
+	}
+
+	game := &gameData{
+		Items:        items,
+		Recipes:      recipes,
+		Entities:     entities,
+		Technologies: technologies,
+	}
+
+	if files.ModList != "" {
+		modList, err := data.LoadModList(files.ModList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mod list: %w", err)
+		}
+		game.ModDiagnostics, err = data.ApplyMods(modList, files.ModsDir, items, recipes, technologies)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if files.Locale != "" {
+		game.Locale, err = data.LoadLocale(files.LocaleDir, files.Locale)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load locale: %w", err)
+		}
+	}
+
+	return game, nil
+}
+
+// printModWarnings prints the diagnostics found while applying mods to
+// standard error.
+func (g *gameData) printModWarnings() {
+	for _, diagnostic := range g.ModDiagnostics {
+		fmt.Fprintln(os.Stderr, diagnostic)
+	}
+}
@@ -1,38 +1,782 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	bp "github.com/blamarvt/factory-planner/internal/blueprint"
+	"github.com/blamarvt/factory-planner/internal/core"
+	"github.com/blamarvt/factory-planner/internal/data"
+	"github.com/blamarvt/factory-planner/internal/render"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "research":
+			runResearchCommand(os.Args[2:])
+			return
+		case "tech":
+			runTechCommand(os.Args[2:])
+			return
+		case "validate-data":
+			runValidateDataCommand(os.Args[2:])
+			return
+		case "export-data":
+			runExportDataCommand(os.Args[2:])
+			return
+		}
+	}
+
 	var (
-		research  = flag.String("research", "", "Research progress level (e.g., 'basic-science')")
-		target    = flag.String("target", "", "Production target (e.g., 'science-pack-1:60/min')")
-		output    = flag.String("output", "", "Output file path for PNG image")
-		blueprint = flag.Bool("blueprint", false, "Generate Factorio blueprint string")
+		research      = flag.String("research", "", "Research progress level (e.g., 'basic-science')")
+		target        = flag.String("target", "", "Production target (e.g., 'science-pack-1:60/min')")
+		output        = flag.String("output", "", "Output file path for PNG image")
+		blueprint     = flag.Bool("blueprint", false, "Generate Factorio blueprint string")
+		rankPollution = flag.Bool("rank-pollution", false, "Rank recipe alternatives for the target by pollution")
+		oilYield      = flag.Float64("oil-yield", 100, "Average yield of crude oil wells in percent")
+		fuel          = flag.String("fuel", "coal", "Fuel item burned by furnaces, burner drills and boilers")
+		ratios        = flag.Bool("ratios", false, "Suggest nearby target rates that use whole numbers of machines")
+		researchQueue = flag.String("research-queue", "", "Comma-separated technologies to estimate research time for; without --target the plan makes their science packs")
+		labs          = flag.Int("labs", 10, "Number of labs used for research time estimates")
+		assembler     = flag.String("assembler", "assembling-machine-1", "Assembling machine used for crafting recipes")
+		checkLayout   = flag.Bool("check-layout", false, "Check the generated layout for collisions, reach and power problems")
+		qualityModule = flag.String("quality-module", "quality-module-3", "Module filling free slots of machines in quality loops")
+		layoutStyle   = flag.String("layout-style", core.LayoutStyleCompact, "Layout style: "+layoutStyleUsage())
+		seed          = flag.Int64("seed", 1, "Seed for custom layout strategies drawing random numbers from Generator.Rand; the built-in styles ignore it with a warning")
+		blockSize     = flag.Int("block-size", core.DefaultCityBlockSize, "Side in tiles of the blocks of the city-block layout style")
 	)
+	files := registerDataFlags(flag.CommandLine)
 	flag.Parse()
 
 	fmt.Println("Factorio Factory Planner")
 	fmt.Println("========================")
 
-	if *research == "" || *target == "" || *output == "" {
+	if *research == "" || (*target == "" && *researchQueue == "") ||
+		(*output == "" && !*rankPollution && !*ratios && *researchQueue == "") ||
+		(*target == "" && (*rankPollution || *ratios)) {
 		fmt.Println("Error: Missing required parameters")
 		fmt.Println("Usage: factory-planner --research <level> --target <item:rate> --output <file.png>")
+		fmt.Println("       factory-planner --research <level> --research-queue <tech,...> [--target <item:rate>] [--output <file.png>]")
 		fmt.Println("Example: factory-planner --research basic-science --target \"science-pack-1:60/min\" --output factory.png")
 		os.Exit(1)
 	}
 
 	fmt.Printf("Research level: %s\n", *research)
-	fmt.Printf("Production target: %s\n", *target)
-	fmt.Printf("Output file: %s\n", *output)
+
+	game, err := loadGameData(files)
+	if err != nil {
+		exitWithError(err)
+	}
+	game.printModWarnings()
+
+	progress := data.CreateResearchProgress(*research)
+	optimizer, err := newOptimizer(game, progress, planOptions{
+		OilYield:      *oilYield / 100.0,
+		Fuel:          *fuel,
+		Assembler:     *assembler,
+		QualityModule: *qualityModule,
+	})
+	if err != nil {
+		exitWithError(err)
+	}
+
+	var queue []string
+	var labSettings data.LabSettings
+	if *researchQueue != "" {
+		for _, name := range strings.Split(*researchQueue, ",") {
+			queue = append(queue, strings.TrimSpace(name))
+		}
+		lab, exists := game.Entities.GetEntity("lab")
+		if !exists {
+			exitWithError(fmt.Errorf("failed to estimate research time: no lab entity"))
+		}
+		labSettings = data.LabSettings{
+			Count:      *labs,
+			Speed:      lab.CraftingSpeed,
+			SpeedBonus: optimizer.Bonuses.LabSpeed,
+		}
+	}
+
+	// Without a target, the plan makes the science packs of the research
+	// queue at the rates the labs consume them.
+	var targets []core.ProductionTarget
+	if *target != "" {
+		fmt.Printf("Production target: %s\n", *target)
+		productionTarget, err := parseTarget(*target)
+		if err != nil {
+			exitWithError(err)
+		}
+		if _, exists := game.Items.GetItem(productionTarget.Item); !exists {
+			exitWithError(fmt.Errorf("unknown item %s", productionTarget.Item))
+		}
+		targets = []core.ProductionTarget{productionTarget}
+	} else {
+		targets, err = game.Technologies.SciencePackTargets(queue, progress, labSettings)
+		if err != nil {
+			exitWithError(fmt.Errorf("failed to plan science packs: %w", err))
+		}
+		for _, packTarget := range targets {
+			fmt.Printf("Production target: %s:%.2f/min\n", packTarget.Item, packTarget.Rate)
+		}
+	}
+
+	if *rankPollution {
+		rankings, err := optimizer.RankRecipesByPollution(targets[0])
+		if err != nil {
+			exitWithError(err)
+		}
+		printPollutionRanking(targets[0], rankings, game.Locale)
+		return
+	}
+
+	if *ratios {
+		suggestions, err := optimizer.FindWholeRatios(targets, 100, 5)
+		if err != nil {
+			exitWithError(err)
+		}
+		printRatioSuggestions(suggestions, game.Locale)
+		return
+	}
+
+	if *output != "" {
+		fmt.Printf("Output file: %s\n", *output)
+	}
+
+	plan, err := optimizer.OptimizeProduction(targets)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to optimize production: %w", err))
+	}
+	printPlan(plan, game.Locale)
+
+	if *researchQueue != "" {
+		estimate, err := game.Technologies.EstimateResearchTime(plan, queue, progress, labSettings)
+		if err != nil {
+			exitWithError(fmt.Errorf("failed to estimate research time: %w", err))
+		}
+		printResearchEstimate(estimate, game.Locale)
+		if *output == "" {
+			return
+		}
+	}
+
+	seedSet := false
+	flag.Visit(func(f *flag.Flag) { seedSet = seedSet || f.Name == "seed" })
+	if warning := seedWarning(*layoutStyle, seedSet); warning != "" {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	generator := newLayoutGenerator(game, optimizer, progress)
+	generator.Style = *layoutStyle
+	generator.BlockSize = *blockSize
+	generator.Seed = *seed
+	layout, err := generator.GenerateLayout(plan)
+	if err != nil {
+		exitWithError(fmt.Errorf("failed to generate layout: %w", err))
+	}
+
+	printBus(layout, game.Locale)
+	printStations(layout, game.Locale)
+	printBeltRoutes(layout, game.Locale)
+	printPipeRoutes(layout, game.Locale)
+	printPoles(layout, game.Locale)
+
+	if *checkLayout {
+		printLayoutViolations(generator.ValidateLayout(layout))
+	}
+
+	if err := render.NewImageRenderer().RenderLayout(layout, *output); err != nil {
+		exitWithError(fmt.Errorf("failed to render layout: %w", err))
+	}
+	fmt.Printf("\nLayout written to %s\n", *output)
 
 	if *blueprint {
-		fmt.Println("Blueprint generation: enabled")
+		blueprintString, err := bp.NewExporter().ExportBlueprint(layout)
+		if err != nil {
+			exitWithError(fmt.Errorf("failed to export blueprint: %w", err))
+		}
+		fmt.Println("\nBlueprint string:")
+		fmt.Println(blueprintString)
+	}
+}
+
+// runResearchCommand handles "research path <recipe>", which lists the
+// technologies needed to unlock a recipe and their science pack cost.
+func runResearchCommand(args []string) {
+	if len(args) == 0 || args[0] != "path" {
+		fmt.Println("Usage: factory-planner research path [--research <level>] <recipe>")
+		os.Exit(1)
+	}
+
+	flags := flag.NewFlagSet("research path", flag.ExitOnError)
+	research := flags.String("research", "", "Research progress level (e.g., 'basic-science')")
+	files := registerDataFlags(flags)
+	flags.Parse(args[1:])
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: factory-planner research path [--research <level>] <recipe>")
+		os.Exit(1)
+	}
+	recipe := flags.Arg(0)
+
+	game, err := loadGameData(files)
+	if err != nil {
+		exitWithError(err)
+	}
+	game.printModWarnings()
+
+	progress := data.CreateResearchProgress(*research)
+	path, err := game.Technologies.ResearchPathForRecipe(recipe, progress)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	names := game.Locale
+	if len(path.Technologies) == 0 {
+		fmt.Printf("Recipe %s is already unlocked\n", names.RecipeName(recipe))
+		return
+	}
+
+	fmt.Printf("Research path to unlock %s:\n", names.RecipeName(recipe))
+	for i, name := range path.Technologies {
+		fmt.Printf("  %d. %s\n", i+1, names.TechnologyName(name))
+	}
+
+	fmt.Println("\nScience packs needed:")
+	for _, pack := range sortedKeys(path.Cost) {
+		fmt.Printf("  %-28s %6d\n", names.ItemName(pack), path.Cost[pack])
+	}
+}
+
+// runTechCommand handles "tech validate", which checks the technology tree
+// for consistency, and "tech graph", which exports it as DOT or SVG.
+func runTechCommand(args []string) {
+	usage := "Usage: factory-planner tech validate | tech graph [--research <level>] [--format dot|svg] [--output <file>]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+
+	flags := flag.NewFlagSet("tech "+args[0], flag.ExitOnError)
+	files := registerDataFlags(flags)
+
+	switch args[0] {
+	case "validate":
+		flags.Parse(args[1:])
+		game, err := loadGameData(files)
+		if err != nil {
+			exitWithError(err)
+		}
+		game.printModWarnings()
+
+		diagnostics := game.Technologies.ValidateTechnologies(game.Items, game.Recipes)
+		printDiagnostics(diagnostics)
+		if data.HasErrors(diagnostics) {
+			os.Exit(1)
+		}
+
+	case "graph":
+		research := flags.String("research", "", "Research progress level to highlight")
+		format := flags.String("format", "dot", "Output format: dot or svg")
+		output := flags.String("output", "", "Output file path (default: standard output)")
+		flags.Parse(args[1:])
+
+		game, err := loadGameData(files)
+		if err != nil {
+			exitWithError(err)
+		}
+		game.printModWarnings()
+
+		writer := os.Stdout
+		if *output != "" {
+			file, err := os.Create(*output)
+			if err != nil {
+				exitWithError(fmt.Errorf("failed to create output file: %w", err))
+			}
+			defer file.Close()
+			writer = file
+		}
+
+		exporter := render.NewTechTreeExporter(game.Technologies, data.CreateResearchProgress(*research))
+		exporter.Locale = game.Locale
+		switch *format {
+		case "dot":
+			err = exporter.WriteDOT(writer)
+		case "svg":
+			err = exporter.WriteSVG(writer)
+		default:
+			err = fmt.Errorf("unknown format %q, expected dot or svg", *format)
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+}
+
+// runValidateDataCommand handles "validate-data", which cross-checks items,
+// recipes, entities and technologies.
+func runValidateDataCommand(args []string) {
+	flags := flag.NewFlagSet("validate-data", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print diagnostics as JSON")
+	files := registerDataFlags(flags)
+	flags.Parse(args)
+
+	game, err := loadGameData(files)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	diagnostics := append(game.ModDiagnostics, data.ValidateData(game.Items, game.Recipes, game.Entities, game.Technologies)...)
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if diagnostics == nil {
+			diagnostics = []data.Diagnostic{}
+		}
+		if err := encoder.Encode(diagnostics); err != nil {
+			exitWithError(err)
+		}
+	} else {
+		printDiagnostics(diagnostics)
+	}
+
+	if data.HasErrors(diagnostics) {
+		os.Exit(1)
+	}
+}
+
+// runExportDataCommand handles "export-data", which writes the loaded
+// items, recipes and technologies as JSON files that can be edited or
+// used as the base layer for override files.
+func runExportDataCommand(args []string) {
+	flags := flag.NewFlagSet("export-data", flag.ExitOnError)
+	dir := flags.String("dir", ".", "Directory to write items.json, recipes.json and technologies.json to")
+	files := registerDataFlags(flags)
+	flags.Parse(args)
+
+	game, err := loadGameData(files)
+	if err != nil {
+		exitWithError(err)
+	}
+	game.printModWarnings()
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		exitWithError(fmt.Errorf("failed to create output directory: %w", err))
+	}
+	if err := game.Items.SaveJSON(filepath.Join(*dir, "items.json")); err != nil {
+		exitWithError(err)
+	}
+	if err := game.Recipes.SaveJSON(filepath.Join(*dir, "recipes.json")); err != nil {
+		exitWithError(err)
+	}
+	if err := game.Technologies.SaveJSON(filepath.Join(*dir, "technologies.json")); err != nil {
+		exitWithError(err)
+	}
+	fmt.Printf("Game data written to %s\n", *dir)
+}
+
+// printDiagnostics prints validation diagnostics, or a note that there
+// were none.
+func printDiagnostics(diagnostics []data.Diagnostic) {
+	if len(diagnostics) == 0 {
+		fmt.Println("No problems found")
+		return
+	}
+	for _, diagnostic := range diagnostics {
+		fmt.Println(diagnostic)
+	}
+}
+
+// planOptions holds the command-line settings that shape a production plan.
+type planOptions struct {
+	OilYield      float64 // average oil well yield, 1.0 = 100%
+	Fuel          string  // fuel item burned by burner machines
+	Assembler     string  // entity crafting "crafting" recipes
+	QualityModule string  // module filling free slots in quality loops
+}
+
+// newOptimizer builds an optimizer from the recipes unlocked by the given
+// research progress, using the default machines for each crafting category.
+func newOptimizer(game *gameData, progress *data.ResearchProgress, options planOptions) (*core.Optimizer, error) {
+	items, entities, technologies := game.Items, game.Entities, game.Technologies
+	unlocked := technologies.UnlockedRecipes(progress)
+
+	graph := core.NewRecipeGraph()
+	for name, recipe := range game.Recipes.Recipes {
+		if unlocked[name] {
+			graph.AddRecipe(recipe)
+		}
 	}
 
-	fmt.Println("\nTODO: Implement factory planning logic")
-	fmt.Println("This is the basic program layout - core functionality coming soon!")
+	optimizer := core.NewOptimizer(graph, progress.UnlockedTechnologies)
+	optimizer.Machines = entities.DefaultMachines()
+	optimizer.Miner, _ = entities.GetEntity("electric-mining-drill")
+	optimizer.Boiler, _ = entities.GetEntity("boiler")
+	optimizer.Drills = entities.UnlockedDrills(unlocked)
+	optimizer.Pumpjack, _ = entities.GetEntity("pumpjack")
+	optimizer.OffshorePump, _ = entities.GetEntity("offshore-pump")
+	optimizer.Bonuses = technologies.ResearchBonuses(progress)
+	optimizer.OilYield = options.OilYield
+	optimizer.ResourceProvider = items
+	optimizer.Fuel = options.Fuel
+	optimizer.FuelProvider = items
+	optimizer.FluidProvider = items
+
+	optimizer.Belts = entities.UnlockedBelts(unlocked)
+	optimizer.Inserters = entities.UnlockedInserters(unlocked)
+
+	if options.Assembler != "" {
+		assembler, exists := entities.GetEntity(options.Assembler)
+		if !exists || !slices.Contains(assembler.Categories, "crafting") {
+			return nil, fmt.Errorf("%s is not an assembling machine", options.Assembler)
+		}
+		optimizer.Machines["crafting"] = assembler
+	}
+
+	optimizer.MaxQuality = technologies.MaxQuality(progress)
+	if options.QualityModule != "" {
+		module, exists := entities.GetModule(options.QualityModule)
+		if !exists {
+			return nil, fmt.Errorf("unknown module %s", options.QualityModule)
+		}
+		optimizer.QualityModule = module
+	}
+
+	return optimizer, nil
+}
+
+// newLayoutGenerator builds a layout generator placing the belts,
+// inserters and poles unlocked by the given research progress.
+func newLayoutGenerator(game *gameData, optimizer *core.Optimizer, progress *data.ResearchProgress) *core.LayoutGenerator {
+	generator := core.NewLayoutGeneratorWithColorProvider(game.Items)
+	generator.Bonuses = optimizer.Bonuses
+	generator.Entities = game.Entities
+	generator.Belts = optimizer.Belts
+	generator.Inserters = optimizer.Inserters
+	generator.Poles = game.Entities.UnlockedPoles(game.Technologies.UnlockedRecipes(progress))
+	generator.Pipe, _ = game.Entities.GetPipe("pipe")
+	generator.StackSizes = game.Items
+	if game.Locale != nil {
+		generator.NameProvider = game.Locale
+	}
+	return generator
+}
+
+// parseTarget parses a production target such as "iron-plate:60/min".
+// Rates may be given per minute ("/min"), per second ("/s") or without a
+// unit, in which case they are per minute. A quality may follow the item,
+// as in "quality-module@legendary:10/min".
+func parseTarget(value string) (core.ProductionTarget, error) {
+	item, rateText, found := strings.Cut(value, ":")
+	if !found || item == "" {
+		return core.ProductionTarget{}, fmt.Errorf("invalid target %q, expected <item[@quality]:rate>", value)
+	}
+
+	quality := core.QualityNormal
+	if name, qualityName, hasQuality := strings.Cut(item, "@"); hasQuality {
+		var err error
+		if quality, err = core.ParseQuality(qualityName); err != nil {
+			return core.ProductionTarget{}, fmt.Errorf("invalid target %q: %w", value, err)
+		}
+		item = name
+	}
+
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(rateText, "/min"):
+		rateText = strings.TrimSuffix(rateText, "/min")
+	case strings.HasSuffix(rateText, "/s"):
+		rateText = strings.TrimSuffix(rateText, "/s")
+		multiplier = 60.0
+	}
+
+	rate, err := strconv.ParseFloat(rateText, 64)
+	if err != nil || rate <= 0 {
+		return core.ProductionTarget{}, fmt.Errorf("invalid rate in target %q", value)
+	}
+
+	return core.ProductionTarget{Item: item, Rate: rate * multiplier, Quality: quality}, nil
+}
+
+// printPlan prints the machines, flows, power and pollution of a plan,
+// naming items, recipes and machines by their display names.
+func printPlan(plan *core.ProductionPlan, names *data.Locale) {
+	fmt.Println("\nRequired machines:")
+	for _, recipeName := range sortedKeys(plan.RequiredMachines) {
+		fmt.Printf("  %-28s %3d x %-22s (%6.2f, %5.1f%% utilized) %8.2f pollution/min (%.2f each)\n",
+			names.RecipeName(recipeName), plan.RequiredMachines[recipeName], names.EntityName(plan.MachineTypes[recipeName]),
+			plan.MachineCounts[recipeName], plan.Utilization[recipeName]*100,
+			plan.RecipePollution[recipeName], plan.MachinePollution[recipeName])
+	}
+
+	fmt.Println("\nResource flow:")
+	for _, item := range sortedKeys(plan.ResourceFlow) {
+		fmt.Printf("  %-28s %8.2f/min\n", names.ItemName(item), plan.ResourceFlow[item])
+	}
+
+	if len(plan.QualityOutput) > 0 {
+		fmt.Println("\nQuality loop output:")
+		for _, item := range sortedKeys(plan.QualityOutput) {
+			output := plan.QualityOutput[item]
+			for quality := core.QualityNormal; quality <= core.QualityLegendary; quality++ {
+				if rate, exists := output[quality]; exists {
+					fmt.Printf("  %-28s %-10s %8.2f/min\n", names.ItemName(item), quality, rate)
+				}
+			}
+		}
+	}
+
+	if len(plan.MiningRequirements) > 0 {
+		fmt.Println("\nMining requirements:")
+		for _, item := range sortedKeys(plan.MiningRequirements) {
+			for _, requirement := range plan.MiningRequirements[item] {
+				fmt.Printf("  %-28s %3d x %-22s (%.2f)\n",
+					names.ItemName(item), requirement.Required, names.EntityName(requirement.Entity), requirement.Count)
+			}
+		}
+	}
+
+	if len(plan.BeltRequirements) > 0 {
+		fmt.Println("\nBelt requirements:")
+		for _, item := range sortedKeys(plan.BeltRequirements) {
+			for _, requirement := range plan.BeltRequirements[item] {
+				fmt.Printf("  %-28s %6.2f x %-22s (%d lanes)\n",
+					names.ItemName(item), requirement.Belts, names.EntityName(requirement.Belt), requirement.Lanes)
+			}
+		}
+	}
+
+	if len(plan.InserterRequirements) > 0 {
+		fmt.Println("\nInserters per machine:")
+		for _, recipeName := range sortedKeys(plan.InserterRequirements) {
+			for _, requirement := range plan.InserterRequirements[recipeName] {
+				direction := "in"
+				if requirement.Output {
+					direction = "out"
+				}
+				fmt.Printf("  %-28s %-3s %-24s %d x %-20s (%.2f/s)\n",
+					names.RecipeName(recipeName), direction, names.ItemName(requirement.Item), requirement.Count,
+					names.EntityName(requirement.Inserter), requirement.Rate)
+			}
+		}
+	}
+
+	if len(plan.MiningPollution) > 0 {
+		fmt.Println("\nMining pollution:")
+		for _, item := range sortedKeys(plan.MiningPollution) {
+			fmt.Printf("  %-28s %8.2f pollution/min\n", names.ItemName(item), plan.MiningPollution[item])
+		}
+	}
+
+	fmt.Printf("\nPower usage: %.2f MW\n", plan.TotalPowerUsage)
+	if plan.Fuel != "" {
+		fmt.Printf("Fuel consumption: %.2f %s/min\n", plan.FuelConsumption, names.ItemName(plan.Fuel))
+		if plan.FuelSupplied {
+			fmt.Printf("  No unlocked recipe makes %s; it must be supplied from outside the plan\n", names.ItemName(plan.Fuel))
+		}
+	}
+	fmt.Printf("Boiler pollution: %.2f/min\n", plan.BoilerPollution)
+	fmt.Printf("Total pollution: %.2f/min\n", plan.TotalPollution)
+}
+
+// printLayoutViolations prints the problems found in a layout, or a note
+// that there are none.
+func printLayoutViolations(violations []core.LayoutViolation) {
+	fmt.Println("\nLayout check:")
+	if len(violations) == 0 {
+		fmt.Println("  No problems found")
+		return
+	}
+	for _, violation := range violations {
+		fmt.Printf("  %s\n", violation)
+	}
+}
+
+// seedWarning returns the warning for a --seed given with a built-in
+// layout style, which draws no random numbers, or an empty string.
+func seedWarning(style string, seedSet bool) string {
+	switch style {
+	case core.LayoutStyleCompact, core.LayoutStyleBus, core.LayoutStyleCity:
+		if seedSet {
+			return fmt.Sprintf("Warning: --seed has no effect on the deterministic %s layout style", style)
+		}
+	}
+	return ""
+}
+
+// layoutStyleUsage lists the registered layout strategies for the
+// --layout-style help text.
+func layoutStyleUsage() string {
+	var styles []string
+	for _, strategy := range core.LayoutStrategies() {
+		styles = append(styles, fmt.Sprintf("%s (%s)", strategy.Name(), strategy.Description()))
+	}
+	return strings.Join(styles, ", ")
+}
+
+// printBus prints the lanes of a main bus layout.
+func printBus(layout *core.FactoryLayout, names *data.Locale) {
+	if len(layout.Bus) == 0 {
+		return
+	}
+	fmt.Println("\nMain bus:")
+	for _, lane := range layout.Bus {
+		fmt.Printf("  x=%-4d %-28s rows %3d-%-3d %s\n", lane.X, names.ItemName(lane.Item), lane.Start, lane.End,
+			names.EntityName(lane.Belt))
+	}
+}
+
+// printStations prints the train stations of a city-block layout.
+func printStations(layout *core.FactoryLayout, names *data.Locale) {
+	if len(layout.Stations) == 0 {
+		return
+	}
+	fmt.Println("\nTrain stations:")
+	for _, station := range layout.Stations {
+		fmt.Printf("  block %-3d %-36s %8.2f/s  %2d trains\n", station.Block, station.Name, station.Rate, station.Trains)
+	}
+}
+
+// printBeltRoutes prints the belt lines of a layout and the ones no path
+// was found for.
+func printBeltRoutes(layout *core.FactoryLayout, names *data.Locale) {
+	if len(layout.Routes) == 0 && len(layout.Unrouted) == 0 {
+		return
+	}
+	fmt.Println("\nBelt routes:")
+	for _, route := range layout.Routes {
+		if route.Direct {
+			fmt.Printf("  %-28s %-16s -> %-16s direct insertion (%.2f/s)\n", names.ItemName(route.Item),
+				route.From, route.To, route.Rate)
+			continue
+		}
+		fmt.Printf("  %-28s %-16s -> %-16s %4d tiles  %2d stops  %s (%.2f/s)\n", names.ItemName(route.Item),
+			routeEnd(route.From, "input edge"), routeEnd(route.To, "output edge"), route.Length, len(route.Stops),
+			names.EntityName(route.Belt), route.Rate)
+	}
+	for _, route := range layout.Unrouted {
+		from, to := routeEnd(route.From, "input edge"), routeEnd(route.To, "output edge")
+		if len(route.Stops) > 0 {
+			// A building its belt line could not reach
+			if route.From == "" {
+				from = "belt line"
+			} else {
+				to = "belt line"
+			}
+		}
+		fmt.Printf("  %-28s %-16s -> %-16s no path found\n", names.ItemName(route.Item), from, to)
+	}
+}
+
+// printPipeRoutes prints the pipelines of a layout and the ones no path
+// was found for.
+func printPipeRoutes(layout *core.FactoryLayout, names *data.Locale) {
+	if len(layout.Pipes) == 0 && len(layout.UnroutedPipes) == 0 {
+		return
+	}
+	fmt.Println("\nPipe routes:")
+	for _, route := range layout.Pipes {
+		fmt.Printf("  %-28s %-16s -> %-16s %4d tiles  %3d segments (%.1f/s of %.0f/s)\n", names.ItemName(route.Fluid),
+			routeEnd(route.From, "input edge"), routeEnd(route.To, "output edge"), route.Length, route.Segments,
+			route.Rate, core.MaxPipeFlow(route.Segments))
+	}
+	for _, route := range layout.UnroutedPipes {
+		fmt.Printf("  %-28s %-16s -> %-16s no path found\n", names.ItemName(route.Fluid),
+			routeEnd(route.From, "input edge"), routeEnd(route.To, "output edge"))
+	}
+}
+
+// printPoles prints the electric poles of a layout and where to connect
+// the outside network.
+func printPoles(layout *core.FactoryLayout, names *data.Locale) {
+	counts := make(map[string]int)
+	for _, building := range layout.Buildings {
+		if building.Type == core.EntityTypeElectricPole {
+			counts[building.Entity]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Println("\nElectric poles:")
+	for _, pole := range sortedKeys(counts) {
+		fmt.Printf("  %-28s %4d\n", names.EntityName(pole), counts[pole])
+	}
+	for _, building := range layout.Buildings {
+		if building.ID == layout.PowerConnection {
+			fmt.Printf("  Connect the outside network at (%d,%d)\n", building.Position.X, building.Position.Y)
+		}
+	}
+}
+
+// routeEnd names one end of a belt route.
+func routeEnd(buildingID, edge string) string {
+	if buildingID == "" {
+		return edge
+	}
+	return buildingID
+}
+
+// printResearchEstimate prints the cost and duration of a research queue.
+func printResearchEstimate(estimate *data.ResearchEstimate, names *data.Locale) {
+	fmt.Println("\nResearch queue:")
+	for _, name := range estimate.Technologies {
+		fmt.Printf("  %-28s %8.1f s\n", names.TechnologyName(name), estimate.TechnologyTimes[name])
+	}
+
+	fmt.Println("\nScience packs needed:")
+	for _, pack := range sortedKeys(estimate.TotalCost) {
+		fmt.Printf("  %-28s %6d\n", names.ItemName(pack), estimate.TotalCost[pack])
+	}
+
+	fmt.Printf("\nTotal research time: %.1f min\n", estimate.TotalTime/60.0)
+}
+
+// printPollutionRanking prints recipe alternatives ordered by pollution.
+func printPollutionRanking(target core.ProductionTarget, rankings []core.RecipeRanking, names *data.Locale) {
+	fmt.Printf("\nRecipes for %s at %.2f/min, least polluting first:\n", names.ItemName(target.Item), target.Rate)
+	for i, ranking := range rankings {
+		fmt.Printf("  %d. %-28s %8.2f pollution/min %8.2f MW\n",
+			i+1, names.RecipeName(ranking.Recipe), ranking.TotalPollution, ranking.TotalPower)
+	}
+}
+
+// printRatioSuggestions prints target rates that use whole numbers of machines.
+func printRatioSuggestions(suggestions []core.RatioSuggestion, names *data.Locale) {
+	fmt.Println("\nWhole-number machine ratios, closest first:")
+	for _, suggestion := range suggestions {
+		for _, target := range suggestion.Targets {
+			fmt.Printf("  %s at %.2f/min:\n", names.ItemName(target.Item), target.Rate)
+		}
+		for _, recipeName := range sortedKeys(suggestion.Machines) {
+			fmt.Printf("    %-28s %3d\n", names.RecipeName(recipeName), suggestion.Machines[recipeName])
+		}
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// exitWithError prints an error and exits with a non-zero status.
+func exitWithError(err error) {
+	fmt.Printf("Error: %v\n", err)
+	os.Exit(1)
 }
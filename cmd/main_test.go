@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+	"github.com/blamarvt/factory-planner/internal/data"
+)
+
+// TestLargeLayoutRoutes lays out a plan of about a hundred assemblers and
+// furnaces, which must finish in a few seconds with every machine on its
+// belt lines.
+func TestLargeLayoutRoutes(t *testing.T) {
+	const (
+		minMachines = 100
+		timeBudget  = 20 * time.Second
+	)
+
+	game, err := loadGameData(&dataFlags{})
+	if err != nil {
+		t.Fatalf("loadGameData: %v", err)
+	}
+	progress := data.CreateResearchProgress("early-game")
+	optimizer, err := newOptimizer(game, progress, planOptions{OilYield: 1, Fuel: "coal", Assembler: "assembling-machine-1"})
+	if err != nil {
+		t.Fatalf("newOptimizer: %v", err)
+	}
+	plan, err := optimizer.OptimizeProduction([]core.ProductionTarget{{Item: "electronic-circuit", Rate: 600}})
+	if err != nil {
+		t.Fatalf("OptimizeProduction: %v", err)
+	}
+	machines := 0
+	for _, count := range plan.RequiredMachines {
+		machines += count
+	}
+	if machines < minMachines {
+		t.Fatalf("plan has %d machines, want at least %d", machines, minMachines)
+	}
+
+	start := time.Now()
+	layout, err := newLayoutGenerator(game, optimizer, progress).GenerateLayout(plan)
+	if err != nil {
+		t.Fatalf("GenerateLayout: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > timeBudget {
+		t.Errorf("layout took %v, want at most %v", elapsed, timeBudget)
+	}
+	for _, route := range layout.Unrouted {
+		t.Errorf("%s for %s/%s not routed", route.Item, route.From, route.To)
+	}
+}
+
+// TestMidSizeLayoutRoutes lays out plans of a few dozen machines whose
+// belts do not all fit between machines at the default spacing.
+func TestMidSizeLayoutRoutes(t *testing.T) {
+	const research = "early-game,steel-processing,oil-processing,plastics,sulfur-processing,advanced-electronics,engine,chemical-science-pack"
+
+	tests := []core.ProductionTarget{
+		{Item: "chemical-science-pack", Rate: 30},
+		{Item: "advanced-circuit", Rate: 60},
+	}
+
+	game, err := loadGameData(&dataFlags{})
+	if err != nil {
+		t.Fatalf("loadGameData: %v", err)
+	}
+	progress := data.CreateResearchProgress(research)
+	optimizer, err := newOptimizer(game, progress, planOptions{OilYield: 1, Fuel: "coal", Assembler: "assembling-machine-2"})
+	if err != nil {
+		t.Fatalf("newOptimizer: %v", err)
+	}
+
+	for _, target := range tests {
+		t.Run(target.Item, func(t *testing.T) {
+			plan, err := optimizer.OptimizeProduction([]core.ProductionTarget{target})
+			if err != nil {
+				t.Fatalf("OptimizeProduction: %v", err)
+			}
+			layout, err := newLayoutGenerator(game, optimizer, progress).GenerateLayout(plan)
+			if err != nil {
+				t.Fatalf("GenerateLayout: %v", err)
+			}
+			for _, route := range layout.Unrouted {
+				t.Errorf("%s for %s/%s not routed", route.Item, route.From, route.To)
+			}
+			for _, route := range layout.UnroutedPipes {
+				t.Errorf("%s pipe for %s/%s not routed", route.Fluid, route.From, route.To)
+			}
+		})
+	}
+}
+
+// TestUnlockedDrills checks that plans only offer the mining drills the
+// research unlocks.
+func TestUnlockedDrills(t *testing.T) {
+	game, err := loadGameData(&dataFlags{})
+	if err != nil {
+		t.Fatalf("loadGameData: %v", err)
+	}
+	optimizer, err := newOptimizer(game, data.CreateResearchProgress("early-game"), planOptions{OilYield: 1})
+	if err != nil {
+		t.Fatalf("newOptimizer: %v", err)
+	}
+	var drills []string
+	for _, drill := range optimizer.Drills {
+		drills = append(drills, drill.Name)
+	}
+	if want := []string{"burner-mining-drill", "electric-mining-drill"}; !slices.Equal(drills, want) {
+		t.Errorf("drills = %v, want %v", drills, want)
+	}
+}
+
+// TestValidateDataExitStatus runs validate-data in a child process, which
+// exits with status 1 when the data has errors.
+func TestValidateDataExitStatus(t *testing.T) {
+	if args, child := os.LookupEnv("VALIDATE_DATA_ARGS"); child {
+		runValidateDataCommand(strings.Fields(args))
+		os.Exit(0)
+	}
+
+	dir := t.TempDir()
+	recipes, err := data.LoadRecipes()
+	if err != nil {
+		t.Fatalf("LoadRecipes: %v", err)
+	}
+	base := filepath.Join(dir, "recipes.json")
+	if err := recipes.SaveJSON(base); err != nil {
+		t.Fatalf("SaveJSON: %v", err)
+	}
+	broken := filepath.Join(dir, "broken.json")
+	content := `{"recipes": {"iron-gear-wheel": {"inputs": {"iron-scrap": 2}, "outputs": {"iron-gear-wheel": 1}, "crafting_time": 0.5, "category": "crafting"}}}`
+	if err := os.WriteFile(broken, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       string
+		wantStatus int
+		wantOutput string
+	}{
+		{name: "built-in data", wantOutput: "No problems found"},
+		{name: "exported data", args: "--recipes " + base, wantOutput: "No problems found"},
+		{name: "unknown ingredient", args: "--recipes " + base + "," + broken, wantStatus: 1, wantOutput: "(unknown-ingredient)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestValidateDataExitStatus$")
+			cmd.Env = append(os.Environ(), "VALIDATE_DATA_ARGS="+tt.args)
+			output, err := cmd.CombinedOutput()
+
+			status := 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				status = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatalf("running validate-data: %v", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("exit status = %d, want %d; output:\n%s", status, tt.wantStatus, output)
+			}
+			if !strings.Contains(string(output), tt.wantOutput) {
+				t.Errorf("output does not contain %q:\n%s", tt.wantOutput, output)
+			}
+		})
+	}
+}
+
+func TestSeedWarning(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   string
+		seedSet bool
+		warn    bool
+	}{
+		{name: "compact with a seed", style: core.LayoutStyleCompact, seedSet: true, warn: true},
+		{name: "bus with a seed", style: core.LayoutStyleBus, seedSet: true, warn: true},
+		{name: "city-block with a seed", style: core.LayoutStyleCity, seedSet: true, warn: true},
+		{name: "default seed", style: core.LayoutStyleCompact},
+		{name: "custom style with a seed", style: "my-search", seedSet: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning := seedWarning(tt.style, tt.seedSet)
+			if (warning != "") != tt.warn {
+				t.Errorf("seedWarning(%q, %v) = %q, want a warning: %v", tt.style, tt.seedSet, warning, tt.warn)
+			}
+		})
+	}
+}
@@ -18,6 +18,8 @@ type BlueprintEntity struct {
 	Position     Position `json:"position"`
 	Direction    *int     `json:"direction,omitempty"`
 	Recipe       *string  `json:"recipe,omitempty"`
+	Type         string   `json:"type,omitempty"`    // "input" or "output" for underground belts
+	Station      string   `json:"station,omitempty"` // name of a train stop
 }
 
 // Position represents coordinates in blueprint format.
@@ -78,25 +80,43 @@ func (e *Exporter) ExportBlueprint(layout *core.FactoryLayout) (string, error) {
 		Version:  e.Version,
 	}
 
-	// Convert buildings to blueprint entities
+	// Convert buildings to blueprint entities, positioned at the center of
+	// their footprint as the game expects
 	for i, building := range layout.Buildings {
+		name := building.Entity
+		if name == "" {
+			name = e.getBlueprintEntityName(building.Type)
+		}
+		size := building.Footprint()
 		entity := BlueprintEntity{
 			EntityNumber: i + 1,
-			Name:         e.getBlueprintEntityName(building.Type),
+			Name:         name,
 			Position: Position{
-				X: float64(building.Position.X),
-				Y: float64(building.Position.Y),
+				X: float64(building.Position.X) + float64(size.Width)/2,
+				Y: float64(building.Position.Y) + float64(size.Height)/2,
 			},
 		}
 
+		if building.Type == core.EntityTypeUndergroundBelt {
+			entity.Type = building.Underground
+		}
+		if building.Type == core.EntityTypeTrainStop {
+			entity.Station = building.Label
+		}
+
 		// Add recipe if it's a crafting machine
 		if building.Recipe != "" {
 			entity.Recipe = &building.Recipe
 		}
 
-		// Add direction/rotation if specified
-		if building.Rotation != 0 {
-			direction := building.Rotation / 90 * 2 // Convert to Factorio direction format
+		// Add direction/rotation if specified. Layout inserters point where
+		// they drop items, while the game's point to where they pick up.
+		rotation := building.Rotation
+		if building.Type == core.EntityTypeInserter {
+			rotation = (rotation + 180) % 360
+		}
+		if rotation != 0 {
+			direction := rotation / 90 * 2 // Convert to Factorio direction format
 			entity.Direction = &direction
 		}
 
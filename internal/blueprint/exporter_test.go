@@ -0,0 +1,111 @@
+package blueprint
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+// decodeBlueprint reverses the exporter's encoding.
+func decodeBlueprint(t *testing.T, blueprintString string) Blueprint {
+	t.Helper()
+	compressed, err := base64.StdEncoding.DecodeString(blueprintString[1:])
+	if err != nil {
+		t.Fatalf("decode base64: %v", err)
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("open zlib: %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("decompress: %v", err)
+	}
+
+	var wrapper BlueprintWrapper
+	if err := json.Unmarshal(content, &wrapper); err != nil {
+		t.Fatalf("decode JSON: %v", err)
+	}
+	return wrapper.Blueprint
+}
+
+func TestExportBlueprintFootprints(t *testing.T) {
+	tests := []struct {
+		name      string
+		building  core.Building
+		entity    string
+		position  Position // center of the footprint
+		direction int      // game direction, 0 when none is written
+	}{
+		{
+			name:     "assembler",
+			building: core.Building{Type: "assembler", Entity: "assembling-machine-1", Position: core.Position{X: 0, Y: 0}, Size: core.Size{Width: 3, Height: 3}, Recipe: "iron-gear-wheel"},
+			entity:   "assembling-machine-1", position: Position{X: 1.5, Y: 1.5},
+		},
+		{
+			name:     "unsized building takes one tile",
+			building: core.Building{Type: "belt", Position: core.Position{X: 5, Y: 2}},
+			entity:   "transport-belt", position: Position{X: 5.5, Y: 2.5},
+		},
+		{
+			name:     "furnace",
+			building: core.Building{Type: "furnace", Entity: "stone-furnace", Position: core.Position{X: 4, Y: 4}, Size: core.Size{Width: 2, Height: 2}},
+			entity:   "stone-furnace", position: Position{X: 5, Y: 5},
+		},
+		{
+			name:     "rotated splitter",
+			building: core.Building{Type: core.EntityTypeSplitter, Entity: "splitter", Position: core.Position{X: 3, Y: 3}, Size: core.Size{Width: 2, Height: 1}, Rotation: 90},
+			entity:   "splitter", position: Position{X: 3.5, Y: 4}, direction: 2,
+		},
+		{
+			name:     "rotated boiler",
+			building: core.Building{Type: "boiler", Entity: "boiler", Position: core.Position{X: 10, Y: 0}, Size: core.Size{Width: 3, Height: 2}, Rotation: 270},
+			entity:   "boiler", position: Position{X: 11, Y: 1.5}, direction: 6,
+		},
+		{
+			name:     "inserter turned to its pickup",
+			building: core.Building{Type: core.EntityTypeInserter, Entity: "inserter", Position: core.Position{X: 7, Y: 7}, Size: core.Size{Width: 1, Height: 1}},
+			entity:   "inserter", position: Position{X: 7.5, Y: 7.5}, direction: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := &core.FactoryLayout{Title: "test", Buildings: []core.Building{tt.building}}
+			blueprintString, err := NewExporter().ExportBlueprint(layout)
+			if err != nil {
+				t.Fatalf("ExportBlueprint: %v", err)
+			}
+			if err := NewExporter().ValidateBlueprint(blueprintString); err != nil {
+				t.Fatalf("ValidateBlueprint: %v", err)
+			}
+
+			blueprint := decodeBlueprint(t, blueprintString)
+			if len(blueprint.Entities) != 1 {
+				t.Fatalf("got %d entities, want 1", len(blueprint.Entities))
+			}
+			entity := blueprint.Entities[0]
+			if entity.Name != tt.entity {
+				t.Errorf("name = %s, want %s", entity.Name, tt.entity)
+			}
+			if entity.Position != tt.position {
+				t.Errorf("position = %+v, want %+v", entity.Position, tt.position)
+			}
+			direction := 0
+			if entity.Direction != nil {
+				direction = *entity.Direction
+			}
+			if direction != tt.direction {
+				t.Errorf("direction = %d, want %d", direction, tt.direction)
+			}
+			if tt.building.Recipe != "" && (entity.Recipe == nil || *entity.Recipe != tt.building.Recipe) {
+				t.Errorf("recipe = %v, want %s", entity.Recipe, tt.building.Recipe)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Package core contains research bonuses applied during planning.
+package core
+
+// Technology modifier names as used by Factorio.
+const (
+	ModifierMiningProductivity    = "mining-drill-productivity-bonus"
+	ModifierInserterStackSize     = "inserter-stack-size-bonus"
+	ModifierStackInserterCapacity = "stack-inserter-capacity-bonus"
+	ModifierLabSpeed              = "laboratory-speed"
+	ModifierTrainBrakingForce     = "train-braking-force-bonus"
+)
+
+// ResearchBonuses is the typed set of bonuses granted by researched
+// technology modifiers. Fractional bonuses use 0.1 for +10%; stack size
+// bonuses are whole items added to the base stack size.
+type ResearchBonuses struct {
+	MiningProductivity    float64
+	InserterStackSize     float64
+	StackInserterCapacity float64
+	LabSpeed              float64
+	TrainBrakingForce     float64
+}
+
+// ApplyModifier adds a technology modifier's change to the matching bonus.
+// It reports false for modifiers the planner does not use.
+func (rb *ResearchBonuses) ApplyModifier(modifier string, change float64) bool {
+	switch modifier {
+	case ModifierMiningProductivity:
+		rb.MiningProductivity += change
+	case ModifierInserterStackSize:
+		rb.InserterStackSize += change
+	case ModifierStackInserterCapacity:
+		rb.StackInserterCapacity += change
+	case ModifierLabSpeed:
+		rb.LabSpeed += change
+	case ModifierTrainBrakingForce:
+		rb.TrainBrakingForce += change
+	default:
+		return false
+	}
+	return true
+}
+
+// StackSize returns the number of items an inserter moves per swing with
+// the bonuses applied.
+func (rb ResearchBonuses) StackSize(inserter *Inserter) float64 {
+	if inserter.Stack {
+		return 1 + rb.StackInserterCapacity
+	}
+	return 1 + rb.InserterStackSize
+}
@@ -0,0 +1,392 @@
+// Package core contains the main bus layout strategy.
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Main bus geometry. Lanes run south down every other column, leaving the
+// column right of each lane free for the splitter tapping it. Each recipe
+// gets a block: one row of machines east of the bus with input belts above
+// and below it and the product belt at the bottom.
+const (
+	busBlockGap     = 2  // free columns between the bus and the blocks
+	busTapClearance = 6  // rows above a block holding the splitters of its upper taps
+	busBlockRows    = 10 // rows a block takes besides its machine height
+	busBlockInputs  = 3  // items a block can take off the bus
+)
+
+// BusLane is one belt of the main bus.
+type BusLane struct {
+	Item  string
+	Belt  string // belt tier of the lane
+	X     int    // column the lane runs down
+	Start int    // first row, 0 for items entering at the top edge
+	End   int    // row where the lane turns into its last tap
+}
+
+// busBlock is the machines of one recipe beside the bus.
+type busBlock struct {
+	recipe   string
+	inputs   []InserterRequirement // items taken off the bus
+	output   *InserterRequirement  // product, nil for none
+	machines []Position            // top-left tiles of the block's machines
+	ids      []string              // IDs of the block's machines
+	top      int                   // top row of the machines
+	height   int                   // machine height
+	width    int                   // machine width
+}
+
+// busTap is a belt taking an item off a bus lane into a block.
+type busTap struct {
+	lane  int
+	row   int  // row the tap belt runs east along
+	far   bool // a long-handed inserter reaches over the near belt for it
+	upper bool // the belt runs above the machines
+	input InserterRequirement
+	block *busBlock
+}
+
+// busSpan is a stretch of a lane passing under tap belts, from its
+// underground entrance row to its exit row.
+type busSpan struct {
+	entrance, exit int
+}
+
+// generateBusLayout lays out a main bus: one lane per belt of every item
+// the blocks take, entering at the top edge or from the block making it.
+// Lanes whose items are used first run nearest the blocks. Splitters tap
+// the lanes, and the bus passes underground where tap belts cross it.
+// Blocks follow their producers, and their products go back onto the bus
+// or leave at the right edge when they are targets. Upper far and product
+// belts need long-handed inserters.
+func (lg *LayoutGenerator) generateBusLayout(plan *ProductionPlan) (*FactoryLayout, error) {
+	if len(lg.Belts) == 0 {
+		return nil, fmt.Errorf("main bus layout needs an unlocked belt")
+	}
+	busBelt := lg.Belts[0]
+	for _, belt := range lg.Belts {
+		if belt.Throughput > busBelt.Throughput {
+			busBelt = belt
+		}
+	}
+	if busBelt.Splitter == "" || busBelt.Underground == "" {
+		return nil, fmt.Errorf("main bus layout needs the splitter and underground belt of %s", busBelt.Name)
+	}
+
+	layout := &FactoryLayout{Title: lg.title(plan)}
+	blocks := lg.busBlocks(plan)
+
+	// Items on the bus, in order of first use
+	var items []string
+	consumers := make(map[string][]int)
+	for b := range blocks {
+		for _, input := range blocks[b].inputs {
+			if len(consumers[input.Item]) == 0 {
+				items = append(items, input.Item)
+			}
+			consumers[input.Item] = append(consumers[input.Item], b)
+		}
+	}
+	producers := make(map[string]int) // item -> block putting it on the bus
+	for b := range blocks {
+		if output := blocks[b].output; output != nil && len(consumers[output.Item]) > 0 && b < consumers[output.Item][0] {
+			if _, exists := producers[output.Item]; !exists {
+				producers[output.Item] = b
+			}
+		}
+	}
+
+	// Raw items get a lane per belt needed, up to one per block using
+	// them; intermediates get the one lane their block puts them on.
+	var lanes []BusLane
+	itemLanes := make(map[string][]int)
+	for _, item := range items {
+		count := 1
+		if _, intermediate := producers[item]; !intermediate {
+			count = int(math.Ceil(plan.ResourceFlow[item]/60/busBelt.Throughput - 1e-9))
+			count = min(max(count, 1), len(consumers[item]))
+		}
+		for i := 0; i < count; i++ {
+			itemLanes[item] = append(itemLanes[item], len(lanes))
+			lanes = append(lanes, BusLane{Item: item, Belt: busBelt.Name})
+		}
+	}
+	for i := range lanes {
+		lanes[i].X = layoutMargin + 2*(len(lanes)-1-i)
+	}
+	busRight := layoutMargin + 2*len(lanes)
+
+	// Machines
+	y, right, bottom := layoutMargin+busTapClearance, busRight+busBlockGap, 0
+	for b := range blocks {
+		block := &blocks[b]
+		_, _, size := lg.machineFor(plan.MachineTypes[block.recipe])
+		block.top, block.height, block.width = y, size.Height, size.Width
+		for i := 0; i < plan.RequiredMachines[block.recipe]; i++ {
+			position := Position{X: busRight + busBlockGap + i*(size.Width+1), Y: y}
+			machine := lg.MachineBuilding(len(layout.Buildings), plan, block.recipe, position)
+			block.machines = append(block.machines, position)
+			block.ids = append(block.ids, machine.ID)
+			layout.Buildings = append(layout.Buildings, machine)
+			right = max(right, position.X+size.Width)
+		}
+		bottom = y + size.Height + 4
+		y += size.Height + busBlockRows
+	}
+	layout.Width = right + layoutMargin
+	layout.Height = bottom + layoutMargin
+
+	// Taps: two above each block, the one further right on the far row so
+	// neither tap belt crosses the other's lane, and one below.
+	var taps []busTap
+	turn := make(map[string]int)
+	for b := range blocks {
+		block := &blocks[b]
+		var upper []busTap
+		for i, input := range block.inputs {
+			if i >= busBlockInputs {
+				for _, id := range block.ids {
+					layout.Unrouted = append(layout.Unrouted, BeltRoute{Item: input.Item, To: id, Rate: input.Rate})
+				}
+				continue
+			}
+			laneIndices := itemLanes[input.Item]
+			tap := busTap{lane: laneIndices[turn[input.Item]%len(laneIndices)], input: input, block: block}
+			turn[input.Item]++
+			if i < 2 {
+				tap.upper = true
+				upper = append(upper, tap)
+			} else {
+				tap.row = block.top + block.height + 1
+				taps = append(taps, tap)
+			}
+		}
+		sort.SliceStable(upper, func(i, j int) bool {
+			return lanes[upper[i].lane].X > lanes[upper[j].lane].X
+		})
+		for i := range upper {
+			upper[i].far = len(upper) == 2 && i == 0
+			upper[i].row = block.top - 2
+			if upper[i].far {
+				upper[i].row = block.top - 3
+			}
+		}
+		taps = append(taps, upper...)
+	}
+	sort.SliceStable(taps, func(i, j int) bool { return taps[i].row < taps[j].row })
+
+	for i := range lanes {
+		lanes[i].End = -1
+	}
+	for _, tap := range taps {
+		lanes[tap.lane].End = max(lanes[tap.lane].End, tap.row)
+	}
+	for item, b := range producers {
+		lanes[itemLanes[item][0]].Start = blocks[b].top + blocks[b].height + 2
+	}
+
+	// Lanes right of a tap or product belt pass under it, from the row
+	// above the block's belts to the row below them.
+	spans := make(map[int][]busSpan)
+	for b := range blocks {
+		block := &blocks[b]
+		bands := []struct {
+			top, bottom int
+			crossing    []int // lanes whose belts cross the bus in this band
+		}{
+			{top: block.top - 3, bottom: block.top - 2},
+			{top: block.top + block.height + 1, bottom: block.top + block.height + 2},
+		}
+		for _, tap := range taps {
+			if tap.block != block {
+				continue
+			}
+			band := &bands[0]
+			if !tap.upper {
+				band = &bands[1]
+			}
+			band.crossing = append(band.crossing, tap.lane)
+		}
+		if block.output != nil {
+			if _, onBus := producers[block.output.Item]; onBus && producers[block.output.Item] == b {
+				bands[1].crossing = append(bands[1].crossing, itemLanes[block.output.Item][0])
+			}
+		}
+
+		for _, band := range bands {
+			if len(band.crossing) == 0 {
+				continue
+			}
+			leftmost := lanes[band.crossing[0]].X
+			for _, lane := range band.crossing {
+				leftmost = min(leftmost, lanes[lane].X)
+			}
+			for i, lane := range lanes {
+				if lane.X > leftmost && lane.Start < band.top-1 && lane.End > band.bottom+1 {
+					spans[i] = append(spans[i], busSpan{entrance: band.top - 1, exit: band.bottom + 1})
+				}
+			}
+		}
+	}
+
+	splitters := make(map[Position]bool)
+	for _, tap := range taps {
+		lane := lanes[tap.lane]
+		if tap.row != lane.End {
+			splitters[Position{X: lane.X, Y: tap.row - 3}] = true
+		}
+	}
+
+	// Lane belts
+	for i, lane := range lanes {
+		for row := lane.Start; row <= lane.End; row++ {
+			tile := Position{X: lane.X, Y: row}
+			switch {
+			case row == lane.End:
+				lg.addBelt(layout, busBelt, tile, 90, "")
+			case splitters[tile]:
+				layout.Buildings = append(layout.Buildings, Building{
+					ID:       fmt.Sprintf("splitter_%d", len(layout.Buildings)),
+					Type:     EntityTypeSplitter,
+					Entity:   busBelt.Splitter,
+					Position: tile,
+					Size:     Size{Width: 2, Height: 1},
+					Rotation: 180,
+					Color:    lg.buildingColor(busBelt.Splitter),
+				})
+			default:
+				underground, hidden := "", false
+				for _, span := range spans[i] {
+					switch {
+					case row == span.entrance:
+						underground = UndergroundInput
+					case row == span.exit:
+						underground = UndergroundOutput
+					case row > span.entrance && row < span.exit:
+						hidden = true
+					}
+				}
+				if !hidden {
+					lg.addBelt(layout, busBelt, tile, 180, underground)
+				}
+			}
+		}
+	}
+
+	// Tap belts: down the column right of the lane from its splitter, then
+	// east past every machine of the block
+	for _, tap := range taps {
+		lane := lanes[tap.lane]
+		belt := lg.beltFor(tap.input.Rate * float64(len(tap.block.machines)))
+		if tap.row != lane.End {
+			for row := tap.row - 2; row < tap.row; row++ {
+				lg.addBelt(layout, belt, Position{X: lane.X + 1, Y: row}, 180, "")
+			}
+		}
+		end := tap.block.machines[len(tap.block.machines)-1].X
+		if tap.far {
+			end++
+		}
+		for x := lane.X + 1; x <= end; x++ {
+			lg.addBelt(layout, belt, Position{X: x, Y: tap.row}, 90, "")
+		}
+
+		reach, rotation, row := 1, 180, tap.block.top-1
+		if tap.far {
+			reach = 2
+		}
+		if !tap.upper {
+			rotation, row = 0, tap.block.top+tap.block.height
+		}
+		for _, machine := range tap.block.machines {
+			position := Position{X: machine.X, Y: row}
+			if tap.far {
+				position.X++
+			}
+			lg.addInserter(layout, position, rotation, reach, tap.input.Rate)
+		}
+	}
+
+	// Product belts: back west onto the bus, or east off the layout for
+	// targets. Long-handed inserters reach over the lower input belt.
+	for b := range blocks {
+		block := &blocks[b]
+		if block.output == nil || len(block.machines) == 0 {
+			continue
+		}
+		row := block.top + block.height + 2
+		belt := lg.beltFor(block.output.Rate * float64(len(block.machines)))
+		first, last := block.machines[0].X+1, block.machines[len(block.machines)-1].X+1
+		switch producer, onBus := producers[block.output.Item]; {
+		case onBus && producer == b:
+			for x := last; x > lanes[itemLanes[block.output.Item][0]].X; x-- {
+				lg.addBelt(layout, belt, Position{X: x, Y: row}, 270, "")
+			}
+		case lg.isTarget(plan, block.output.Item):
+			for x := first; x < layout.Width; x++ {
+				lg.addBelt(layout, belt, Position{X: x, Y: row}, 90, "")
+			}
+		default:
+			continue
+		}
+		for _, machine := range block.machines {
+			lg.addInserter(layout, Position{X: machine.X + 1, Y: block.top + block.height}, 180, 2, block.output.Rate)
+		}
+	}
+
+	layout.Bus = lanes
+	lg.routePipes(plan, layout)
+	lg.placePoles(layout)
+	return layout, nil
+}
+
+// busBlocks returns a block per recipe of the plan, in producer order.
+func (lg *LayoutGenerator) busBlocks(plan *ProductionPlan) []busBlock {
+	var blocks []busBlock
+	for _, recipeName := range producerOrder(plan) {
+		block := busBlock{recipe: recipeName}
+		for _, requirement := range plan.InserterRequirements[recipeName] {
+			if !requirement.Output {
+				block.inputs = append(block.inputs, requirement)
+			} else if block.output == nil {
+				output := requirement
+				block.output = &output
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// addBelt places a belt, or an underground belt end when underground is
+// UndergroundInput or UndergroundOutput.
+func (lg *LayoutGenerator) addBelt(layout *FactoryLayout, belt *Belt, position Position, rotation int, underground string) {
+	building := Building{
+		ID:          fmt.Sprintf("belt_%d", len(layout.Buildings)),
+		Type:        EntityTypeBelt,
+		Entity:      belt.Name,
+		Position:    position,
+		Size:        Size{Width: 1, Height: 1},
+		Rotation:    rotation,
+		Underground: underground,
+	}
+	if underground != "" {
+		building.Type = EntityTypeUndergroundBelt
+		building.Entity = belt.Underground
+	}
+	building.Color = lg.buildingColor(building.Entity)
+	layout.Buildings = append(layout.Buildings, building)
+}
+
+// isTarget reports whether an item is one of the plan's targets.
+func (lg *LayoutGenerator) isTarget(plan *ProductionPlan, item string) bool {
+	for _, target := range plan.Targets {
+		if target.Item == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+// testLayoutGenerator returns a generator with one belt tier, an inserter,
+// a long-handed inserter, a pole and 3x3 electric assemblers.
+func testLayoutGenerator() *LayoutGenerator {
+	lg := NewLayoutGenerator()
+	lg.Entities = testEntities{
+		"assembler":            validationEntities["assembler"],
+		"inserter":             validationEntities["inserter"],
+		"long-handed-inserter": {Name: "long-handed-inserter", Type: EntityTypeInserter, EnergySource: EnergySourceElectric, Reach: 2},
+		"small-pole":           {Name: "small-pole", Type: EntityTypeElectricPole, Width: 1, Height: 1, SupplyArea: 5, WireReach: 7.5},
+	}
+	lg.Belts = []*Belt{{Name: "belt", Throughput: 15, Underground: "underground-belt", UndergroundLength: 5, Splitter: "splitter"}}
+	lg.Inserters = []*Inserter{{Name: "inserter", SwingsPerSecond: 0.83}, {Name: "long-handed-inserter", SwingsPerSecond: 1.2}}
+	lg.Poles = []*Entity{{Name: "small-pole", Type: EntityTypeElectricPole, Width: 1, Height: 1, SupplyArea: 5, WireReach: 7.5}}
+	return lg
+}
+
+// testInserterPlan makes inserters from plates: gears and cables feed
+// circuits, and circuits, gears and plates feed the inserters. Rates of
+// the machines' items are per second and machine.
+func testInserterPlan() *ProductionPlan {
+	item := func(name string, rate float64, output bool) InserterRequirement {
+		return InserterRequirement{Item: name, Rate: rate, Inserter: "inserter", Count: 1, Output: output}
+	}
+	return &ProductionPlan{
+		Targets:          []ProductionTarget{{Item: "inserter", Rate: 60}},
+		RequiredMachines: map[string]int{"iron-gear-wheel": 1, "copper-cable": 2, "electronic-circuit": 2, "inserter": 2},
+		MachineTypes: map[string]string{
+			"iron-gear-wheel": "assembler", "copper-cable": "assembler", "electronic-circuit": "assembler", "inserter": "assembler",
+		},
+		InserterRequirements: map[string][]InserterRequirement{
+			"iron-gear-wheel":    {item("iron-plate", 2, false), item("iron-gear-wheel", 1, true)},
+			"copper-cable":       {item("copper-plate", 1.5, false), item("copper-cable", 3, true)},
+			"electronic-circuit": {item("iron-plate", 0.5, false), item("copper-cable", 1.5, false), item("electronic-circuit", 0.5, true)},
+			"inserter":           {item("electronic-circuit", 0.5, false), item("iron-gear-wheel", 0.5, false), item("iron-plate", 0.5, false), item("inserter", 0.5, true)},
+		},
+		ResourceFlow: map[string]float64{"iron-plate": 240, "copper-plate": 180},
+	}
+}
+
+func TestGenerateBusLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(lg *LayoutGenerator, plan *ProductionPlan)
+		wantLanes []string // items of the bus lanes, nearest the blocks first
+		unrouted  int
+		wantErr   bool
+	}{
+		{
+			name:      "lanes in order of first use",
+			wantLanes: []string{"copper-plate", "iron-plate", "copper-cable", "electronic-circuit", "iron-gear-wheel"},
+		},
+		{
+			name:      "a lane per belt of a raw item",
+			change:    func(lg *LayoutGenerator, plan *ProductionPlan) { plan.ResourceFlow["iron-plate"] = 1200 },
+			wantLanes: []string{"copper-plate", "iron-plate", "iron-plate", "copper-cable", "electronic-circuit", "iron-gear-wheel"},
+		},
+		{
+			name: "more inputs than a block takes",
+			change: func(lg *LayoutGenerator, plan *ProductionPlan) {
+				plan.InserterRequirements["inserter"] = append(plan.InserterRequirements["inserter"],
+					InserterRequirement{Item: "copper-plate", Rate: 0.5, Inserter: "inserter", Count: 1})
+			},
+			wantLanes: []string{"copper-plate", "iron-plate", "copper-cable", "electronic-circuit", "iron-gear-wheel"},
+			unrouted:  2,
+		},
+		{
+			name:    "no belt",
+			change:  func(lg *LayoutGenerator, plan *ProductionPlan) { lg.Belts = nil },
+			wantErr: true,
+		},
+		{
+			name:    "no splitter",
+			change:  func(lg *LayoutGenerator, plan *ProductionPlan) { lg.Belts[0].Splitter = "" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg, plan := testLayoutGenerator(), testInserterPlan()
+			lg.Style = LayoutStyleBus
+			if tt.change != nil {
+				tt.change(lg, plan)
+			}
+
+			layout, err := lg.GenerateLayout(plan)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("GenerateLayout succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateLayout: %v", err)
+			}
+
+			var lanes []string
+			for i, lane := range layout.Bus {
+				lanes = append(lanes, lane.Item)
+				if i > 0 && lane.X != layout.Bus[i-1].X-2 {
+					t.Errorf("lane %d runs down column %d, want %d", i, lane.X, layout.Bus[i-1].X-2)
+				}
+				if _, raw := plan.ResourceFlow[lane.Item]; raw != (lane.Start == 0) {
+					t.Errorf("%s lane starts at row %d, want raw items at the top edge and the rest below their block", lane.Item, lane.Start)
+				}
+				if lane.End < lane.Start {
+					t.Errorf("%s lane ends at row %d before it starts at row %d", lane.Item, lane.End, lane.Start)
+				}
+			}
+			if !slices.Equal(lanes, tt.wantLanes) {
+				t.Errorf("lanes = %v, want %v", lanes, tt.wantLanes)
+			}
+			if len(layout.Unrouted) != tt.unrouted {
+				t.Errorf("%d unrouted belt lines, want %d", len(layout.Unrouted), tt.unrouted)
+			}
+
+			for _, violation := range lg.ValidateLayout(layout) {
+				if violation.Rule != RuleUnroutedBelt {
+					t.Errorf("violation %s", violation)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+// Package core contains the city-block layout strategy.
+package core
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultCityBlockSize is the side in tiles of a city block, counting the
+// rails along its top and left edges.
+const DefaultCityBlockSize = 100
+
+// City-block geometry and train assumptions.
+const (
+	cityRailWidth          = 2     // straight rails are 2x2
+	cityStopSize           = 2     // train stops are 2x2
+	cityStopSpacing        = 4     // rows from one train stop of a block side to the next
+	cityTrainWagons        = 4     // cargo or fluid wagons per train
+	cityWagonSlots         = 40    // item stacks in a cargo wagon
+	cityFluidWagonCapacity = 25000 // fluid units in a fluid wagon
+	cityRoundTrip          = 180.0 // seconds between two visits of a train to the same station, without bonuses
+	cityBrakingTime        = 40.0  // seconds of the round trip spent braking without bonuses
+	cityDefaultStackSize   = 50    // stack size of items without data
+)
+
+// StackSizeProvider provides the stack sizes of items.
+type StackSizeProvider interface {
+	GetStackSize(itemName string) (int, bool)
+}
+
+// TrainStation is a train stop at the edge of a city block: unloading an
+// input of the block or loading its product.
+type TrainStation struct {
+	Name    string
+	Item    string
+	Stop    string  // ID of the train stop building
+	Block   int     // index of the block, in row order
+	Loading bool    // trains take the block's product away
+	Rate    float64 // items or fluid units per second
+	Trains  int     // trains needed to move the rate
+}
+
+// cityBlock is one block of a city-block layout before it is placed.
+type cityBlock struct {
+	recipe   string
+	machines int
+	layout   *FactoryLayout // compact layout of the block's machines
+}
+
+// generateCityBlockLayout splits the plan into blocks of BlockSize tiles
+// with rails along their edges. Each block holds the machines of one
+// recipe, laid out compactly, with unloading stations for its inputs on the
+// left and loading stations for its products on the right. Recipes with
+// more machines than fit in a block get several blocks. Blocks follow the
+// blocks making their inputs, in rows of a square grid.
+func (lg *LayoutGenerator) generateCityBlockLayout(plan *ProductionPlan) (*FactoryLayout, error) {
+	size := lg.BlockSize
+	if size == 0 {
+		size = DefaultCityBlockSize
+	}
+	if size%cityRailWidth != 0 || size < 2*cityStopSpacing+cityRailWidth {
+		return nil, fmt.Errorf("city block size %d must be even and at least %d", size, 2*cityStopSpacing+cityRailWidth)
+	}
+	interior := size - cityRailWidth
+
+	var blocks []cityBlock
+	for _, order := range lg.busBlocks(plan) {
+		total := plan.RequiredMachines[order.recipe]
+		perBlock := total
+		stations := cityStationRows(plan, order.recipe)
+		for {
+			sub := lg.cityBlockLayout(plan, order.recipe, perBlock)
+			if sub.Width+2 <= interior && stations+sub.Height <= interior {
+				break
+			}
+			if perBlock == 1 {
+				return nil, fmt.Errorf("a %s machine does not fit in a %dx%d city block", order.recipe, size, size)
+			}
+			perBlock = (perBlock + 1) / 2
+		}
+		for placed := 0; placed < total; placed += perBlock {
+			count := min(perBlock, total-placed)
+			blocks = append(blocks, cityBlock{recipe: order.recipe, machines: count, layout: lg.cityBlockLayout(plan, order.recipe, count)})
+		}
+	}
+
+	columns := max(int(math.Ceil(math.Sqrt(float64(len(blocks))))), 1)
+	rows := max((len(blocks)+columns-1)/columns, 1)
+	layout := &FactoryLayout{
+		Title:  lg.title(plan),
+		Width:  columns*size + cityRailWidth,
+		Height: rows*size + cityRailWidth,
+	}
+
+	// Rails: vertical lines first, then horizontal ones between them
+	for column := 0; column <= columns; column++ {
+		for y := 0; y < layout.Height; y += cityRailWidth {
+			lg.addRail(layout, Position{X: column * size, Y: y}, 0)
+		}
+	}
+	for row := 0; row <= rows; row++ {
+		for x := 0; x < layout.Width; x += cityRailWidth {
+			if x%size >= cityRailWidth {
+				lg.addRail(layout, Position{X: x, Y: row * size}, 90)
+			}
+		}
+	}
+
+	for b, block := range blocks {
+		origin := Position{X: b%columns*size + cityRailWidth, Y: b/columns*size + cityRailWidth}
+		lg.placeCityBlock(layout, b, lg.cityBlockContents(plan, block, interior), origin)
+	}
+	return layout, nil
+}
+
+// cityBlockLayout lays out a number of machines of one recipe compactly,
+// with its inputs entering at the left edge and its products leaving at
+// the right edge. Poles are left to the whole block.
+func (lg *LayoutGenerator) cityBlockLayout(plan *ProductionPlan, recipeName string, machines int) *FactoryLayout {
+	sub := &ProductionPlan{
+		RequiredMachines:     map[string]int{recipeName: machines},
+		MachineTypes:         map[string]string{recipeName: plan.MachineTypes[recipeName]},
+		InserterRequirements: map[string][]InserterRequirement{recipeName: plan.InserterRequirements[recipeName]},
+		FluidRequirements:    map[string][]FluidRequirement{recipeName: plan.FluidRequirements[recipeName]},
+	}
+	for _, requirement := range plan.InserterRequirements[recipeName] {
+		if requirement.Output {
+			sub.Targets = append(sub.Targets, ProductionTarget{Item: requirement.Item, Rate: requirement.Rate * float64(machines) * 60})
+		}
+	}
+	for _, requirement := range plan.FluidRequirements[recipeName] {
+		if requirement.Output {
+			sub.Targets = append(sub.Targets, ProductionTarget{Item: requirement.Fluid, Rate: requirement.Rate * float64(machines) * 60})
+		}
+	}
+	generator := *lg
+	generator.Poles = nil
+	return generator.generateCompactLayout(sub)
+}
+
+// cityStationRows returns the rows the train stops of a recipe's block
+// take at its top.
+func cityStationRows(plan *ProductionPlan, recipeName string) int {
+	inputs, outputs := 0, 0
+	for _, requirement := range plan.InserterRequirements[recipeName] {
+		if requirement.Output {
+			outputs++
+		} else {
+			inputs++
+		}
+	}
+	for _, requirement := range plan.FluidRequirements[recipeName] {
+		if requirement.Output {
+			outputs++
+		} else {
+			inputs++
+		}
+	}
+	return max(inputs, outputs) * cityStopSpacing
+}
+
+// cityBlockContents lays out the inside of a block, interior tiles square,
+// in block coordinates. Train stops take the top of the block, on the right
+// of the track: heading north along the left rail and south along the
+// right one. The machines go below them. Belts entering the machines are
+// fed by an inserter unloading wagons on the left rail, and belts leaving
+// them run on to an inserter loading wagons on the right rail.
+func (lg *LayoutGenerator) cityBlockContents(plan *ProductionPlan, block cityBlock, interior int) *FactoryLayout {
+	contents := &FactoryLayout{Width: interior, Height: interior}
+	inputs, outputs := lg.cityBlockFlows(plan, block)
+	for i, flow := range inputs {
+		lg.addStation(contents, flow, false, Position{Y: i * cityStopSpacing}, 0)
+	}
+	for i, flow := range outputs {
+		lg.addStation(contents, flow, true, Position{X: interior - cityStopSize, Y: i * cityStopSpacing}, 180)
+	}
+
+	offset := Position{X: 1, Y: cityStationRows(plan, block.recipe)}
+	for _, building := range block.layout.Buildings {
+		building.Position = building.Position.Add(offset)
+		contents.Buildings = append(contents.Buildings, building)
+	}
+	for _, routes := range []struct{ from, to *[]BeltRoute }{
+		{&block.layout.Routes, &contents.Routes},
+		{&block.layout.Unrouted, &contents.Unrouted},
+	} {
+		for _, route := range *routes.from {
+			*routes.to = append(*routes.to, route.moved(offset))
+		}
+	}
+	for _, pipes := range []struct{ from, to *[]PipeRoute }{
+		{&block.layout.Pipes, &contents.Pipes},
+		{&block.layout.UnroutedPipes, &contents.UnroutedPipes},
+	} {
+		for _, route := range *pipes.from {
+			*pipes.to = append(*pipes.to, route.moved(offset))
+		}
+	}
+
+	for _, route := range contents.Routes {
+		if route.From == "" {
+			lg.addInserter(contents, route.Start.Add(Position{X: -1}), 90, 1, route.Rate)
+		}
+		if route.To == "" {
+			belt := lg.beltNamed(route.Belt)
+			for x := route.End.X + 1; x < interior-1 && belt != nil; x++ {
+				lg.addBelt(contents, belt, Position{X: x, Y: route.End.Y}, 90, "")
+			}
+			lg.addInserter(contents, Position{X: interior - 1, Y: route.End.Y}, 90, 1, route.Rate)
+		}
+	}
+	lg.placePoles(contents)
+	return contents
+}
+
+// placeCityBlock copies the contents of a block into the city layout at
+// its origin, prefixing building IDs with the block.
+func (lg *LayoutGenerator) placeCityBlock(layout *FactoryLayout, index int, contents *FactoryLayout, origin Position) {
+	id := func(buildingID string) string {
+		if buildingID == "" {
+			return ""
+		}
+		return fmt.Sprintf("block_%d/%s", index, buildingID)
+	}
+
+	for _, building := range contents.Buildings {
+		building.ID = id(building.ID)
+		building.Position = building.Position.Add(origin)
+		layout.Buildings = append(layout.Buildings, building)
+	}
+	for _, routes := range []struct{ from, to *[]BeltRoute }{
+		{&contents.Routes, &layout.Routes},
+		{&contents.Unrouted, &layout.Unrouted},
+	} {
+		for _, route := range *routes.from {
+			route = route.moved(origin)
+			route.From, route.To = id(route.From), id(route.To)
+			for i := range route.Stops {
+				route.Stops[i].Building = id(route.Stops[i].Building)
+			}
+			*routes.to = append(*routes.to, route)
+		}
+	}
+	for _, pipes := range []struct{ from, to *[]PipeRoute }{
+		{&contents.Pipes, &layout.Pipes},
+		{&contents.UnroutedPipes, &layout.UnroutedPipes},
+	} {
+		for _, route := range *pipes.from {
+			route = route.moved(origin)
+			route.From, route.To = id(route.From), id(route.To)
+			*pipes.to = append(*pipes.to, route)
+		}
+	}
+	for _, station := range contents.Stations {
+		station.Stop, station.Block = id(station.Stop), index
+		layout.Stations = append(layout.Stations, station)
+	}
+	if layout.PowerConnection == "" {
+		layout.PowerConnection = id(contents.PowerConnection)
+	}
+}
+
+// cityFlow is an item or fluid moved by train to or from a block.
+type cityFlow struct {
+	item  string
+	rate  float64 // per second
+	fluid bool
+}
+
+// cityBlockFlows returns the items and fluids a block takes in and the ones
+// it makes, at the rates the plan needs: machines built beyond the plan's
+// exact count share the work, so each runs below its full rate.
+func (lg *LayoutGenerator) cityBlockFlows(plan *ProductionPlan, block cityBlock) (inputs, outputs []cityFlow) {
+	machines := float64(block.machines)
+	if utilization, exists := plan.Utilization[block.recipe]; exists {
+		machines *= utilization
+	}
+	for _, requirement := range plan.InserterRequirements[block.recipe] {
+		flow := cityFlow{item: requirement.Item, rate: requirement.Rate * machines}
+		if requirement.Output {
+			outputs = append(outputs, flow)
+		} else {
+			inputs = append(inputs, flow)
+		}
+	}
+	for _, requirement := range plan.FluidRequirements[block.recipe] {
+		flow := cityFlow{item: requirement.Fluid, rate: requirement.Rate * machines, fluid: true}
+		if requirement.Output {
+			outputs = append(outputs, flow)
+		} else {
+			inputs = append(inputs, flow)
+		}
+	}
+	return inputs, outputs
+}
+
+// beltNamed returns the unlocked belt tier of a name, nil when it is not
+// unlocked.
+func (lg *LayoutGenerator) beltNamed(name string) *Belt {
+	for _, belt := range lg.Belts {
+		if belt.Name == name {
+			return belt
+		}
+	}
+	return nil
+}
+
+// addRail places a straight rail running along the rotation.
+func (lg *LayoutGenerator) addRail(layout *FactoryLayout, position Position, rotation int) {
+	layout.Buildings = append(layout.Buildings, Building{
+		ID:       fmt.Sprintf("rail_%d", len(layout.Buildings)),
+		Type:     EntityTypeRail,
+		Entity:   "straight-rail",
+		Position: position,
+		Size:     Size{Width: cityRailWidth, Height: cityRailWidth},
+		Rotation: rotation,
+		Color:    lg.buildingColor("rail"),
+	})
+}
+
+// addStation places the train stop of a block's flow and records its
+// station. Stations of one item share a name so trains serve them all.
+func (lg *LayoutGenerator) addStation(layout *FactoryLayout, flow cityFlow, loading bool, position Position, rotation int) {
+	itemName := flow.item
+	if lg.NameProvider != nil {
+		itemName = lg.NameProvider.ItemName(flow.item)
+	}
+	name := itemName + " unloading"
+	if loading {
+		name = itemName + " loading"
+	}
+
+	id := fmt.Sprintf("stop_%d", len(layout.Buildings))
+	layout.Buildings = append(layout.Buildings, Building{
+		ID:       id,
+		Type:     EntityTypeTrainStop,
+		Entity:   "train-stop",
+		Position: position,
+		Size:     Size{Width: cityStopSize, Height: cityStopSize},
+		Rotation: rotation,
+		Label:    name,
+		Color:    lg.buildingColor("train-stop"),
+	})
+	layout.Stations = append(layout.Stations, TrainStation{
+		Name:    name,
+		Item:    flow.item,
+		Stop:    id,
+		Loading: loading,
+		Rate:    flow.rate,
+		Trains:  lg.trainsFor(flow),
+	})
+}
+
+// trainsFor returns the trains needed for a flow when each train comes
+// back once per round trip.
+func (lg *LayoutGenerator) trainsFor(flow cityFlow) int {
+	capacity := float64(cityTrainWagons * cityFluidWagonCapacity)
+	if !flow.fluid {
+		stackSize := cityDefaultStackSize
+		if lg.StackSizes != nil {
+			if size, exists := lg.StackSizes.GetStackSize(flow.item); exists && size > 0 {
+				stackSize = size
+			}
+		}
+		capacity = float64(cityTrainWagons * cityWagonSlots * stackSize)
+	}
+	return max(int(math.Ceil(flow.rate*lg.cityRoundTrip()/capacity-1e-9)), 1)
+}
+
+// cityRoundTrip returns the seconds between two visits of a train to the
+// same station. Braking force research shortens the time spent braking.
+func (lg *LayoutGenerator) cityRoundTrip() float64 {
+	return cityRoundTrip - cityBrakingTime + cityBrakingTime/(1+lg.Bonuses.TrainBrakingForce)
+}
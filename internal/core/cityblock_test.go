@@ -0,0 +1,172 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+// testStackSizes is a stack size provider backed by a map.
+type testStackSizes map[string]int
+
+func (s testStackSizes) GetStackSize(itemName string) (int, bool) {
+	size, exists := s[itemName]
+	return size, exists
+}
+
+// testOilPlan has two plants making petroleum gas from crude oil, run at
+// half their rate, feed plastic made in two plants with coal. Fluid rates
+// are per second and machine.
+func testOilPlan() *ProductionPlan {
+	fluid := func(name string, rate float64, output bool) FluidRequirement {
+		return FluidRequirement{Fluid: name, Rate: rate, Output: output}
+	}
+	return &ProductionPlan{
+		Targets:          []ProductionTarget{{Item: "plastic-bar", Rate: 60}},
+		RequiredMachines: map[string]int{"oil-processing": 2, "plastic-bar": 2},
+		Utilization:      map[string]float64{"oil-processing": 0.5, "plastic-bar": 0.5},
+		MachineTypes:     map[string]string{"oil-processing": "plant", "plastic-bar": "plant"},
+		InserterRequirements: map[string][]InserterRequirement{
+			"plastic-bar": {
+				{Item: "coal", Rate: 1, Inserter: "inserter", Count: 1},
+				{Item: "plastic-bar", Rate: 2, Inserter: "inserter", Count: 1, Output: true},
+			},
+		},
+		FluidRequirements: map[string][]FluidRequirement{
+			"oil-processing": {fluid("crude-oil", 20, false), fluid("petroleum-gas", 10, true)},
+			"plastic-bar":    {fluid("petroleum-gas", 10, false)},
+		},
+	}
+}
+
+func TestGenerateCityBlockLayout(t *testing.T) {
+	tests := []struct {
+		name       string
+		blockSize  int
+		machines   int  // inserter machines, 2 when zero
+		oil        bool // lay out testOilPlan instead
+		wantBlocks int
+		wantRates  map[string]float64 // per second at the stations of an item, loading and unloading
+		wantErr    bool
+	}{
+		{name: "default block size", wantBlocks: 4},
+		{
+			name: "piped blocks", oil: true, wantBlocks: 2,
+			wantRates: map[string]float64{"crude-oil": 20, "petroleum-gas": 10, "plastic-bar": 2},
+		},
+		{name: "small blocks", blockSize: 40, wantBlocks: 4},
+		{name: "recipe split over blocks", blockSize: 40, machines: 20, wantBlocks: 7},
+		{name: "odd block size", blockSize: 41, wantErr: true},
+		{name: "block too small for its stations", blockSize: 8, wantErr: true},
+		{name: "machine does not fit", blockSize: 16, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg, plan := testLayoutGenerator(), testInserterPlan()
+			lg.Style = LayoutStyleCity
+			lg.BlockSize = tt.blockSize
+			if tt.machines > 0 {
+				plan.RequiredMachines["inserter"] = tt.machines
+			}
+			if tt.oil {
+				plan = testOilPlan()
+				lg.Entities.(testEntities)["plant"] = validationEntities["plant"]
+				lg.Pipe = &Pipe{Name: "pipe", Underground: "pipe-to-ground", UndergroundLength: 10}
+			}
+
+			layout, err := lg.GenerateLayout(plan)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("GenerateLayout succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateLayout: %v", err)
+			}
+
+			size := tt.blockSize
+			if size == 0 {
+				size = DefaultCityBlockSize
+			}
+			blocks := make(map[int]bool)
+			loaded, unloaded := make(map[string]float64), make(map[string]float64)
+			for _, station := range layout.Stations {
+				blocks[station.Block] = true
+				if station.Trains < 1 {
+					t.Errorf("station %s has %d trains", station.Name, station.Trains)
+				}
+				if station.Loading {
+					loaded[station.Item] += station.Rate
+				} else {
+					unloaded[station.Item] += station.Rate
+				}
+			}
+			if len(blocks) != tt.wantBlocks {
+				t.Errorf("stations in %d blocks, want %d", len(blocks), tt.wantBlocks)
+			}
+			for item, want := range tt.wantRates {
+				for _, rates := range []map[string]float64{loaded, unloaded} {
+					if got, exists := rates[item]; exists && math.Abs(got-want) > 1e-9 {
+						t.Errorf("%s stations move %v/s, want %v/s", item, got, want)
+					}
+				}
+			}
+			for _, route := range layout.UnroutedPipes {
+				t.Errorf("%s pipe for %s/%s not routed", route.Fluid, route.From, route.To)
+			}
+			if (layout.Width-cityRailWidth)%size != 0 || (layout.Height-cityRailWidth)%size != 0 {
+				t.Errorf("layout is %dx%d, want whole %d-tile blocks and a rail", layout.Width, layout.Height, size)
+			}
+
+			machines := make(map[string]int)
+			for _, building := range layout.Buildings {
+				if building.Recipe != "" {
+					machines[building.Recipe]++
+				}
+			}
+			for recipeName, want := range plan.RequiredMachines {
+				if machines[recipeName] != want {
+					t.Errorf("placed %d %s machines, want %d", machines[recipeName], recipeName, want)
+				}
+			}
+
+			for _, violation := range lg.ValidateLayout(layout) {
+				switch violation.Rule {
+				case RuleOverlap, RuleOutOfBounds, RuleUnpowered, RuleFluidMix, RuleFluidBoxMix, RuleUnpipedInput, RuleUnpipedOutput:
+					t.Errorf("violation %s", violation)
+				}
+			}
+		})
+	}
+}
+
+func TestTrainsFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		flow    cityFlow
+		bonuses ResearchBonuses
+		want    int
+	}{
+		// 4 wagons of 40 stacks of 50 carry 8000 items every 180 s
+		{name: "one train", flow: cityFlow{item: "iron-plate", rate: 10}, want: 1},
+		{name: "two trains", flow: cityFlow{item: "iron-plate", rate: 50}, want: 2},
+		{name: "larger stacks", flow: cityFlow{item: "copper-cable", rate: 50}, want: 1},
+		// braking twice as hard saves 20 of the 40 s spent braking
+		{name: "braking force", flow: cityFlow{item: "iron-plate", rate: 50}, bonuses: ResearchBonuses{TrainBrakingForce: 1}, want: 1},
+		// 4 fluid wagons carry 100000 units
+		{name: "fluid", flow: cityFlow{item: "water", rate: 1000, fluid: true}, want: 2},
+		{name: "nothing to move", flow: cityFlow{item: "iron-plate"}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg := NewLayoutGenerator()
+			lg.Bonuses = tt.bonuses
+			lg.StackSizes = testStackSizes{"copper-cable": 200}
+			if got := lg.trainsFor(tt.flow); got != tt.want {
+				t.Errorf("trainsFor = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
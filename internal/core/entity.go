@@ -0,0 +1,117 @@
+// Package core contains entity and module definitions used by the planner.
+package core
+
+// Entity types of logistics and power buildings in layouts.
+const (
+	EntityTypeBelt            = "belt"
+	EntityTypeUndergroundBelt = "underground-belt"
+	EntityTypeSplitter        = "splitter"
+	EntityTypeInserter        = "inserter"
+	EntityTypeElectricPole    = "electric-pole"
+	EntityTypePipe            = "pipe"
+	EntityTypePipeToGround    = "pipe-to-ground"
+	EntityTypeRail            = "rail"
+	EntityTypeTrainStop       = "train-stop"
+)
+
+// Energy source types for entities.
+const (
+	EnergySourceElectric = "electric"
+	EnergySourceBurner   = "burner"
+	EnergySourceNone     = "" // entity needs no energy (e.g. offshore pump)
+)
+
+// Entity represents a placeable machine with the stats needed for planning.
+type Entity struct {
+	Name          string
+	Type          string   // "assembler", "furnace", "mining-drill", "boiler", etc.
+	CraftingSpeed float64  // crafting or mining speed
+	PumpingSpeed  float64  // fluid units per second, for offshore pumps
+	EnergyUsage   float64  // in kW at full load
+	EnergySource  string   // one of the EnergySource constants
+	Emissions     float64  // pollution per minute at full load
+	ModuleSlots   int      // number of module slots
+	Categories    []string // crafting categories the entity can run
+	Width         int      // footprint width in tiles, unrotated
+	Height        int      // footprint height in tiles, unrotated
+	Reach         int      // tiles from an inserter to its pickup and drop positions
+	SupplyArea    int      // side of the square an electric pole powers, in tiles
+	WireReach     float64  // distance in tiles an electric pole connects to others
+	FluidBoxes    []FluidBox
+}
+
+// FluidBox is a pipe connection of a machine, given for the unrotated
+// footprint.
+type FluidBox struct {
+	Position  Position // tile of the footprint the connection is on
+	Direction int      // side the connection opens to, 0 north to 270 west
+	Output    bool     // true when fluid leaves the machine here
+}
+
+// Module represents a machine module and its effect bonuses.
+// Bonuses are fractions, so 0.2 means +20%.
+type Module struct {
+	Name         string
+	Speed        float64
+	Productivity float64
+	Consumption  float64
+	Pollution    float64
+	Quality      float64 // chance of a higher quality result, 0.025 = 2.5%
+}
+
+// ModuleEffects is the combined effect of all modules in a machine.
+type ModuleEffects struct {
+	Speed        float64
+	Productivity float64
+	Consumption  float64
+	Pollution    float64
+	Quality      float64
+}
+
+// CombineModules sums the bonuses of the given modules, applying the
+// game's lower limit of -80% to speed and energy consumption and keeping
+// the quality chance from going negative.
+func CombineModules(modules []*Module) ModuleEffects {
+	var effects ModuleEffects
+	for _, module := range modules {
+		if module == nil {
+			continue
+		}
+		effects.Speed += module.Speed
+		effects.Productivity += module.Productivity
+		effects.Consumption += module.Consumption
+		effects.Pollution += module.Pollution
+		effects.Quality += module.Quality
+	}
+
+	if effects.Speed < -0.8 {
+		effects.Speed = -0.8
+	}
+	if effects.Consumption < -0.8 {
+		effects.Consumption = -0.8
+	}
+	if effects.Pollution < -0.8 {
+		effects.Pollution = -0.8
+	}
+	if effects.Quality < 0 {
+		effects.Quality = 0
+	}
+
+	return effects
+}
+
+// EnergyMultiplier returns the factor applied to an entity's energy usage.
+func (me ModuleEffects) EnergyMultiplier() float64 {
+	return 1 + me.Consumption
+}
+
+// PollutionMultiplier returns the factor applied to an entity's emissions.
+// Emissions scale with both energy consumption and the pollution bonus.
+func (me ModuleEffects) PollutionMultiplier() float64 {
+	return me.EnergyMultiplier() * (1 + me.Pollution)
+}
+
+// IsElectric reports whether the entity draws from the electric network.
+func (e *Entity) IsElectric() bool {
+	return e.EnergySource == EnergySourceElectric
+}
@@ -0,0 +1,85 @@
+// Package core contains building footprints used by layout generation.
+package core
+
+// Size is the width and height of a footprint in tiles.
+type Size struct {
+	Width, Height int
+}
+
+// Rect is an area of tiles, from Min (inclusive) to Max (exclusive).
+type Rect struct {
+	Min, Max Position
+}
+
+// EntityProvider provides entity data for the buildings of a layout.
+type EntityProvider interface {
+	GetEntity(name string) (*Entity, bool)
+}
+
+// Footprint returns the entity's size in tiles when placed unrotated.
+// Entities without a size occupy a single tile.
+func (e *Entity) Footprint() Size {
+	return Size{Width: max(e.Width, 1), Height: max(e.Height, 1)}
+}
+
+// Rotated returns the size after rotating by the given degrees; quarter
+// turns swap width and height.
+func (s Size) Rotated(rotation int) Size {
+	if (rotation/90)%2 != 0 {
+		return Size{Width: s.Height, Height: s.Width}
+	}
+	return s
+}
+
+// Footprint returns the building's size in tiles with its rotation
+// applied. Buildings without a size occupy a single tile.
+func (b *Building) Footprint() Size {
+	size := Size{Width: max(b.Size.Width, 1), Height: max(b.Size.Height, 1)}
+	return size.Rotated(b.Rotation)
+}
+
+// Bounds returns the tiles covered by the building. Position is the
+// top-left tile of the footprint.
+func (b *Building) Bounds() Rect {
+	size := b.Footprint()
+	return Rect{
+		Min: b.Position,
+		Max: Position{X: b.Position.X + size.Width, Y: b.Position.Y + size.Height},
+	}
+}
+
+// Contains reports whether a tile lies inside the rectangle.
+func (r Rect) Contains(p Position) bool {
+	return p.X >= r.Min.X && p.X < r.Max.X && p.Y >= r.Min.Y && p.Y < r.Max.Y
+}
+
+// Overlaps reports whether two rectangles share at least one tile.
+func (r Rect) Overlaps(other Rect) bool {
+	return r.Min.X < other.Max.X && other.Min.X < r.Max.X &&
+		r.Min.Y < other.Max.Y && other.Min.Y < r.Max.Y
+}
+
+// Add returns the position moved by the given offset.
+func (p Position) Add(offset Position) Position {
+	return Position{X: p.X + offset.X, Y: p.Y + offset.Y}
+}
+
+// Scale returns the offset multiplied by n.
+func (p Position) Scale(n int) Position {
+	return Position{X: p.X * n, Y: p.Y * n}
+}
+
+// DirectionOffset returns the one-tile offset a rotation points to: 0 is
+// north (up), 90 east, 180 south and 270 west.
+func DirectionOffset(rotation int) Position {
+	switch ((rotation%360 + 360) % 360) / 90 {
+	case 1:
+		return Position{X: 1}
+	case 2:
+		return Position{Y: 1}
+	case 3:
+		return Position{X: -1}
+	default:
+		return Position{Y: -1}
+	}
+}
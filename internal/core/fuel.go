@@ -0,0 +1,105 @@
+// Package core contains fuel consumption calculations for burner machines.
+package core
+
+import "fmt"
+
+// maxFuelIterations bounds the passes made while fuel production itself
+// needs more fuel (e.g. burner drills mining coal).
+const maxFuelIterations = 20
+
+// FuelProvider provides fuel values for items.
+type FuelProvider interface {
+	IsFuel(itemName string) bool
+	GetFuelValue(itemName string) (float64, bool)
+}
+
+// addFuelDemand adds the fuel burned by burner machines, drills, inserters
+// and boilers to the plan as extra demand for the optimizer's fuel item.
+// Producing that fuel can need more fuel, so demand is expanded until it
+// settles. A fuel no unlocked recipe makes and no drill mines is supplied
+// from outside the plan instead.
+func (opt *Optimizer) addFuelDemand(plan *ProductionPlan) error {
+	if opt.Fuel == "" {
+		return nil
+	}
+	if opt.FuelProvider == nil || !opt.FuelProvider.IsFuel(opt.Fuel) {
+		return fmt.Errorf("%s is not a fuel", opt.Fuel)
+	}
+	fuelValue, _ := opt.FuelProvider.GetFuelValue(opt.Fuel)
+
+	plan.Fuel = opt.Fuel
+	if opt.selectRecipe(opt.Fuel) == nil && !opt.isRawResource(opt.Fuel) {
+		plan.FuelSupplied = true
+		plan.FuelConsumption = opt.burnerEnergy(plan) * 60.0 / 1000.0 / fuelValue
+		return nil
+	}
+	for i := 0; i < maxFuelIterations; i++ {
+		needed := opt.burnerEnergy(plan) * 60.0 / 1000.0 / fuelValue
+		delta := needed - plan.FuelConsumption
+		if delta < 1e-6 {
+			return nil
+		}
+		if err := opt.expandItem(plan, opt.Fuel, delta, 0); err != nil {
+			return err
+		}
+		plan.FuelConsumption = needed
+	}
+
+	return fmt.Errorf("fuel demand for %s does not converge", opt.Fuel)
+}
+
+// burnerEnergy returns the power in kW drawn from burning fuel by the
+// plan's machines, extractors, inserters and boilers.
+func (opt *Optimizer) burnerEnergy(plan *ProductionPlan) float64 {
+	totalKW := 0.0
+
+	for recipeName, count := range plan.MachineCounts {
+		machine := opt.machineFor(opt.RecipeGraph.Recipes[recipeName])
+		if machine == nil || machine.EnergySource != EnergySourceBurner {
+			continue
+		}
+		effects := CombineModules(opt.modules(plan, recipeName))
+		totalKW += count * machine.EnergyUsage * effects.EnergyMultiplier()
+	}
+
+	for item, rate := range plan.ResourceFlow {
+		extractor := opt.extractorFor(item)
+		if extractor == nil || extractor.EnergySource != EnergySourceBurner {
+			continue
+		}
+		totalKW += opt.extractorsNeeded(extractor, item, rate) * extractor.EnergyUsage
+	}
+
+	// Burner inserters burn fuel while they swing, for the share of the
+	// time they are busy
+	for recipeName, count := range plan.MachineCounts {
+		requirements, _ := opt.recipeRequirements(plan, recipeName)
+		for _, requirement := range requirements {
+			inserter := opt.inserter(requirement.Inserter)
+			if inserter == nil || inserter.EnergySource != EnergySourceBurner {
+				continue
+			}
+			if throughput := opt.InserterThroughput(inserter); throughput > 0 {
+				totalKW += count * requirement.Rate / throughput * inserter.EnergyUsage
+			}
+		}
+	}
+
+	// Boilers turn fuel into the electric power used by everything else
+	opt.calculatePower(plan)
+	if opt.Boiler != nil && opt.Boiler.EnergySource == EnergySourceBurner {
+		totalKW += plan.TotalPowerUsage * 1000.0
+	}
+
+	return totalKW
+}
+
+// inserter returns the unlocked inserter with the given name, or nil.
+func (opt *Optimizer) inserter(name string) *Inserter {
+	for _, inserter := range opt.Inserters {
+		if inserter.Name == name {
+			return inserter
+		}
+	}
+	return nil
+}
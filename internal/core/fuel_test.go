@@ -0,0 +1,109 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+// testFuels is a fuel provider with fixed fuel values in MJ.
+type testFuels map[string]float64
+
+func (f testFuels) IsFuel(itemName string) bool {
+	return f[itemName] > 0
+}
+
+func (f testFuels) GetFuelValue(itemName string) (float64, bool) {
+	value, exists := f[itemName]
+	return value, exists && value > 0
+}
+
+func TestAddFuelDemand(t *testing.T) {
+	burnerDrill := &Entity{Name: "burner-drill", CraftingSpeed: 0.25, EnergyUsage: 150, EnergySource: EnergySourceBurner}
+	electricDrill := &Entity{Name: "electric-drill", CraftingSpeed: 0.5, EnergyUsage: 90, EnergySource: EnergySourceElectric}
+
+	tests := []struct {
+		name      string
+		fuel      string
+		miner     *Entity
+		boiler    bool
+		inserters []*Inserter
+		want      float64 // fuel items per minute
+		supplied  bool
+		wantErr   bool
+	}{
+		{name: "no burners", fuel: "coal", miner: electricDrill},
+		// 8 burner drills on ore draw 1200 kW, 18 coal/min; mining that
+		// coal takes 0.15 coal per coal, so demand settles at 18 / 0.85.
+		{name: "burner drills mining their own fuel", fuel: "coal", miner: burnerDrill, want: 18 / 0.85},
+		// 2 assemblers at 100 kW and 4 drills at 90 kW draw 560 kW from
+		// boilers, 8.4 coal/min; the drills mining that coal add 0.045
+		// coal per coal.
+		{name: "boilers", fuel: "coal", miner: electricDrill, boiler: true, want: 8.4 / 0.955},
+		// 1 ore/s in and 1 gadget/s out keep both inserters of each
+		// machine busy: 4 inserters at 60 kW
+		{
+			name: "burner inserters", fuel: "coal", miner: electricDrill,
+			inserters: []*Inserter{{Name: "burner-inserter", SwingsPerSecond: 1, EnergyUsage: 60, EnergySource: EnergySourceBurner}},
+			want:      0.24 * 60 / 4,
+		},
+		{
+			name: "electric inserters", fuel: "coal", miner: electricDrill,
+			inserters: []*Inserter{{Name: "inserter", SwingsPerSecond: 1, EnergyUsage: 60, EnergySource: EnergySourceElectric}},
+		},
+		// Nothing makes solid fuel here: the same 1200 kW of drills burn
+		// 6 solid fuel/min, brought in from outside.
+		{name: "supplied fuel", fuel: "solid-fuel", miner: burnerDrill, want: 6, supplied: true},
+		{name: "not a fuel", fuel: "ore", miner: burnerDrill, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := NewRecipeGraph()
+			graph.AddRecipe(&Recipe{
+				Name:         "gadget",
+				Inputs:       map[string]float64{"ore": 1},
+				Outputs:      map[string]float64{"gadget": 1},
+				CraftingTime: 1,
+				Category:     "crafting",
+			})
+
+			opt := NewOptimizer(graph, nil)
+			opt.Machines["crafting"] = &Entity{Name: "assembler", CraftingSpeed: 1, EnergyUsage: 100, EnergySource: EnergySourceElectric}
+			opt.Miner = tt.miner
+			if tt.boiler {
+				opt.Boiler = &Entity{Name: "boiler", EnergyUsage: 1000, EnergySource: EnergySourceBurner}
+			}
+			opt.Inserters = tt.inserters
+			opt.ResourceProvider = testResources{"ore": 1, "coal": 1}
+			opt.Fuel = tt.fuel
+			opt.FuelProvider = testFuels{"coal": 4, "solid-fuel": 12}
+
+			plan, err := opt.OptimizeProduction([]ProductionTarget{{Item: "gadget", Rate: 120}})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("OptimizeProduction succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OptimizeProduction: %v", err)
+			}
+
+			if math.Abs(plan.FuelConsumption-tt.want) > 1e-4 {
+				t.Errorf("fuel consumption = %v/min, want %v/min", plan.FuelConsumption, tt.want)
+			}
+			if plan.FuelSupplied != tt.supplied {
+				t.Errorf("fuel supplied = %v, want %v", plan.FuelSupplied, tt.supplied)
+			}
+			if tt.supplied {
+				if _, planned := plan.ResourceFlow[tt.fuel]; planned {
+					t.Errorf("supplied fuel %s planned as a resource", tt.fuel)
+				}
+				return
+			}
+			if got := plan.ResourceFlow[tt.fuel]; math.Abs(got-tt.want) > 1e-4 {
+				t.Errorf("%s flow = %v/min, want %v/min", tt.fuel, got, tt.want)
+			}
+		})
+	}
+}
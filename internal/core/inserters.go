@@ -0,0 +1,201 @@
+// Package core contains inserter placement between machines and belts.
+package core
+
+import (
+	"fmt"
+)
+
+// placeInserters places an inserter at each stop of the layout's belt
+// routes, taking products off the machines loading the belt and feeding
+// the machines unloading it, and one inserter between the machines of each
+// direct insertion. Inserters reaching over a neighboring belt are
+// long-handed; otherwise the slowest unlocked tier moving the stop's rate
+// is used.
+func (lg *LayoutGenerator) placeInserters(layout *FactoryLayout) {
+	if len(lg.Inserters) == 0 {
+		return
+	}
+
+	machines := make(map[string]Rect, len(layout.Buildings))
+	for i := range layout.Buildings {
+		machines[layout.Buildings[i].ID] = layout.Buildings[i].Bounds()
+	}
+
+	for _, route := range layout.Routes {
+		for _, stop := range route.Stops {
+			rotation := sideRotation(stop.Inserter, machines[stop.Building])
+			reach := tileDistance(stop.Inserter, stop.Belt)
+			if route.Direct {
+				reach = 1
+			}
+			if stop.Load {
+				rotation = (rotation + 180) % 360
+			}
+			lg.addInserter(layout, stop.Inserter, rotation, reach, stop.Rate)
+		}
+	}
+}
+
+// addInserter places an inserter moving items in the direction of rotation
+// over the given reach.
+func (lg *LayoutGenerator) addInserter(layout *FactoryLayout, position Position, rotation, reach int, rate float64) {
+	inserter := lg.inserterFor(rate, reach)
+	if inserter == nil {
+		return
+	}
+	layout.Buildings = append(layout.Buildings, Building{
+		ID:       fmt.Sprintf("inserter_%d", len(layout.Buildings)),
+		Type:     EntityTypeInserter,
+		Entity:   inserter.Name,
+		Position: position,
+		Size:     Size{Width: 1, Height: 1},
+		Rotation: rotation,
+		Color:    lg.buildingColor(inserter.Name),
+	})
+}
+
+// inserterFor returns the slowest unlocked inserter with the given reach
+// that moves the rate on its own, or the fastest one with that reach.
+func (lg *LayoutGenerator) inserterFor(rate float64, reach int) *Inserter {
+	var chosen, fastest *Inserter
+	for _, inserter := range lg.Inserters {
+		if lg.inserterReach(inserter) != reach {
+			continue
+		}
+		throughput := lg.inserterThroughput(inserter)
+		if throughput >= rate && (chosen == nil || throughput < lg.inserterThroughput(chosen)) {
+			chosen = inserter
+		}
+		if fastest == nil || throughput > lg.inserterThroughput(fastest) {
+			fastest = inserter
+		}
+	}
+	if chosen == nil {
+		return fastest
+	}
+	return chosen
+}
+
+// inserterThroughput returns the items per second an inserter moves with
+// the researched stack size bonuses applied.
+func (lg *LayoutGenerator) inserterThroughput(inserter *Inserter) float64 {
+	return inserter.SwingsPerSecond * lg.Bonuses.StackSize(inserter)
+}
+
+// inserterReach returns how many tiles an inserter reaches, 1 without
+// entity data.
+func (lg *LayoutGenerator) inserterReach(inserter *Inserter) int {
+	if lg.Entities != nil {
+		if entity, exists := lg.Entities.GetEntity(inserter.Name); exists {
+			return max(entity.Reach, 1)
+		}
+	}
+	return 1
+}
+
+// longReach reports whether a long-handed inserter is unlocked.
+func (lg *LayoutGenerator) longReach() bool {
+	for _, inserter := range lg.Inserters {
+		if lg.inserterReach(inserter) >= 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// directFeeds returns the recipes whose single machine hands its only
+// product to the single machine of one other recipe, mapped to that
+// recipe. These pairs are placed side by side with an inserter between
+// them instead of a belt. Target products always leave on a belt.
+func (lg *LayoutGenerator) directFeeds(plan *ProductionPlan) map[string]string {
+	feeds := make(map[string]string)
+	if len(lg.Inserters) == 0 {
+		return feeds
+	}
+
+	targets := make(map[string]bool, len(plan.Targets))
+	for _, target := range plan.Targets {
+		targets[target.Item] = true
+	}
+	recipes := sortedKeys(plan.RequiredMachines)
+
+	consumers := make(map[string][]string)
+	for _, recipeName := range recipes {
+		for _, requirement := range plan.InserterRequirements[recipeName] {
+			if !requirement.Output {
+				consumers[requirement.Item] = append(consumers[requirement.Item], recipeName)
+			}
+		}
+	}
+
+	fed := make(map[string]bool)
+	for _, recipeName := range recipes {
+		if plan.RequiredMachines[recipeName] != 1 {
+			continue
+		}
+		var products []string
+		for _, requirement := range plan.InserterRequirements[recipeName] {
+			if requirement.Output {
+				products = append(products, requirement.Item)
+			}
+		}
+		if len(products) != 1 || targets[products[0]] {
+			continue
+		}
+		users := consumers[products[0]]
+		if len(users) != 1 || users[0] == recipeName || plan.RequiredMachines[users[0]] != 1 || fed[users[0]] {
+			continue
+		}
+		feeds[recipeName] = users[0]
+		fed[users[0]] = true
+	}
+	return feeds
+}
+
+// orderDirectFeeds reorders recipes so that every directly feeding recipe
+// comes right before the recipe it feeds, keeping the order otherwise.
+func orderDirectFeeds(recipes []string, feeds map[string]string) []string {
+	feeders := make(map[string]string, len(feeds))
+	for feeder, consumer := range feeds {
+		feeders[consumer] = feeder
+	}
+
+	ordered := make([]string, 0, len(recipes))
+	placed := make(map[string]bool, len(recipes))
+	var place func(recipeName string)
+	place = func(recipeName string) {
+		if placed[recipeName] {
+			return
+		}
+		placed[recipeName] = true
+		if feeder, exists := feeders[recipeName]; exists {
+			place(feeder)
+		}
+		ordered = append(ordered, recipeName)
+	}
+
+	for _, recipeName := range recipes {
+		if _, isFeeder := feeds[recipeName]; !isFeeder {
+			place(recipeName)
+		}
+	}
+	for _, recipeName := range recipes {
+		place(recipeName) // feeding cycles have no last recipe
+	}
+	return ordered
+}
+
+// sideRotation returns the rotation pointing from a tile next to a
+// rectangle into it.
+func sideRotation(p Position, rect Rect) int {
+	switch {
+	case p.X < rect.Min.X:
+		return 90
+	case p.X >= rect.Max.X:
+		return 270
+	case p.Y < rect.Min.Y:
+		return 180
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import "testing"
+
+func TestPlaceInserters(t *testing.T) {
+	inserters := []*Inserter{
+		{Name: "inserter", SwingsPerSecond: 0.83},
+		{Name: "fast-inserter", SwingsPerSecond: 2.31},
+		{Name: "long-handed-inserter", SwingsPerSecond: 1.2},
+	}
+	entities := testEntities{"long-handed-inserter": {Name: "long-handed-inserter", Type: EntityTypeInserter, Reach: 2}}
+
+	// The machine covers (2,2) to (4,4).
+	tests := []struct {
+		name         string
+		stop         BeltStop
+		direct       bool
+		bonuses      ResearchBonuses
+		inserters    []*Inserter
+		wantEntity   string // empty when no inserter is placed
+		wantRotation int
+	}{
+		{
+			name:       "feeding from the west",
+			stop:       BeltStop{Inserter: Position{X: 1, Y: 3}, Belt: Position{X: 0, Y: 3}, Rate: 0.5},
+			inserters:  inserters,
+			wantEntity: "inserter", wantRotation: 90,
+		},
+		{
+			name:       "loading to the west",
+			stop:       BeltStop{Inserter: Position{X: 1, Y: 3}, Belt: Position{X: 0, Y: 3}, Load: true, Rate: 0.5},
+			inserters:  inserters,
+			wantEntity: "inserter", wantRotation: 270,
+		},
+		{
+			name:       "faster tier for the rate",
+			stop:       BeltStop{Inserter: Position{X: 3, Y: 1}, Belt: Position{X: 3, Y: 0}, Rate: 2},
+			inserters:  inserters,
+			wantEntity: "fast-inserter", wantRotation: 180,
+		},
+		{
+			name:       "stack size bonus",
+			stop:       BeltStop{Inserter: Position{X: 3, Y: 1}, Belt: Position{X: 3, Y: 0}, Rate: 2},
+			bonuses:    ResearchBonuses{InserterStackSize: 2},
+			inserters:  inserters,
+			wantEntity: "inserter", wantRotation: 180,
+		},
+		{
+			name:       "fastest tier above every rate",
+			stop:       BeltStop{Inserter: Position{X: 5, Y: 3}, Belt: Position{X: 6, Y: 3}, Rate: 5},
+			inserters:  inserters,
+			wantEntity: "fast-inserter", wantRotation: 270,
+		},
+		{
+			name:       "reaching over a belt",
+			stop:       BeltStop{Inserter: Position{X: 3, Y: 5}, Belt: Position{X: 3, Y: 7}, Rate: 0.5},
+			inserters:  inserters,
+			wantEntity: "long-handed-inserter", wantRotation: 0,
+		},
+		{
+			name:       "direct insertion",
+			stop:       BeltStop{Inserter: Position{X: 5, Y: 3}, Belt: Position{X: 7, Y: 3}, Rate: 0.5},
+			direct:     true,
+			inserters:  inserters,
+			wantEntity: "inserter", wantRotation: 270,
+		},
+		{
+			name: "no inserter unlocked",
+			stop: BeltStop{Inserter: Position{X: 1, Y: 3}, Belt: Position{X: 0, Y: 3}, Rate: 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg := NewLayoutGenerator()
+			lg.Entities = entities
+			lg.Inserters = tt.inserters
+			lg.Bonuses = tt.bonuses
+
+			tt.stop.Building = "machine"
+			layout := &FactoryLayout{
+				Buildings: []Building{{ID: "machine", Type: "assembler", Position: Position{X: 2, Y: 2}, Size: Size{Width: 3, Height: 3}}},
+				Routes:    []BeltRoute{{Item: "iron-plate", Stops: []BeltStop{tt.stop}, Direct: tt.direct}},
+			}
+			lg.placeInserters(layout)
+
+			if tt.wantEntity == "" {
+				if len(layout.Buildings) != 1 {
+					t.Fatalf("placed %v, want no inserter", layout.Buildings[1:])
+				}
+				return
+			}
+			if len(layout.Buildings) != 2 {
+				t.Fatalf("placed %d buildings, want one inserter", len(layout.Buildings)-1)
+			}
+			inserter := layout.Buildings[1]
+			if inserter.Type != EntityTypeInserter || inserter.Entity != tt.wantEntity || inserter.Position != tt.stop.Inserter {
+				t.Errorf("placed %s %s at %v, want %s at %v", inserter.Type, inserter.Entity, inserter.Position, tt.wantEntity, tt.stop.Inserter)
+			}
+			if inserter.Rotation != tt.wantRotation {
+				t.Errorf("rotation = %d, want %d", inserter.Rotation, tt.wantRotation)
+			}
+		})
+	}
+}
@@ -4,6 +4,25 @@ package core
 import (
 	"fmt"
 	"image/color"
+	"math"
+	"sort"
+	"strings"
+)
+
+// layoutRowWidth is the width in tiles after which placement wraps to a
+// new row of buildings. Rows of large plans are wider, keeping the layout
+// about square so that belts between far rows stay short.
+const layoutRowWidth = 24
+
+// layoutMargin is the free border around the machines, leaving room for an
+// inserter, a belt and the edge belts entering and leaving the layout.
+const layoutMargin = 3
+
+// Built-in layout styles selectable with LayoutGenerator.Style.
+const (
+	LayoutStyleCompact = "compact"    // machines packed into rows with belts routed between them
+	LayoutStyleBus     = "bus"        // production blocks beside a main bus
+	LayoutStyleCity    = "city-block" // one recipe per rail-bounded block with train stations
 )
 
 // ItemColorProvider provides color information for items.
@@ -11,6 +30,12 @@ type ItemColorProvider interface {
 	GetItemColor(itemName string) (color.Color, bool)
 }
 
+// NameProvider provides display names for items and recipes.
+type NameProvider interface {
+	ItemName(itemName string) string
+	RecipeName(recipeName string) string
+}
+
 // Position represents a 2D coordinate in the factory layout.
 type Position struct {
 	X, Y int
@@ -19,11 +44,18 @@ type Position struct {
 // Building represents a factory building (assembler, furnace, etc.).
 type Building struct {
 	ID       string
-	Type     string // "assembler", "furnace", "belt", "inserter", etc.
-	Position Position
-	Recipe   string     // recipe being crafted (for machines)
-	Rotation int        // 0, 90, 180, 270 degrees
+	Type     string      // "assembler", "furnace", "belt", "inserter", etc.
+	Entity   string      // entity placed, e.g. "assembling-machine-2"
+	Position Position    // top-left tile of the footprint
+	Size     Size        // footprint before rotation
+	Recipe   string      // recipe being crafted (for machines)
+	Label    string      // display name shown for the building
+	Rotation int         // 0, 90, 180, 270 degrees
 	Color    color.Color // color for rendering
+
+	Underground string             // UndergroundInput or UndergroundOutput for underground belt and pipe ends
+	Fluid       string             // fluid carried, for pipes
+	Fluids      []FluidRequirement // fluids piped in and out, for machines
 }
 
 // FactoryLayout represents the complete physical layout of a factory.
@@ -32,88 +64,142 @@ type FactoryLayout struct {
 	Width     int
 	Height    int
 	Title     string
+
+	Routes   []BeltRoute // belt lines placed between buildings
+	Unrouted []BeltRoute // belt lines no path was found for
+
+	Pipes         []PipeRoute // pipe lines placed between buildings
+	UnroutedPipes []PipeRoute // pipe lines no path was found for
+
+	PowerConnection string // ID of the pole to wire the outside network to
+
+	Bus      []BusLane      // main bus lanes, for the bus style
+	Stations []TrainStation // train stations, for the city-block style
 }
 
 // LayoutGenerator creates physical factory layouts from production plans.
 type LayoutGenerator struct {
-	MinSpacing    int                // minimum space between buildings
-	ColorProvider ItemColorProvider  // provider for building colors
+	MinSpacing    int               // minimum space between buildings
+	ColorProvider ItemColorProvider // provider for building colors
+	Bonuses       ResearchBonuses   // researched technology bonuses
+	NameProvider  NameProvider      // provider for display names, nil for internal names
+	Entities      EntityProvider    // provider for building types and footprints
+	Belts         []*Belt           // unlocked belt tiers, nil to skip belt routing
+	Inserters     []*Inserter       // unlocked inserter tiers, nil to skip inserter placement
+	Poles         []*Entity         // unlocked electric poles, nil to skip pole placement
+	Pipe          *Pipe             // pipe used for fluids, nil to skip pipe routing
+	Style         string            // name of a registered layout strategy, empty for compact
+	BlockSize     int               // city block side in tiles, 0 for DefaultCityBlockSize
+	StackSizes    StackSizeProvider // provider for item stack sizes, nil to assume 50
+	Seed          int64             // seed for custom strategies searching randomly, see Rand
 }
 
 // NewLayoutGenerator creates a new layout generator.
 func NewLayoutGenerator() *LayoutGenerator {
 	return &LayoutGenerator{
-		MinSpacing: 2, // default spacing
+		MinSpacing: 5, // room for inserters and belts on both sides of a lane
 	}
 }
 
 // NewLayoutGeneratorWithColorProvider creates a new layout generator with a color provider.
 func NewLayoutGeneratorWithColorProvider(colorProvider ItemColorProvider) *LayoutGenerator {
 	return &LayoutGenerator{
-		MinSpacing:    2,
+		MinSpacing:    5,
 		ColorProvider: colorProvider,
 	}
 }
 
-// GenerateLayout creates a factory layout from a production plan.
+// GenerateLayout creates a factory layout from a production plan in the
+// generator's layout style. The same plan, settings and Seed always give
+// the same layout.
 func (lg *LayoutGenerator) GenerateLayout(plan *ProductionPlan) (*FactoryLayout, error) {
 	if plan == nil {
 		return nil, fmt.Errorf("production plan cannot be nil")
 	}
 
+	style := lg.Style
+	if style == "" {
+		style = LayoutStyleCompact
+	}
+	strategy, exists := GetLayoutStrategy(style)
+	if !exists {
+		return nil, fmt.Errorf("unknown layout style %q, want one of %s", style, layoutStrategyNames())
+	}
+	return strategy.Generate(lg, plan)
+}
+
+// compactSpacingRetries is how many times the compact style widens the gaps
+// between machines by a tile when belt or pipe lines stay unrouted.
+const compactSpacingRetries = 6
+
+// generateCompactLayout packs the plan's machines into rows and routes
+// belts, inserters, pipes and poles between them. When lines stay
+// unrouted it packs them again with wider gaps and keeps the layout with
+// the fewest unrouted lines.
+func (lg *LayoutGenerator) generateCompactLayout(plan *ProductionPlan) *FactoryLayout {
+	var best *FactoryLayout
+	for retry := 0; retry <= compactSpacingRetries; retry++ {
+		layout := lg.packCompactLayout(plan, lg.MinSpacing+retry)
+		if best == nil || unroutedLines(layout) < unroutedLines(best) {
+			best = layout
+		}
+		if unroutedLines(best) == 0 {
+			break
+		}
+	}
+	return best
+}
+
+// unroutedLines counts the belt and pipe lines of a layout no path was
+// found for.
+func unroutedLines(layout *FactoryLayout) int {
+	return len(layout.Unrouted) + len(layout.UnroutedPipes)
+}
+
+// packCompactLayout places the plan's machines in rows with spacing free
+// tiles between them and connects them.
+func (lg *LayoutGenerator) packCompactLayout(plan *ProductionPlan, spacing int) *FactoryLayout {
 	layout := &FactoryLayout{
 		Buildings: make([]Building, 0),
 		Width:     10, // placeholder dimensions
 		Height:    10,
-		Title:     "Factory Layout",
+		Title:     lg.title(plan),
 	}
 
-	// TODO: Implement actual layout generation algorithm
-	// This would involve:
-	// 1. Placing production buildings based on the plan
-	// 2. Routing belts and inserters for material flow
-	// 3. Optimizing for minimal belt length and congestion
-	// 4. Ensuring proper spacing for inserter reach
-	// 5. Adding power poles and infrastructure
-
-	// Placeholder: Add a basic building for each recipe in the plan
-	x, y := 0, 0
+	// Place the machines for each recipe in the plan, packed into rows by
+	// footprint, after the machines making their inputs
+	x, y, rowHeight := layoutMargin, layoutMargin, 0
 	buildingID := 0
 
-	for recipeName, count := range plan.RequiredMachines {
-		for i := 0; i < count; i++ {
-			buildingType := "assembler" // simplified for now
-			building := Building{
-				ID:       fmt.Sprintf("building_%d", buildingID),
-				Type:     buildingType,
-				Position: Position{X: x, Y: y},
-				Recipe:   recipeName,
-				Rotation: 0,
-			}
+	feeds := lg.directFeeds(plan)
+	recipes := orderDirectFeeds(producerOrder(plan), feeds)
 
-			// Set building color from color provider
-			if lg.ColorProvider != nil {
-				// Map building type to item name
-				itemName := lg.getBuildingItemName(buildingType)
-				if buildingColor, hasColor := lg.ColorProvider.GetItemColor(itemName); hasColor {
-					building.Color = buildingColor
-				} else {
-					// Default color if not found
-					building.Color = color.RGBA{150, 150, 150, 255} // gray
-				}
-			} else {
-				// Default color if no provider
-				building.Color = color.RGBA{150, 150, 150, 255} // gray
+	area := 0
+	for _, recipeName := range recipes {
+		_, _, size := lg.machineFor(plan.MachineTypes[recipeName])
+		area += plan.RequiredMachines[recipeName] * (size.Width + spacing) * (size.Height + spacing)
+	}
+	rowWidth := max(layoutRowWidth, int(math.Sqrt(float64(area))))
+
+	for _, recipeName := range recipes {
+		count := plan.RequiredMachines[recipeName]
+		_, _, size := lg.machineFor(plan.MachineTypes[recipeName])
+		for i := 0; i < count; i++ {
+			if x > layoutMargin && x+size.Width > layoutMargin+rowWidth { // wrap to next row
+				x = layoutMargin
+				y += rowHeight + spacing
+				rowHeight = 0
 			}
 
-			layout.Buildings = append(layout.Buildings, building)
+			layout.Buildings = append(layout.Buildings, lg.MachineBuilding(buildingID, plan, recipeName, Position{X: x, Y: y}))
 
 			buildingID++
-			x += lg.MinSpacing + 1
-			if x > 20 { // wrap to next row
-				x = 0
-				y += lg.MinSpacing + 1
+			if feeds[recipeName] != "" {
+				x += size.Width + 1 // leave just the tile for the inserter into the next machine
+			} else {
+				x += size.Width + spacing
 			}
+			rowHeight = max(rowHeight, size.Height)
 		}
 	}
 
@@ -121,30 +207,142 @@ func (lg *LayoutGenerator) GenerateLayout(plan *ProductionPlan) (*FactoryLayout,
 	if len(layout.Buildings) > 0 {
 		maxX, maxY := 0, 0
 		for _, building := range layout.Buildings {
-			if building.Position.X > maxX {
-				maxX = building.Position.X
+			bounds := building.Bounds()
+			maxX = max(maxX, bounds.Max.X)
+			maxY = max(maxY, bounds.Max.Y)
+		}
+		layout.Width = maxX + layoutMargin
+		layout.Height = maxY + layoutMargin
+	}
+
+	lg.Connect(plan, layout)
+
+	return layout
+}
+
+// MachineBuilding returns the machine crafting a recipe of the plan at a
+// position.
+func (lg *LayoutGenerator) MachineBuilding(id int, plan *ProductionPlan, recipeName string, position Position) Building {
+	buildingType, entityName, size := lg.machineFor(plan.MachineTypes[recipeName])
+	itemName := entityName
+	if itemName == "" {
+		itemName = lg.getBuildingItemName(buildingType)
+	}
+	return Building{
+		ID:       fmt.Sprintf("building_%d", id),
+		Type:     buildingType,
+		Entity:   entityName,
+		Position: position,
+		Size:     size,
+		Recipe:   recipeName,
+		Label:    lg.recipeName(recipeName),
+		Rotation: 0,
+		Color:    lg.buildingColor(itemName),
+		Fluids:   plan.FluidRequirements[recipeName],
+	}
+}
+
+// producerOrder returns the recipes of the plan, each after the recipes
+// making its inputs and in name order otherwise. Recipes feeding each
+// other in a cycle are placed in name order.
+func producerOrder(plan *ProductionPlan) []string {
+	var recipes []string
+	for recipeName, count := range plan.RequiredMachines {
+		if count > 0 {
+			recipes = append(recipes, recipeName)
+		}
+	}
+	sort.Strings(recipes)
+
+	producers := make(map[string][]string)
+	for _, recipeName := range recipes {
+		for _, requirement := range plan.InserterRequirements[recipeName] {
+			if requirement.Output {
+				producers[requirement.Item] = append(producers[requirement.Item], recipeName)
 			}
-			if building.Position.Y > maxY {
-				maxY = building.Position.Y
+		}
+	}
+
+	ordered := make([]string, 0, len(recipes))
+	placed := make(map[string]bool)
+	for len(ordered) < len(recipes) {
+		progress := false
+		for _, recipeName := range recipes {
+			if placed[recipeName] {
+				continue
 			}
+			ready := true
+			for _, requirement := range plan.InserterRequirements[recipeName] {
+				for _, producer := range producers[requirement.Item] {
+					if !requirement.Output && producer != recipeName && !placed[producer] {
+						ready = false
+					}
+				}
+			}
+			if ready {
+				ordered = append(ordered, recipeName)
+				placed[recipeName] = true
+				progress = true
+			}
+		}
+		if !progress {
+			for _, recipeName := range recipes {
+				if !placed[recipeName] {
+					ordered = append(ordered, recipeName)
+					placed[recipeName] = true
+					break
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// buildingColor returns the color of a building's item, gray when unknown.
+func (lg *LayoutGenerator) buildingColor(itemName string) color.Color {
+	if lg.ColorProvider != nil {
+		if buildingColor, hasColor := lg.ColorProvider.GetItemColor(itemName); hasColor {
+			return buildingColor
 		}
-		layout.Width = maxX + 5 // add some padding
-		layout.Height = maxY + 5
 	}
+	return color.RGBA{150, 150, 150, 255} // gray
+}
 
-	return layout, nil
+// machineFor returns the building type, entity name and footprint of the
+// machine used for a recipe. Without entity data, machines are 1x1
+// assemblers.
+func (lg *LayoutGenerator) machineFor(entityName string) (string, string, Size) {
+	if lg.Entities != nil {
+		if entity, exists := lg.Entities.GetEntity(entityName); exists {
+			return entity.Type, entity.Name, entity.Footprint()
+		}
+	}
+	return "assembler", "", Size{Width: 1, Height: 1}
 }
 
-// ValidateLayout checks if a layout is valid and collision-free.
-func (lg *LayoutGenerator) ValidateLayout(layout *FactoryLayout) error {
-	// TODO: Implement collision detection and validation
-	// Check for overlapping buildings, invalid connections, etc.
+// title names a layout after the display names of the plan's targets.
+func (lg *LayoutGenerator) title(plan *ProductionPlan) string {
+	if len(plan.Targets) == 0 {
+		return "Factory Layout"
+	}
 
-	if len(layout.Buildings) == 0 {
-		return fmt.Errorf("layout contains no buildings")
+	names := make([]string, 0, len(plan.Targets))
+	for _, target := range plan.Targets {
+		name := target.Item
+		if lg.NameProvider != nil {
+			name = lg.NameProvider.ItemName(target.Item)
+		}
+		names = append(names, name)
 	}
+	return strings.Join(names, ", ")
+}
 
-	return nil
+// recipeName returns the display name of a recipe.
+func (lg *LayoutGenerator) recipeName(recipeName string) string {
+	if lg.NameProvider == nil {
+		return recipeName
+	}
+	return lg.NameProvider.RecipeName(recipeName)
 }
 
 // getBuildingItemName maps building types to their corresponding item names.
@@ -0,0 +1,159 @@
+// Package core contains mining and resource extraction requirements.
+package core
+
+import "math"
+
+// Resources extracted by something other than mining drills.
+const (
+	ResourceCrudeOil = "crude-oil"
+	ResourceWater    = "water"
+)
+
+// ResourceProvider provides information about raw resources.
+type ResourceProvider interface {
+	IsRawMaterial(itemName string) bool
+	GetMiningTime(itemName string) (float64, bool) // seconds per item at mining speed 1, for resources drills mine
+}
+
+// pumpjackBaseOutput is the crude oil per second of a pumpjack with mining
+// speed 1 on a well with 100% yield.
+const pumpjackBaseOutput = 10.0
+
+// MiningRequirement is the number of extractors of one type needed to supply
+// a raw resource at its planned rate.
+type MiningRequirement struct {
+	Entity   string  // drill, pumpjack or offshore pump entity name
+	Count    float64 // exact number of extractors
+	Required int     // whole extractors to build
+}
+
+// calculateMining fills in the extractor requirements for every raw
+// resource in the plan.
+func (opt *Optimizer) calculateMining(plan *ProductionPlan) {
+	for item, rate := range plan.ResourceFlow {
+		if !opt.isRawResource(item) {
+			continue
+		}
+
+		var requirements []MiningRequirement
+		for _, extractor := range opt.extractorOptions(item) {
+			count := opt.extractorsNeeded(extractor, item, rate)
+			if count <= 0 {
+				continue
+			}
+			requirements = append(requirements, MiningRequirement{
+				Entity:   extractor.Name,
+				Count:    count,
+				Required: int(math.Ceil(count - 1e-9)),
+			})
+		}
+
+		if len(requirements) > 0 {
+			plan.MiningRequirements[item] = requirements
+		}
+	}
+}
+
+// extractorOptions returns every extractor that can supply a raw resource.
+func (opt *Optimizer) extractorOptions(item string) []*Entity {
+	switch item {
+	case ResourceCrudeOil:
+		if opt.Pumpjack != nil {
+			return []*Entity{opt.Pumpjack}
+		}
+		return nil
+	case ResourceWater:
+		if opt.OffshorePump != nil {
+			return []*Entity{opt.OffshorePump}
+		}
+		return nil
+	}
+
+	if _, minable := opt.miningTime(item); !minable {
+		return nil
+	}
+	if len(opt.Drills) > 0 {
+		return opt.Drills
+	}
+	if opt.Miner != nil {
+		return []*Entity{opt.Miner}
+	}
+	return nil
+}
+
+// isRawResource reports whether an item is extracted rather than crafted.
+// Without a resource provider every item lacking a recipe counts as raw.
+func (opt *Optimizer) isRawResource(item string) bool {
+	switch {
+	case item == ResourceCrudeOil || item == ResourceWater:
+		return true
+	case opt.ResourceProvider != nil:
+		return opt.ResourceProvider.IsRawMaterial(item)
+	default:
+		return opt.selectRecipe(item) == nil
+	}
+}
+
+// miningTime returns the seconds a drill with mining speed 1 takes per
+// item of a resource, and whether drills mine it at all. Raw resources
+// gathered otherwise, such as wood, have no mining time. Without a
+// resource provider every raw resource takes one second.
+func (opt *Optimizer) miningTime(item string) (float64, bool) {
+	if item == ResourceCrudeOil || item == ResourceWater || !opt.isRawResource(item) {
+		return 0, false
+	}
+	if opt.ResourceProvider == nil {
+		return 1, true
+	}
+	return opt.ResourceProvider.GetMiningTime(item)
+}
+
+// extractorFor returns the extractor the plan uses to supply a raw resource,
+// or nil if the item is crafted or has no extractor configured.
+func (opt *Optimizer) extractorFor(item string) *Entity {
+	if !opt.isRawResource(item) {
+		return nil
+	}
+
+	switch item {
+	case ResourceCrudeOil:
+		return opt.Pumpjack
+	case ResourceWater:
+		return opt.OffshorePump
+	default:
+		if _, minable := opt.miningTime(item); !minable {
+			return nil
+		}
+		return opt.Miner
+	}
+}
+
+// extractorsNeeded returns the exact number of extractors needed to supply
+// an item at the given rate in items per minute.
+func (opt *Optimizer) extractorsNeeded(extractor *Entity, item string, rate float64) float64 {
+	itemsPerSecond := rate / 60.0
+
+	switch item {
+	case ResourceWater:
+		if extractor.PumpingSpeed <= 0 {
+			return 0
+		}
+		return itemsPerSecond / extractor.PumpingSpeed
+	case ResourceCrudeOil:
+		perPumpjack := pumpjackBaseOutput * extractor.CraftingSpeed * opt.OilYield * (1 + opt.Bonuses.MiningProductivity)
+		if perPumpjack <= 0 {
+			return 0
+		}
+		return itemsPerSecond / perPumpjack
+	}
+
+	miningTime, minable := opt.miningTime(item)
+	if !minable || miningTime <= 0 {
+		return 0
+	}
+	perDrill := extractor.CraftingSpeed * (1 + opt.Bonuses.MiningProductivity) / miningTime
+	if perDrill <= 0 {
+		return 0
+	}
+	return itemsPerSecond / perDrill
+}
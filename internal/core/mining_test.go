@@ -0,0 +1,85 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+// testResources is a resource provider with fixed mining times; raw items
+// missing from it are gathered rather than mined.
+type testResources map[string]float64
+
+func (r testResources) IsRawMaterial(itemName string) bool {
+	_, exists := r[itemName]
+	return exists || itemName == "wood"
+}
+
+func (r testResources) GetMiningTime(itemName string) (float64, bool) {
+	time, exists := r[itemName]
+	return time, exists && time > 0
+}
+
+func TestCalculateMining(t *testing.T) {
+	tests := []struct {
+		name         string
+		item         string
+		rate         float64 // items per minute
+		productivity float64
+		oilYield     float64
+		entity       string // extractor expected, empty for none
+		count        float64
+	}{
+		{name: "ore", item: "iron-ore", rate: 60, entity: "drill", count: 2},
+		{name: "slow ore", item: "uranium-ore", rate: 60, entity: "drill", count: 4},
+		{name: "mining productivity", item: "iron-ore", rate: 60, productivity: 0.25, entity: "drill", count: 1.6},
+		{name: "gathered, not mined", item: "wood", rate: 60},
+		{name: "crude oil", item: ResourceCrudeOil, rate: 600, oilYield: 0.5, entity: "pumpjack", count: 2},
+		{name: "water", item: ResourceWater, rate: 72000, entity: "offshore-pump", count: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := NewRecipeGraph()
+			graph.AddRecipe(&Recipe{
+				Name:         "gadget",
+				Inputs:       map[string]float64{tt.item: 1},
+				Outputs:      map[string]float64{"gadget": 1},
+				CraftingTime: 1,
+				Category:     "crafting",
+			})
+
+			opt := NewOptimizer(graph, nil)
+			opt.Machines["crafting"] = &Entity{Name: "assembler", CraftingSpeed: 1}
+			opt.Miner = &Entity{Name: "drill", CraftingSpeed: 0.5}
+			opt.Pumpjack = &Entity{Name: "pumpjack", CraftingSpeed: 1}
+			opt.OffshorePump = &Entity{Name: "offshore-pump", PumpingSpeed: 1200}
+			opt.ResourceProvider = testResources{"iron-ore": 1, "uranium-ore": 2}
+			opt.Bonuses.MiningProductivity = tt.productivity
+			if tt.oilYield > 0 {
+				opt.OilYield = tt.oilYield
+			}
+
+			plan, err := opt.OptimizeProduction([]ProductionTarget{{Item: "gadget", Rate: tt.rate}})
+			if err != nil {
+				t.Fatalf("OptimizeProduction: %v", err)
+			}
+
+			requirements := plan.MiningRequirements[tt.item]
+			if tt.entity == "" {
+				if len(requirements) > 0 {
+					t.Fatalf("got extractors %+v, want none", requirements)
+				}
+				return
+			}
+			if len(requirements) != 1 || requirements[0].Entity != tt.entity {
+				t.Fatalf("got extractors %+v, want %s", requirements, tt.entity)
+			}
+			if math.Abs(requirements[0].Count-tt.count) > 1e-9 {
+				t.Errorf("count = %v, want %v", requirements[0].Count, tt.count)
+			}
+			if want := int(math.Ceil(tt.count - 1e-9)); requirements[0].Required != want {
+				t.Errorf("required = %d, want %d", requirements[0].Required, want)
+			}
+		})
+	}
+}
@@ -1,33 +1,96 @@
 // Package core contains optimization algorithms for efficient factory planning.
 package core
 
-import "math"
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// maxRecipeDepth limits recursion when expanding recipe chains, so that
+// cyclic recipe data fails instead of overflowing the stack.
+const maxRecipeDepth = 64
 
 // ProductionTarget represents a desired production rate for an item.
 type ProductionTarget struct {
-	Item string  // item name
-	Rate float64 // items per minute
+	Item    string  // item name
+	Rate    float64 // items per minute
+	Quality Quality // quality of the produced items
 }
 
 // ProductionPlan represents the calculated production requirements.
 type ProductionPlan struct {
 	Targets          []ProductionTarget
-	RequiredMachines map[string]int     // recipe name -> number of machines needed
-	ResourceFlow     map[string]float64 // item name -> items per minute
-	TotalPowerUsage  float64            // estimated power consumption in MW
+	RequiredMachines map[string]int                 // recipe name -> number of machines needed
+	MachineCounts    map[string]float64             // recipe name -> exact (fractional) number of machines
+	Utilization      map[string]float64             // recipe name -> fraction of built machine capacity in use
+	MachineTypes     map[string]string              // recipe name -> entity name of the machine used
+	ResourceFlow     map[string]float64             // item name -> items per minute
+	Modules          map[string][]*Module           // recipe name -> modules used instead of the optimizer's, e.g. in quality loops
+	QualityOutput    map[string]map[Quality]float64 // item name -> items per minute leaving quality loops per quality
+	TotalPowerUsage  float64                        // estimated power consumption in MW
+
+	MiningRequirements   map[string][]MiningRequirement   // raw item -> extractors needed per extractor type
+	BeltRequirements     map[string][]BeltRequirement     // item name -> belts needed per unlocked belt tier
+	InserterRequirements map[string][]InserterRequirement // recipe name -> inserters needed per machine
+	FluidRequirements    map[string][]FluidRequirement    // recipe name -> fluids piped in and out per machine
+
+	Fuel            string  // fuel item burned by burner machines
+	FuelConsumption float64 // fuel items per minute burned by burner machines, inserters and boilers
+	FuelSupplied    bool    // no unlocked recipe makes the fuel, so it comes from outside the plan
+
+	MachinePollution map[string]float64 // recipe name -> pollution per minute of one machine
+	RecipePollution  map[string]float64 // recipe name -> pollution per minute of all its machines
+	MiningPollution  map[string]float64 // raw item -> pollution per minute of its extractors
+	BoilerPollution  float64            // pollution per minute of boilers powering the plan
+	TotalPollution   float64            // total pollution per minute
 }
 
 // Optimizer handles production optimization calculations.
 type Optimizer struct {
-	RecipeGraph *RecipeGraph
-	Research    map[string]bool // available technologies
+	RecipeGraph  *RecipeGraph
+	Research     map[string]bool      // available technologies
+	Bonuses      ResearchBonuses      // researched technology bonuses
+	Machines     map[string]*Entity   // crafting category -> machine used
+	Modules      map[string][]*Module // recipe name -> modules in each machine
+	Miner        *Entity              // mining drill used for raw resources
+	Boiler       *Entity              // boiler supplying electric power
+	RecipeChoice map[string]string    // item name -> preferred recipe name
+
+	Drills       []*Entity // mining drills to report requirements for
+	Pumpjack     *Entity   // extractor used for crude oil
+	OffshorePump *Entity   // extractor used for water
+	OilYield     float64   // average oil well yield, 1.0 = 100%
+
+	ResourceProvider ResourceProvider // provider telling raw resources from crafted items
+
+	Fuel         string       // fuel item burned by burner machines, empty to ignore fuel
+	FuelProvider FuelProvider // provider for fuel values
+
+	Belts         []*Belt       // unlocked belt tiers
+	Inserters     []*Inserter   // unlocked inserter types
+	FluidProvider FluidProvider // provider for fluid items
+
+	MaxQuality    Quality // highest unlocked quality
+	QualityModule *Module // module filling free slots of machines in quality loops
+}
+
+// RecipeRanking is the pollution of a plan made with one recipe alternative.
+type RecipeRanking struct {
+	Recipe         string
+	TotalPollution float64 // pollution per minute
+	TotalPower     float64 // power usage in MW
 }
 
 // NewOptimizer creates a new optimizer with the given recipe graph and research.
 func NewOptimizer(graph *RecipeGraph, research map[string]bool) *Optimizer {
 	return &Optimizer{
-		RecipeGraph: graph,
-		Research:    research,
+		RecipeGraph:  graph,
+		Research:     research,
+		Machines:     make(map[string]*Entity),
+		Modules:      make(map[string][]*Module),
+		RecipeChoice: make(map[string]string),
+		OilYield:     1.0,
 	}
 }
 
@@ -36,37 +99,204 @@ func (opt *Optimizer) OptimizeProduction(targets []ProductionTarget) (*Productio
 	plan := &ProductionPlan{
 		Targets:          targets,
 		RequiredMachines: make(map[string]int),
+		MachineCounts:    make(map[string]float64),
+		Utilization:      make(map[string]float64),
+		MachineTypes:     make(map[string]string),
 		ResourceFlow:     make(map[string]float64),
+		Modules:          make(map[string][]*Module),
+		QualityOutput:    make(map[string]map[Quality]float64),
 		TotalPowerUsage:  0.0,
+		MachinePollution: make(map[string]float64),
+		RecipePollution:  make(map[string]float64),
+		MiningPollution:  make(map[string]float64),
+
+		MiningRequirements:   make(map[string][]MiningRequirement),
+		BeltRequirements:     make(map[string][]BeltRequirement),
+		InserterRequirements: make(map[string][]InserterRequirement),
+		FluidRequirements:    make(map[string][]FluidRequirement),
 	}
 
-	// TODO: Implement actual optimization algorithm
-	// This would involve:
-	// 1. Building dependency tree for all target items
-	// 2. Calculating required production rates for intermediate items
-	// 3. Determining optimal recipe choices (when multiple options exist)
-	// 4. Computing machine counts based on crafting times
-	// 5. Minimizing resource waste and bottlenecks
+	// TODO: Determine optimal recipe choices when multiple options exist
+	// and minimize resource waste and bottlenecks.
 
-	// Placeholder calculation
 	for _, target := range targets {
-		recipes := opt.RecipeGraph.GetRecipesForItem(target.Item)
-		if len(recipes) > 0 {
-			recipe := recipes[0] // Use first available recipe for now
+		var err error
+		if target.Quality > QualityNormal {
+			err = opt.expandQualityItem(plan, target, 0)
+		} else {
+			err = opt.expandItem(plan, target.Item, target.Rate, 0)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
 
-			// Calculate machines needed (simplified)
-			itemsPerSecond := target.Rate / 60.0
-			outputPerSecond := recipe.Outputs[target.Item] / recipe.CraftingTime
-			machinesNeeded := math.Ceil(itemsPerSecond / outputPerSecond)
+	if err := opt.addFuelDemand(plan); err != nil {
+		return nil, err
+	}
 
-			plan.RequiredMachines[recipe.Name] = int(machinesNeeded)
-			plan.ResourceFlow[target.Item] = target.Rate
+	for recipeName, count := range plan.MachineCounts {
+		// Small epsilon keeps float noise from adding a whole machine
+		required := int(math.Ceil(count - 1e-9))
+		plan.RequiredMachines[recipeName] = required
+		if required > 0 {
+			plan.Utilization[recipeName] = count / float64(required)
 		}
 	}
 
+	opt.calculateMining(plan)
+	opt.calculatePower(plan)
+	opt.calculatePollution(plan)
+	opt.calculateThroughput(plan)
+
 	return plan, nil
 }
 
+// expandItem adds the production of an item at the given rate (items per
+// minute) to the plan, recursing into the inputs of the recipe used.
+func (opt *Optimizer) expandItem(plan *ProductionPlan, item string, rate float64, depth int) error {
+	if depth > maxRecipeDepth {
+		return fmt.Errorf("recipe chain for %s exceeds depth %d, recipes may be cyclic", item, maxRecipeDepth)
+	}
+
+	recipe := opt.selectRecipe(item)
+	if recipe == nil && !opt.isRawResource(item) {
+		return fmt.Errorf("no unlocked recipe produces %s", item)
+	}
+
+	plan.ResourceFlow[item] += rate
+	if recipe == nil {
+		return nil // raw resource
+	}
+
+	machine := opt.machineFor(recipe)
+	effects := CombineModules(opt.modules(plan, recipe.Name))
+
+	output := recipe.Outputs[item] * (1 + effects.Productivity)
+	if output <= 0 {
+		return fmt.Errorf("recipe %s produces no %s", recipe.Name, item)
+	}
+	craftsPerMinute := rate / output
+
+	speed := 1.0
+	if machine != nil {
+		speed = machine.CraftingSpeed
+		plan.MachineTypes[recipe.Name] = machine.Name
+	}
+	speed *= 1 + effects.Speed
+	plan.MachineCounts[recipe.Name] += craftsPerMinute / 60.0 * recipe.CraftingTime / speed
+
+	for input, quantity := range recipe.Inputs {
+		if err := opt.expandItem(plan, input, craftsPerMinute*quantity, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selectRecipe picks the recipe used to produce an item, or nil when the
+// item has no available recipe.
+func (opt *Optimizer) selectRecipe(item string) *Recipe {
+	recipes := opt.RecipeGraph.GetRecipesForItem(item)
+
+	if preferred, ok := opt.RecipeChoice[item]; ok {
+		for _, recipe := range recipes {
+			if recipe.Name == preferred && opt.IsRecipeAvailable(recipe.Name) {
+				return recipe
+			}
+		}
+	}
+
+	for _, recipe := range recipes {
+		if recipe.Category != RecipeCategoryRecycling && opt.IsRecipeAvailable(recipe.Name) {
+			return recipe // use first available recipe
+		}
+	}
+	return nil
+}
+
+// modules returns the modules in each machine running a recipe in a plan.
+func (opt *Optimizer) modules(plan *ProductionPlan, recipeName string) []*Module {
+	if modules, exists := plan.Modules[recipeName]; exists {
+		return modules
+	}
+	return opt.Modules[recipeName]
+}
+
+// machineFor returns the machine used to craft a recipe, or nil when no
+// machine is configured for its category.
+func (opt *Optimizer) machineFor(recipe *Recipe) *Entity {
+	return opt.Machines[recipe.Category]
+}
+
+// calculatePower estimates the electric power usage of the plan in MW.
+func (opt *Optimizer) calculatePower(plan *ProductionPlan) {
+	totalKW := 0.0
+	for recipeName, count := range plan.MachineCounts {
+		recipe := opt.RecipeGraph.Recipes[recipeName]
+		machine := opt.machineFor(recipe)
+		if machine == nil || !machine.IsElectric() {
+			continue
+		}
+		effects := CombineModules(opt.modules(plan, recipeName))
+		totalKW += count * machine.EnergyUsage * effects.EnergyMultiplier()
+	}
+
+	for item, rate := range plan.ResourceFlow {
+		extractor := opt.extractorFor(item)
+		if extractor == nil || !extractor.IsElectric() {
+			continue
+		}
+		totalKW += opt.extractorsNeeded(extractor, item, rate) * extractor.EnergyUsage
+	}
+
+	plan.TotalPowerUsage = totalKW / 1000.0
+}
+
+// RankRecipesByPollution plans the target once with each available recipe
+// for the target item and returns the alternatives ordered from least to
+// most polluting.
+func (opt *Optimizer) RankRecipesByPollution(target ProductionTarget) ([]RecipeRanking, error) {
+	previous, hadPrevious := opt.RecipeChoice[target.Item]
+	defer func() {
+		if hadPrevious {
+			opt.RecipeChoice[target.Item] = previous
+		} else {
+			delete(opt.RecipeChoice, target.Item)
+		}
+	}()
+
+	var rankings []RecipeRanking
+	for _, recipe := range opt.RecipeGraph.GetRecipesForItem(target.Item) {
+		if !opt.IsRecipeAvailable(recipe.Name) {
+			continue
+		}
+
+		opt.RecipeChoice[target.Item] = recipe.Name
+		plan, err := opt.OptimizeProduction([]ProductionTarget{target})
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan with recipe %s: %w", recipe.Name, err)
+		}
+
+		rankings = append(rankings, RecipeRanking{
+			Recipe:         recipe.Name,
+			TotalPollution: plan.TotalPollution,
+			TotalPower:     plan.TotalPowerUsage,
+		})
+	}
+
+	if len(rankings) == 0 {
+		return nil, fmt.Errorf("no available recipe produces %s", target.Item)
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].TotalPollution < rankings[j].TotalPollution
+	})
+
+	return rankings, nil
+}
+
 // IsRecipeAvailable checks if a recipe can be used with current research.
 func (opt *Optimizer) IsRecipeAvailable(recipeName string) bool {
 	// TODO: Implement technology dependency checking
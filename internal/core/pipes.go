@@ -0,0 +1,397 @@
+// Package core contains pipe routing for fluid recipes.
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Pipe is a pipe and the pipe-to-ground of the same kind.
+type Pipe struct {
+	Name              string
+	Underground       string // pipe-to-ground entity
+	UndergroundLength int    // most tiles a pipe-to-ground passes under
+}
+
+// pipeFlowLimits is the most fluid per second a pipeline carries by its
+// number of segments, from the game's fluid flow table.
+var pipeFlowLimits = []struct {
+	segments int
+	flow     float64
+}{
+	{1, 6000}, {2, 3000}, {3, 3000}, {7, 2000}, {12, 1500}, {17, 1200},
+	{20, 1125}, {30, 1053}, {50, 1034}, {100, 1000}, {150, 968}, {200, 800},
+	{261, 600}, {300, 500}, {400, 400}, {500, 334}, {600, 286}, {800, 223},
+	{1000, 200},
+}
+
+// MaxPipeFlow returns the fluid units per second a pipeline of the given
+// number of segments carries. Each pipe and each pipe-to-ground counts as
+// one segment, however far it reaches underground. Lengths between table
+// entries use the next longer entry.
+func MaxPipeFlow(segments int) float64 {
+	for _, limit := range pipeFlowLimits {
+		if segments <= limit.segments {
+			return limit.flow
+		}
+	}
+	last := pipeFlowLimits[len(pipeFlowLimits)-1]
+	return last.flow * float64(last.segments) / float64(segments)
+}
+
+// PipeRoute is a pipeline carrying one fluid between two buildings, or
+// between a building and the edge of the layout.
+type PipeRoute struct {
+	Fluid    string
+	From     string   // ID of the producing building, empty when entering at the left edge
+	To       string   // ID of the consuming building, or of the one fed by the pipeline joined; empty when leaving at the right edge
+	Rate     float64  // units per second
+	Start    Position // first new pipe
+	End      Position // last pipe, on the consumer's fluid box connection or next to the pipeline joined
+	Segments int      // pipe entities placed, which limit the flow
+	Length   int      // tiles covered, including underground stretches
+
+	// Pipes are the tiles of the pipes the fluid flows through from the
+	// source, including the pipes shared with earlier pipelines it
+	// branches off.
+	Pipes []Position
+}
+
+// moved returns the route shifted by an offset.
+func (route PipeRoute) moved(offset Position) PipeRoute {
+	route.Start, route.End = route.Start.Add(offset), route.End.Add(offset)
+	pipes := make([]Position, len(route.Pipes))
+	for i, pipe := range route.Pipes {
+		pipes[i] = pipe.Add(offset)
+	}
+	route.Pipes = pipes
+	return route
+}
+
+// fluidPort is a machine fluid box in use by the machine's recipe.
+type fluidPort struct {
+	building *Building
+	fluid    string
+	output   bool
+	rate     float64  // units per second
+	tile     Position // tile outside the machine where a pipe connects
+	side     int      // rotation pointing from the machine to the tile
+}
+
+// fluidPorts assigns the fluids of each machine to the machine's fluid
+// boxes in order, inputs and outputs separately, and returns where pipes
+// connect to them.
+func (lg *LayoutGenerator) fluidPorts(layout *FactoryLayout) []fluidPort {
+	var ports []fluidPort
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		requirements := building.Fluids
+		entity := lg.entity(building)
+		if len(requirements) == 0 || entity == nil {
+			continue
+		}
+
+		var inputs, outputs []FluidBox
+		for _, box := range entity.FluidBoxes {
+			if box.Output {
+				outputs = append(outputs, box)
+			} else {
+				inputs = append(inputs, box)
+			}
+		}
+		for _, requirement := range requirements {
+			boxes := &inputs
+			if requirement.Output {
+				boxes = &outputs
+			}
+			if len(*boxes) == 0 {
+				continue // the machine has no free fluid box for it
+			}
+			ports = append(ports, fluidPort{
+				building: building,
+				fluid:    requirement.Fluid,
+				output:   requirement.Output,
+				rate:     requirement.Rate,
+				tile:     building.fluidBoxTile((*boxes)[0]),
+				side:     (*boxes)[0].Direction + building.Rotation,
+			})
+			*boxes = (*boxes)[1:]
+		}
+	}
+	return ports
+}
+
+// fluidBoxTile returns the tile outside the building a fluid box connects
+// to, with the building's rotation applied.
+func (b *Building) fluidBoxTile(box FluidBox) Position {
+	size := Size{Width: max(b.Size.Width, 1), Height: max(b.Size.Height, 1)}
+	p := box.Position
+	for turn := 0; turn < ((b.Rotation%360+360)%360)/90; turn++ {
+		p = Position{X: size.Height - 1 - p.Y, Y: p.X} // quarter turn clockwise
+		size = Size{Width: size.Height, Height: size.Width}
+	}
+	return b.Position.Add(p).Add(DirectionOffset(box.Direction + b.Rotation))
+}
+
+// pipeConnection is a pipeline still to be routed.
+type pipeConnection struct {
+	fluid  string
+	source string     // key of the network the fluid comes from
+	from   *fluidPort // nil for the layout edge
+	to     *fluidPort // nil for the layout edge or another network
+	join   bool       // ends next to a pipe of another network of the fluid, or at the right edge for fluids leaving there
+	rate   float64    // units per second
+}
+
+// routePipes connects every fluid input of a machine to a machine making
+// the fluid, or to the left edge of the layout for fluids made elsewhere,
+// and carries target fluids and fluids no machine uses to the right edge.
+// Machines making a fluid that no input was given to, or a fluid leaving
+// that another machine already carries off, join the pipes of another
+// machine making it or run to the edge themselves. Pipes join every pipe next to them, so a
+// pipeline never runs beside a pipe or fluid box of another fluid;
+// pipe-to-ground crosses obstacles. Later pipelines of the same source
+// branch off the pipes already laid.
+func (lg *LayoutGenerator) routePipes(plan *ProductionPlan, layout *FactoryLayout) {
+	if lg.Pipe == nil {
+		return
+	}
+	ports := lg.fluidPorts(layout)
+	if len(ports) == 0 {
+		return
+	}
+
+	targets := make(map[string]bool, len(plan.Targets))
+	for _, target := range plan.Targets {
+		targets[target.Item] = true
+	}
+	producers := make(map[string][]*fluidPort)
+	consumed := make(map[string]bool)
+	for i := range ports {
+		if ports[i].output {
+			producers[ports[i].fluid] = append(producers[ports[i].fluid], &ports[i])
+		} else {
+			consumed[ports[i].fluid] = true
+		}
+	}
+	leaves := func(fluid string) bool { return targets[fluid] || !consumed[fluid] }
+
+	var connections, joins []pipeConnection
+	turn := make(map[string]int)
+	leaving := make(map[string]bool) // fluids a machine already carries to the edge
+	for i := range ports {
+		port := &ports[i]
+		if port.output {
+			if leaves(port.fluid) {
+				connections = append(connections, pipeConnection{fluid: port.fluid, source: port.building.ID + "/" + port.fluid, from: port, join: leaving[port.fluid], rate: port.rate})
+				leaving[port.fluid] = true
+			}
+			continue
+		}
+		connection := pipeConnection{fluid: port.fluid, source: "edge/" + port.fluid, to: port, rate: port.rate}
+		if sources := producers[port.fluid]; len(sources) > 0 {
+			connection.from = sources[turn[port.fluid]%len(sources)]
+			connection.source = connection.from.building.ID + "/" + port.fluid
+			turn[port.fluid]++
+		}
+		connections = append(connections, connection)
+	}
+	for fluid, sources := range producers {
+		if leaves(fluid) {
+			continue
+		}
+		for _, port := range sources[min(turn[fluid], len(sources)):] {
+			joins = append(joins, pipeConnection{fluid: fluid, source: port.building.ID + "/" + fluid, from: port, join: true, rate: port.rate})
+		}
+	}
+	sort.Slice(joins, func(i, j int) bool { return joins[i].source < joins[j].source })
+	connections = append(connections, joins...)
+
+	router := newBeltRouter(layout, Rect{Max: Position{X: layout.Width, Y: layout.Height}})
+	fluids := make(map[Position]string) // fluid of every pipe and fluid box connection
+	for _, port := range ports {
+		fluids[port.tile] = port.fluid
+	}
+	var fluid string
+	router.fits = func(p Position) bool {
+		for _, tile := range []Position{p, p.Add(Position{Y: -1}), p.Add(Position{X: 1}), p.Add(Position{Y: 1}), p.Add(Position{X: -1})} {
+			if other, exists := fluids[tile]; exists && other != fluid {
+				return false
+			}
+		}
+		return true
+	}
+
+	networks := make(map[string]map[Position][]Position) // pipes from each source to its surface pipes
+	consumers := make(map[string]string)                 // first building each source feeds
+	networkFluids := make(map[string]string)             // fluid of each source
+	sources := make(map[Position]string)                 // source of every surface pipe
+	portAt := make(map[Position]*fluidPort)
+	for i := range ports {
+		portAt[ports[i].tile] = &ports[i]
+	}
+	// A pipe-to-ground starting or ending a pipeline only joins what lies
+	// beyond its surface side: the machine, a pipe or the layout edge.
+	router.opens = func(end Position, dir int) bool {
+		if port, exists := portAt[end]; exists {
+			return dir*90 == (port.side+180)%360
+		}
+		next := end.Add(DirectionOffset(dir * 90))
+		_, piped := sources[next]
+		return piped || !router.area.Contains(next)
+	}
+	for _, connection := range connections {
+		fluid = connection.fluid
+		var goals []Position
+		if connection.join {
+			if _, joined := sources[connection.from.tile]; joined {
+				continue // an earlier pipeline runs past the fluid box
+			}
+			var joined []map[Position][]Position
+			for _, source := range sortedKeys(networks) {
+				if networkFluids[source] == fluid {
+					joined = append(joined, networks[source])
+				}
+			}
+			goals = freeNeighbors(router, joined...)
+			if leaves(fluid) {
+				goals = append(goals, router.edgeTiles(router.area.Max.X-1)...)
+			}
+		}
+		route, steps, ok := lg.routePipe(router, networks[connection.source], goals, connection)
+		if !ok {
+			layout.UnroutedPipes = append(layout.UnroutedPipes, route)
+			continue
+		}
+		if connection.join {
+			for dir := 0; dir < 4 && route.To == ""; dir++ {
+				if source, exists := sources[route.End.Add(DirectionOffset(dir*90))]; exists {
+					route.To = consumers[source]
+				}
+			}
+		}
+
+		router.claim(steps)
+		if networks[connection.source] == nil {
+			networks[connection.source] = make(map[Position][]Position)
+			consumers[connection.source] = route.To
+			networkFluids[connection.source] = connection.fluid
+		}
+		base := len(route.Pipes) - len(steps)
+		for i, step := range steps {
+			fluids[step.pos] = connection.fluid
+			building := Building{
+				ID:       fmt.Sprintf("pipe_%d", len(layout.Buildings)),
+				Type:     EntityTypePipe,
+				Entity:   lg.Pipe.Name,
+				Position: step.pos,
+				Size:     Size{Width: 1, Height: 1},
+				Fluid:    connection.fluid,
+			}
+			switch step.underground {
+			case UndergroundInput:
+				building.Rotation = (step.dir + 2) % 4 * 90 // the surface side faces back along the pipeline
+			case UndergroundOutput:
+				building.Rotation = step.dir * 90
+			default:
+				networks[connection.source][step.pos] = route.Pipes[: base+i+1 : base+i+1]
+				sources[step.pos] = connection.source
+			}
+			if step.underground != "" {
+				building.Type = EntityTypePipeToGround
+				building.Entity = lg.Pipe.Underground
+				building.Underground = step.underground
+			}
+			building.Color = lg.buildingColor(building.Entity)
+			layout.Buildings = append(layout.Buildings, building)
+		}
+		layout.Pipes = append(layout.Pipes, route)
+	}
+}
+
+// routePipe finds the pipes of one connection, starting from the source's
+// fluid box or next to a pipe already laid from the same source, and
+// ending at the consumer's fluid box, at one of the given goals of a
+// connection joining other pipelines or at the right edge. The route's
+// pipes and segments count from the source, through the pipes branched
+// off.
+func (lg *LayoutGenerator) routePipe(router *beltRouter, network map[Position][]Position, goals []Position, connection pipeConnection) (PipeRoute, []routeStep, bool) {
+	route := PipeRoute{Fluid: connection.fluid, Rate: connection.rate}
+
+	var starts []Position
+	switch {
+	case len(network) > 0:
+		starts = freeNeighbors(router, network)
+	case connection.from != nil:
+		starts = []Position{connection.from.tile}
+	default:
+		starts = router.edgeTiles(router.area.Min.X)
+	}
+	if connection.from != nil {
+		route.From = connection.from.building.ID
+	}
+	switch {
+	case connection.to != nil:
+		route.To = connection.to.building.ID
+		goals = []Position{connection.to.tile}
+	case connection.join:
+		// the goals next to the pipelines joined are given
+	default:
+		goals = router.edgeTiles(router.area.Max.X - 1)
+	}
+	if len(starts) == 0 || len(goals) == 0 {
+		return route, nil, false
+	}
+
+	steps, found := router.findPath(starts, goals, lg.Pipe.UndergroundLength)
+	if !found {
+		// Each tile is searched once, so a pipe-to-ground passing in front
+		// of a fluid box can shut out the pipe the box needs; searching
+		// from the other end reaches the box first.
+		if steps, found = router.findPath(goals, starts, lg.Pipe.UndergroundLength); !found {
+			return route, nil, false
+		}
+		steps = reverseSteps(steps)
+	}
+
+	route.Start, route.End = steps[0].pos, steps[len(steps)-1].pos
+	if len(network) > 0 {
+		var trunk []Position
+		for dir := 0; dir < 4; dir++ {
+			if pipes, exists := network[steps[0].pos.Add(DirectionOffset(dir*90))]; exists && (trunk == nil || len(pipes) < len(trunk)) {
+				trunk = pipes
+			}
+		}
+		route.Pipes = append(route.Pipes, trunk...)
+	}
+	for _, step := range steps {
+		route.Pipes = append(route.Pipes, step.pos)
+	}
+	route.Segments = len(route.Pipes)
+	route.Length = 1
+	for i := 1; i < len(steps); i++ {
+		route.Length += tileDistance(steps[i].pos, steps[i-1].pos)
+	}
+	return route, steps, true
+}
+
+// freeNeighbors returns the free tiles next to the surface pipes of the
+// networks, in row order.
+func freeNeighbors(router *beltRouter, networks ...map[Position][]Position) []Position {
+	var tiles []Position
+	seen := make(map[Position]bool)
+	for _, network := range networks {
+		for pipe := range network {
+			for dir := 0; dir < 4; dir++ {
+				if next := pipe.Add(DirectionOffset(dir * 90)); !seen[next] && router.free(next) {
+					seen[next] = true
+					tiles = append(tiles, next)
+				}
+			}
+		}
+	}
+	sort.Slice(tiles, func(i, j int) bool {
+		return tiles[i].Y < tiles[j].Y || (tiles[i].Y == tiles[j].Y && tiles[i].X < tiles[j].X)
+	})
+	return tiles
+}
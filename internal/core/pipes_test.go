@@ -0,0 +1,172 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestRoutePipes(t *testing.T) {
+	entities := testEntities{
+		"chemical-plant": {
+			Name: "chemical-plant", Type: "assembler", Width: 3, Height: 3,
+			FluidBoxes: []FluidBox{
+				{Position: Position{X: 0, Y: 0}, Direction: 0},
+				{Position: Position{X: 2, Y: 0}, Direction: 0},
+				{Position: Position{X: 1, Y: 2}, Direction: 180, Output: true},
+			},
+		},
+	}
+	machine := func(id, recipe string, x, y int) Building {
+		return Building{ID: id, Type: "assembler", Entity: "chemical-plant", Recipe: recipe, Position: Position{X: x, Y: y}, Size: Size{Width: 3, Height: 3}}
+	}
+	wall := func(x, y int) Building {
+		return Building{ID: fmt.Sprintf("wall-%d-%d", x, y), Type: "wall", Entity: "stone-wall", Position: Position{X: x, Y: y}, Size: Size{Width: 1, Height: 1}}
+	}
+	gas := func(rate float64, output bool) FluidRequirement {
+		return FluidRequirement{Fluid: "petroleum-gas", Rate: rate, Output: output}
+	}
+
+	type pipeline struct{ from, to string }
+	tests := []struct {
+		name      string
+		buildings []Building
+		fluids    map[string][]FluidRequirement
+		targets   []string
+		want      []pipeline // routed pipelines in order
+		unrouted  int
+		shared    bool // the second pipeline branches off the first
+		joins     bool // the second pipeline ends next to the first
+	}{
+		{
+			name:      "from the edge",
+			buildings: []Building{machine("plastic", "plastic-bar", 8, 4)},
+			fluids:    map[string][]FluidRequirement{"plastic-bar": {gas(10, false)}},
+			want:      []pipeline{{"", "plastic"}},
+		},
+		{
+			name:      "between machines",
+			buildings: []Building{machine("oil", "oil-processing", 2, 1), machine("plastic", "plastic-bar", 10, 4)},
+			fluids: map[string][]FluidRequirement{
+				"oil-processing": {gas(20, true)},
+				"plastic-bar":    {gas(10, false)},
+			},
+			want: []pipeline{{"oil", "plastic"}},
+		},
+		{
+			name:      "branching off a trunk",
+			buildings: []Building{machine("plastic-1", "plastic-bar", 6, 6), machine("plastic-2", "plastic-bar", 12, 6)},
+			fluids:    map[string][]FluidRequirement{"plastic-bar": {gas(10, false)}},
+			want:      []pipeline{{"", "plastic-1"}, {"", "plastic-2"}},
+			shared:    true,
+		},
+		{
+			name:      "two fluids kept apart",
+			buildings: []Building{machine("acid", "sulfuric-acid", 8, 4)},
+			fluids: map[string][]FluidRequirement{
+				"sulfuric-acid": {{Fluid: "water", Rate: 100}, {Fluid: "sulfur-dioxide", Rate: 5}},
+			},
+			want: []pipeline{{"", "acid"}, {"", "acid"}},
+		},
+		{
+			name:      "target fluid to the edge",
+			buildings: []Building{machine("oil", "oil-processing", 4, 2)},
+			fluids:    map[string][]FluidRequirement{"oil-processing": {gas(20, true)}},
+			targets:   []string{"petroleum-gas"},
+			want:      []pipeline{{"oil", ""}},
+		},
+		{
+			name:      "by-product to the edge",
+			buildings: []Building{machine("oil", "oil-processing", 4, 2)},
+			fluids:    map[string][]FluidRequirement{"oil-processing": {gas(20, true)}},
+			want:      []pipeline{{"oil", ""}},
+		},
+		{
+			name: "second producer joins the pipeline",
+			buildings: []Building{
+				machine("oil-1", "oil-processing", 2, 1), machine("oil-2", "oil-processing", 2, 7), machine("plastic", "plastic-bar", 12, 4),
+			},
+			fluids: map[string][]FluidRequirement{
+				"oil-processing": {gas(10, true)},
+				"plastic-bar":    {gas(20, false)},
+			},
+			want:  []pipeline{{"oil-1", "plastic"}, {"oil-2", "plastic"}},
+			joins: true,
+		},
+		{
+			name:      "pipe-to-ground facing the fluid box",
+			buildings: []Building{machine("plastic", "plastic-bar", 8, 4), wall(7, 3), wall(8, 2), wall(9, 3)},
+			fluids:    map[string][]FluidRequirement{"plastic-bar": {gas(10, false)}},
+			want:      []pipeline{{"", "plastic"}},
+		},
+		{
+			name:      "fluid box outside the layout",
+			buildings: []Building{machine("plastic", "plastic-bar", 8, 0)},
+			fluids:    map[string][]FluidRequirement{"plastic-bar": {gas(10, false)}},
+			unrouted:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg := NewLayoutGenerator()
+			lg.Entities = entities
+			lg.Pipe = &Pipe{Name: "pipe", Underground: "pipe-to-ground", UndergroundLength: 10}
+
+			plan := &ProductionPlan{FluidRequirements: tt.fluids}
+			for _, target := range tt.targets {
+				plan.Targets = append(plan.Targets, ProductionTarget{Item: target})
+			}
+			layout := &FactoryLayout{Buildings: append([]Building(nil), tt.buildings...), Width: 20, Height: 12}
+			for i := range layout.Buildings {
+				layout.Buildings[i].Fluids = tt.fluids[layout.Buildings[i].Recipe]
+			}
+			lg.routePipes(plan, layout)
+
+			if len(layout.Pipes) != len(tt.want) || len(layout.UnroutedPipes) != tt.unrouted {
+				t.Fatalf("routed %d and left %d pipelines, want %d and %d", len(layout.Pipes), len(layout.UnroutedPipes), len(tt.want), tt.unrouted)
+			}
+
+			pipes := make(map[Position]Building)
+			for _, building := range layout.Buildings[len(tt.buildings):] {
+				pipes[building.Position] = building
+			}
+			for i, route := range layout.Pipes {
+				if route.From != tt.want[i].from || route.To != tt.want[i].to {
+					t.Errorf("pipeline %d runs %q -> %q, want %q -> %q", i, route.From, route.To, tt.want[i].from, tt.want[i].to)
+				}
+				if route.Segments != len(route.Pipes) || route.Pipes[len(route.Pipes)-1] != route.End {
+					t.Errorf("pipeline %d has %d segments over %v, want them ending at %v", i, route.Segments, route.Pipes, route.End)
+				}
+				if route.To == "" && route.End.X != layout.Width-1 {
+					t.Errorf("pipeline %d ends at %v, want the right edge", i, route.End)
+				}
+				for _, tile := range route.Pipes {
+					if pipe, exists := pipes[tile]; !exists || pipe.Fluid != route.Fluid {
+						t.Errorf("pipeline %d has no %s pipe at %v", i, route.Fluid, tile)
+					}
+				}
+			}
+			if tt.shared && layout.Pipes[1].Pipes[0] != layout.Pipes[0].Pipes[0] {
+				t.Errorf("second pipeline starts at %v, want it to branch off the first at %v", layout.Pipes[1].Pipes[0], layout.Pipes[0].Pipes[0])
+			}
+
+			if tt.joins && !slices.ContainsFunc(layout.Pipes[0].Pipes, func(pipe Position) bool {
+				return tileDistance(pipe, layout.Pipes[1].End) == 1
+			}) {
+				t.Errorf("second pipeline ends at %v, want it next to the first", layout.Pipes[1].End)
+			}
+
+			for _, violation := range lg.ValidateLayout(layout) {
+				switch violation.Rule {
+				case RuleOverlap, RuleFluidMix, RuleFluidBoxMix, RulePipeFlow:
+					t.Errorf("violation %s", violation)
+				case RuleUnpipedInput, RuleUnpipedOutput:
+					if tt.unrouted == 0 {
+						t.Errorf("violation %s", violation)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package core contains pollution estimation for production plans.
+package core
+
+// calculatePollution fills in the per-machine, mining, boiler and total
+// pollution figures of a plan. Emissions scale with the actual amount of
+// work done, so partially utilized machines pollute proportionally less.
+func (opt *Optimizer) calculatePollution(plan *ProductionPlan) {
+	total := 0.0
+
+	for recipeName, count := range plan.MachineCounts {
+		recipe := opt.RecipeGraph.Recipes[recipeName]
+		machine := opt.machineFor(recipe)
+		if machine == nil {
+			continue
+		}
+
+		effects := CombineModules(opt.modules(plan, recipeName))
+		perMachine := machine.Emissions * effects.PollutionMultiplier()
+
+		plan.MachinePollution[recipeName] = perMachine
+		plan.RecipePollution[recipeName] = perMachine * count
+		total += perMachine * count
+	}
+
+	for item, rate := range plan.ResourceFlow {
+		extractor := opt.extractorFor(item)
+		if extractor == nil || extractor.Emissions == 0 {
+			continue
+		}
+		pollution := opt.extractorsNeeded(extractor, item, rate) * extractor.Emissions
+		plan.MiningPollution[item] = pollution
+		total += pollution
+	}
+
+	if opt.Boiler != nil && opt.Boiler.EnergyUsage > 0 {
+		boilers := plan.TotalPowerUsage * 1000.0 / opt.Boiler.EnergyUsage
+		plan.BoilerPollution = boilers * opt.Boiler.Emissions
+		total += plan.BoilerPollution
+	}
+
+	plan.TotalPollution = total
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculatePollution(t *testing.T) {
+	tests := []struct {
+		name     string
+		modules  []*Module
+		miner    bool
+		boiler   bool
+		machines float64 // pollution per minute of the assemblers
+		mining   float64 // pollution per minute of the drills
+		boilers  float64 // pollution per minute of the boilers
+	}{
+		{name: "machines only", machines: 8},
+		{name: "efficiency module", modules: []*Module{{Name: "efficiency", Consumption: -0.3}}, machines: 5.6},
+		{name: "pollution bonus", modules: []*Module{{Name: "dirty", Pollution: 0.5}}, machines: 12},
+		{name: "mining drills", miner: true, machines: 8, mining: 40},
+		// 2 assemblers at 75 kW and 4 drills at 90 kW need 510 kW of 1800 kW boilers
+		{name: "boilers", miner: true, boiler: true, machines: 8, mining: 40, boilers: 510.0 / 1800 * 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := NewRecipeGraph()
+			graph.AddRecipe(&Recipe{
+				Name:         "gadget",
+				Inputs:       map[string]float64{"ore": 1},
+				Outputs:      map[string]float64{"gadget": 1},
+				CraftingTime: 1,
+				Category:     "crafting",
+			})
+
+			opt := NewOptimizer(graph, nil)
+			opt.Machines["crafting"] = &Entity{Name: "assembler", CraftingSpeed: 1, EnergyUsage: 75, EnergySource: EnergySourceElectric, Emissions: 4}
+			opt.Modules["gadget"] = tt.modules
+			if tt.miner {
+				opt.Miner = &Entity{Name: "drill", CraftingSpeed: 0.5, EnergyUsage: 90, EnergySource: EnergySourceElectric, Emissions: 10}
+			}
+			if tt.boiler {
+				opt.Boiler = &Entity{Name: "boiler", EnergyUsage: 1800, EnergySource: EnergySourceBurner, Emissions: 30}
+			}
+
+			plan, err := opt.OptimizeProduction([]ProductionTarget{{Item: "gadget", Rate: 120}})
+			if err != nil {
+				t.Fatalf("OptimizeProduction: %v", err)
+			}
+
+			if got := plan.RecipePollution["gadget"]; math.Abs(got-tt.machines) > 1e-9 {
+				t.Errorf("machine pollution = %v, want %v", got, tt.machines)
+			}
+			if got := plan.MiningPollution["ore"]; math.Abs(got-tt.mining) > 1e-9 {
+				t.Errorf("mining pollution = %v, want %v", got, tt.mining)
+			}
+			if got := plan.BoilerPollution; math.Abs(got-tt.boilers) > 1e-9 {
+				t.Errorf("boiler pollution = %v, want %v", got, tt.boilers)
+			}
+			if want := tt.machines + tt.mining + tt.boilers; math.Abs(plan.TotalPollution-want) > 1e-9 {
+				t.Errorf("total pollution = %v, want %v", plan.TotalPollution, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+// Package core contains electric pole placement for factory layouts.
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// poleCandidate is a free spot for a pole and the powered buildings its
+// supply area would reach.
+type poleCandidate struct {
+	position Position
+	covers   []int // indices of powered buildings
+}
+
+// polePlan is the poles of one pole type covering a layout.
+type polePlan struct {
+	entity     *Entity
+	positions  []Position
+	connection int // index of the pole at the layout edge
+}
+
+// placePoles powers the layout's electric buildings. Each unlocked pole
+// type is tried in turn: poles are placed greedily where they reach the
+// most unpowered buildings, a pole is added at the layout edge for the
+// outside network, and relay poles join poles out of wire reach. The type
+// needing the fewest poles is placed.
+func (lg *LayoutGenerator) placePoles(layout *FactoryLayout) {
+	var powered []Rect
+	for i := range layout.Buildings {
+		if entity := lg.entity(&layout.Buildings[i]); entity != nil && entity.IsElectric() {
+			powered = append(powered, layout.Buildings[i].Bounds())
+		}
+	}
+	if len(powered) == 0 || len(lg.Poles) == 0 {
+		return
+	}
+
+	poles := append([]*Entity(nil), lg.Poles...)
+	sort.SliceStable(poles, func(i, j int) bool {
+		return poles[i].SupplyArea < poles[j].SupplyArea
+	})
+
+	var best *polePlan
+	for _, pole := range poles {
+		plan, ok := lg.planPoles(layout, pole, powered)
+		if ok && (best == nil || len(plan.positions) < len(best.positions)) {
+			best = plan
+		}
+	}
+	if best == nil {
+		return
+	}
+
+	for i, position := range best.positions {
+		id := fmt.Sprintf("pole_%d", len(layout.Buildings))
+		if i == best.connection {
+			layout.PowerConnection = id
+		}
+		layout.Buildings = append(layout.Buildings, Building{
+			ID:       id,
+			Type:     EntityTypeElectricPole,
+			Entity:   best.entity.Name,
+			Position: position,
+			Size:     best.entity.Footprint(),
+			Color:    lg.buildingColor(best.entity.Name),
+		})
+	}
+}
+
+// planPoles places poles of one type: cover, edge connection and relays.
+// It fails when some building cannot be reached or the poles cannot be
+// joined into one network.
+func (lg *LayoutGenerator) planPoles(layout *FactoryLayout, pole *Entity, powered []Rect) (*polePlan, bool) {
+	occupied := make(map[Position]bool)
+	for i := range layout.Buildings {
+		bounds := layout.Buildings[i].Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				occupied[Position{X: x, Y: y}] = true
+			}
+		}
+	}
+	area := Rect{Max: Position{X: layout.Width, Y: layout.Height}}
+	size := pole.Footprint()
+	free := func(p Position) bool {
+		for y := p.Y; y < p.Y+size.Height; y++ {
+			for x := p.X; x < p.X+size.Width; x++ {
+				if !area.Contains(Position{X: x, Y: y}) || occupied[Position{X: x, Y: y}] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	plan := &polePlan{entity: pole}
+	place := func(p Position) {
+		plan.positions = append(plan.positions, p)
+		for y := p.Y; y < p.Y+size.Height; y++ {
+			for x := p.X; x < p.X+size.Width; x++ {
+				occupied[Position{X: x, Y: y}] = true
+			}
+		}
+	}
+
+	var candidates []poleCandidate
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			p := Position{X: x, Y: y}
+			if !free(p) {
+				continue
+			}
+			supply := poleSupply(p, size, pole.SupplyArea)
+			candidate := poleCandidate{position: p}
+			for i, bounds := range powered {
+				if suppliesBounds(supply, bounds) {
+					candidate.covers = append(candidate.covers, i)
+				}
+			}
+			if len(candidate.covers) > 0 {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	// Greedy set cover: the candidate reaching the most unpowered buildings
+	// first, the earliest in row order on ties.
+	unpowered := len(powered)
+	covered := make([]bool, len(powered))
+	for unpowered > 0 {
+		bestIndex, bestCount := -1, 0
+		for i, candidate := range candidates {
+			if !free(candidate.position) {
+				continue
+			}
+			count := 0
+			for _, index := range candidate.covers {
+				if !covered[index] {
+					count++
+				}
+			}
+			if count > bestCount {
+				bestIndex, bestCount = i, count
+			}
+		}
+		if bestIndex < 0 {
+			return nil, false
+		}
+		place(candidates[bestIndex].position)
+		for _, index := range candidates[bestIndex].covers {
+			if !covered[index] {
+				covered[index] = true
+				unpowered--
+			}
+		}
+	}
+
+	// Connection point: a pole already at the layout edge, or a new one on
+	// the free edge spot closest to the placed poles.
+	onEdge := func(p Position) bool {
+		return p.X == area.Min.X || p.Y == area.Min.Y || p.X+size.Width == area.Max.X || p.Y+size.Height == area.Max.Y
+	}
+	plan.connection = -1
+	for i, placed := range plan.positions {
+		if onEdge(placed) {
+			plan.connection = i
+			break
+		}
+	}
+	if plan.connection < 0 {
+		connection, closest := Position{}, math.Inf(1)
+		for y := area.Min.Y; y < area.Max.Y; y++ {
+			for x := area.Min.X; x < area.Max.X; x++ {
+				p := Position{X: x, Y: y}
+				if !onEdge(p) || !free(p) {
+					continue
+				}
+				for _, placed := range plan.positions {
+					if distance := wireDistance(p, placed); distance < closest {
+						connection, closest = p, distance
+					}
+				}
+			}
+		}
+		if math.IsInf(closest, 1) {
+			return nil, false
+		}
+		plan.connection = len(plan.positions)
+		place(connection)
+	}
+
+	// Join the network: extend from the component holding the first pole
+	// towards the closest pole outside it until every pole is connected.
+	for {
+		connected := connectedPoles(plan.positions, pole.WireReach)
+		from, to, gap := -1, -1, math.Inf(1)
+		for i := range plan.positions {
+			for j := range plan.positions {
+				if connected[i] && !connected[j] {
+					if distance := wireDistance(plan.positions[i], plan.positions[j]); distance < gap {
+						from, to, gap = i, j, distance
+					}
+				}
+			}
+		}
+		if to < 0 {
+			return plan, true
+		}
+
+		relay, relayGap := Position{}, gap
+		reach := int(pole.WireReach)
+		origin := plan.positions[from]
+		for y := origin.Y - reach; y <= origin.Y+reach; y++ {
+			for x := origin.X - reach; x <= origin.X+reach; x++ {
+				p := Position{X: x, Y: y}
+				if !free(p) || wireDistance(p, origin) > pole.WireReach {
+					continue
+				}
+				if distance := wireDistance(p, plan.positions[to]); distance < relayGap {
+					relay, relayGap = p, distance
+				}
+			}
+		}
+		if relayGap >= gap {
+			return nil, false // no free spot brings the networks closer
+		}
+		place(relay)
+	}
+}
+
+// connectedPoles marks the poles wired to the first pole, directly or
+// through other poles.
+func connectedPoles(positions []Position, wireReach float64) []bool {
+	connected := make([]bool, len(positions))
+	if len(positions) == 0 {
+		return connected
+	}
+	connected[0] = true
+	queue := []int{0}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for next := range positions {
+			if !connected[next] && wireDistance(positions[current], positions[next]) <= wireReach {
+				connected[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return connected
+}
+
+// wireDistance returns the distance in tiles between the centers of two
+// poles of the same size.
+func wireDistance(a, b Position) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+// poleSupply returns the supply area of a pole in half tiles, so that odd
+// supply sizes around a tile center stay exact.
+func poleSupply(position Position, size Size, supplyArea int) Rect {
+	center := Position{X: 2*position.X + size.Width, Y: 2*position.Y + size.Height}
+	return Rect{
+		Min: Position{X: center.X - supplyArea, Y: center.Y - supplyArea},
+		Max: Position{X: center.X + supplyArea, Y: center.Y + supplyArea},
+	}
+}
+
+// suppliesBounds reports whether a supply area in half tiles touches any
+// tile of a building's bounds.
+func suppliesBounds(supply, bounds Rect) bool {
+	return 2*bounds.Min.X < supply.Max.X && supply.Min.X < 2*bounds.Max.X &&
+		2*bounds.Min.Y < supply.Max.Y && supply.Min.Y < 2*bounds.Max.Y
+}
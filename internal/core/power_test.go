@@ -0,0 +1,110 @@
+package core
+
+import "testing"
+
+func TestPlacePoles(t *testing.T) {
+	small := &Entity{Name: "small-pole", Type: EntityTypeElectricPole, Width: 1, Height: 1, SupplyArea: 5, WireReach: 7.5}
+	substation := &Entity{Name: "substation", Type: EntityTypeElectricPole, Width: 2, Height: 2, SupplyArea: 18, WireReach: 18}
+
+	tests := []struct {
+		name       string
+		buildings  []Building
+		area       Size
+		poles      []*Entity
+		wantEntity string // empty when no pole is placed
+		wantCount  int
+	}{
+		{
+			name:       "one machine",
+			buildings:  []Building{assembler("a", 1, 1)},
+			area:       Size{Width: 5, Height: 5},
+			poles:      []*Entity{small},
+			wantEntity: "small-pole", wantCount: 1,
+		},
+		{
+			name:       "relays between distant machines",
+			buildings:  []Building{assembler("a", 1, 1), assembler("b", 20, 1)},
+			area:       Size{Width: 25, Height: 5},
+			poles:      []*Entity{small},
+			wantEntity: "small-pole", wantCount: 4,
+		},
+		{
+			name:       "fewest poles",
+			buildings:  []Building{assembler("a", 1, 1), assembler("b", 20, 1)},
+			area:       Size{Width: 25, Height: 5},
+			poles:      []*Entity{small, substation},
+			wantEntity: "substation", wantCount: 1,
+		},
+		{
+			name:      "no free tile",
+			buildings: []Building{assembler("a", 0, 0)},
+			area:      Size{Width: 3, Height: 3},
+			poles:     []*Entity{small},
+		},
+		{
+			name:      "nothing electric",
+			buildings: []Building{belt("b", 1, 1, 90)},
+			area:      Size{Width: 5, Height: 5},
+			poles:     []*Entity{small},
+		},
+		{
+			name:      "no pole unlocked",
+			buildings: []Building{assembler("a", 1, 1)},
+			area:      Size{Width: 5, Height: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities := testEntities{"assembler": validationEntities["assembler"]}
+			for _, pole := range tt.poles {
+				entities[pole.Name] = pole
+			}
+			lg := NewLayoutGenerator()
+			lg.Entities = entities
+			lg.Poles = tt.poles
+			layout := &FactoryLayout{Buildings: append([]Building(nil), tt.buildings...), Width: tt.area.Width, Height: tt.area.Height}
+			lg.placePoles(layout)
+
+			var positions []Position
+			var connection *Building
+			for i := range layout.Buildings[len(tt.buildings):] {
+				pole := &layout.Buildings[len(tt.buildings)+i]
+				if pole.Type != EntityTypeElectricPole || pole.Entity != tt.wantEntity {
+					t.Errorf("placed %s %s, want %s", pole.Type, pole.Entity, tt.wantEntity)
+				}
+				if pole.ID == layout.PowerConnection {
+					connection = pole
+				}
+				positions = append(positions, pole.Position)
+			}
+			if len(positions) != tt.wantCount {
+				t.Fatalf("placed %d poles, want %d", len(positions), tt.wantCount)
+			}
+			if tt.wantCount == 0 {
+				if layout.PowerConnection != "" {
+					t.Errorf("power connection = %q, want none", layout.PowerConnection)
+				}
+				return
+			}
+
+			for _, violation := range lg.ValidateLayout(layout) {
+				if violation.Rule == RuleUnpowered || violation.Rule == RuleOverlap || violation.Rule == RuleOutOfBounds {
+					t.Errorf("violation %s", violation)
+				}
+			}
+			for i, connected := range connectedPoles(positions, entities[tt.wantEntity].WireReach) {
+				if !connected {
+					t.Errorf("pole at %v is not wired to the network", positions[i])
+				}
+			}
+			if connection == nil {
+				t.Fatalf("power connection %q is not a placed pole", layout.PowerConnection)
+			}
+			bounds := connection.Bounds()
+			if bounds.Min.X > 0 && bounds.Min.Y > 0 && bounds.Max.X < tt.area.Width && bounds.Max.Y < tt.area.Height {
+				t.Errorf("power connection at %v is not on the layout edge", connection.Position)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Package core contains quality tiers and upcycling loop planning.
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quality is an item quality tier, as added by Factorio 2.0 Space Age.
+type Quality int
+
+// Quality tiers from lowest to highest.
+const (
+	QualityNormal Quality = iota
+	QualityUncommon
+	QualityRare
+	QualityEpic
+	QualityLegendary
+)
+
+// RecipeCategoryRecycling is the crafting category of recycling recipes.
+const RecipeCategoryRecycling = "recycling"
+
+// Recycling and quality constants of the game.
+const (
+	qualityNextTierChance = 0.1      // chance of each further tier once an item upgrades
+	recyclingReturn       = 0.25     // fraction of ingredients a recycler returns
+	recyclingTimeFactor   = 1.0 / 16 // recycling time relative to the recipe's crafting time
+)
+
+var qualityNames = [...]string{"normal", "uncommon", "rare", "epic", "legendary"}
+
+// String returns the game's name for the quality.
+func (q Quality) String() string {
+	if q < QualityNormal || int(q) >= len(qualityNames) {
+		return fmt.Sprintf("quality(%d)", int(q))
+	}
+	return qualityNames[q]
+}
+
+// ParseQuality parses a quality name such as "legendary".
+func ParseQuality(name string) (Quality, error) {
+	for i, qualityName := range qualityNames {
+		if strings.EqualFold(name, qualityName) {
+			return Quality(i), nil
+		}
+	}
+	return QualityNormal, fmt.Errorf("unknown quality %q, expected one of %s", name, strings.Join(qualityNames[:], ", "))
+}
+
+// QualityDistribution returns the probability of a craft started at quality
+// from ending up at each quality up to highest, indexed by quality. With
+// the given quality chance the result upgrades one tier, and each further
+// tier follows with a 10% chance; whatever would pass highest stays there.
+func QualityDistribution(from, highest Quality, chance float64) []float64 {
+	chance = min(max(chance, 0), 1)
+	distribution := make([]float64, highest+1)
+	if from >= highest {
+		distribution[highest] = 1
+		return distribution
+	}
+
+	distribution[from] = 1 - chance
+	remaining := chance
+	for q := from + 1; q < highest; q++ {
+		distribution[q] = remaining * (1 - qualityNextTierChance)
+		remaining *= qualityNextTierChance
+	}
+	distribution[highest] += remaining
+	return distribution
+}
+
+// RecyclingRecipe returns the recycler recipe for an item made by recipe.
+// It turns one item back into a quarter of the ingredients used to make
+// it, in a sixteenth of the crafting time.
+func RecyclingRecipe(recipe *Recipe, item string) *Recipe {
+	perItem := recyclingReturn / recipe.Outputs[item]
+	outputs := make(map[string]float64, len(recipe.Inputs))
+	for input, quantity := range recipe.Inputs {
+		outputs[input] = quantity * perItem
+	}
+
+	return &Recipe{
+		Name:         item + "-recycling",
+		Inputs:       map[string]float64{item: 1},
+		Outputs:      outputs,
+		CraftingTime: recipe.CraftingTime * recyclingTimeFactor,
+		Category:     RecipeCategoryRecycling,
+	}
+}
+
+// addRecyclingRecipe registers the recycling recipe of an item in the
+// graph's recipe table. It is not registered as a producer of the
+// ingredients, so it is never picked for normal production.
+func (rg *RecipeGraph) addRecyclingRecipe(recipe *Recipe, item string) *Recipe {
+	recycling := RecyclingRecipe(recipe, item)
+	if existing, exists := rg.Recipes[recycling.Name]; exists {
+		return existing
+	}
+	rg.Recipes[recycling.Name] = recycling
+	return recycling
+}
+
+// qualityModules returns the modules in a machine of a quality loop: the
+// recipe's configured modules, with the remaining slots filled with the
+// optimizer's quality module.
+func (opt *Optimizer) qualityModules(recipeName string, machine *Entity) []*Module {
+	modules := append([]*Module(nil), opt.Modules[recipeName]...)
+	if opt.QualityModule == nil || machine == nil {
+		return modules
+	}
+	for len(modules) < machine.ModuleSlots {
+		modules = append(modules, opt.QualityModule)
+	}
+	return modules
+}
+
+// expandQualityItem adds an upcycling loop producing an item at a quality
+// above normal. Fresh normal ingredients are crafted with quality modules;
+// products below the target quality are recycled into a quarter of their
+// ingredients, which keep their quality and may upgrade again, and are
+// crafted anew. Products at or above the target quality leave the loop.
+func (opt *Optimizer) expandQualityItem(plan *ProductionPlan, target ProductionTarget, depth int) error {
+	item := target.Item
+	if target.Quality > opt.MaxQuality {
+		return fmt.Errorf("%s quality is not unlocked", target.Quality)
+	}
+
+	recipe := opt.selectRecipe(item)
+	if recipe == nil {
+		return fmt.Errorf("%s has no recipe, so its quality cannot be raised", item)
+	}
+	if recipe.NoQuality {
+		return fmt.Errorf("recipe %s does not allow quality", recipe.Name)
+	}
+	machine := opt.machineFor(recipe)
+	recycler := opt.Machines[RecipeCategoryRecycling]
+	if machine == nil || recycler == nil {
+		return fmt.Errorf("quality loop for %s needs a %s machine and a recycler", item, recipe.Category)
+	}
+
+	recycling := opt.RecipeGraph.addRecyclingRecipe(recipe, item)
+	plan.Modules[recipe.Name] = opt.qualityModules(recipe.Name, machine)
+	plan.Modules[recycling.Name] = opt.qualityModules(recycling.Name, recycler)
+	craftEffects := CombineModules(plan.Modules[recipe.Name])
+	recycleEffects := CombineModules(plan.Modules[recycling.Name])
+
+	yield := recipe.Outputs[item] * (1 + craftEffects.Productivity) // items per craft
+	returned := recyclingReturn / recipe.Outputs[item]              // ingredient sets per recycled item
+	if yield <= 0 {
+		return fmt.Errorf("recipe %s produces no %s", recipe.Name, item)
+	}
+
+	// Flows per fresh ingredient set, by quality. Qualities only go up, so
+	// each tier is settled once the tiers below it are; a tier's own
+	// recycling feeds back into it, which is solved in closed form.
+	highest := opt.MaxQuality
+	crafts := make([]float64, highest+1)   // ingredient sets crafted
+	made := make([]float64, highest+1)     // items crafted
+	recycled := make([]float64, highest+1) // items recycled
+	sets := make([]float64, highest+1)     // ingredient sets arriving, fresh or recycled
+	sets[QualityNormal] = 1
+
+	for q := QualityNormal; q <= highest; q++ {
+		craftOdds := QualityDistribution(q, highest, craftEffects.Quality)
+		recycleOdds := QualityDistribution(q, highest, recycleEffects.Quality)
+
+		if q < target.Quality {
+			selfCraft := yield * craftOdds[q]
+			selfReturn := returned * recycleOdds[q]
+			crafts[q] = (sets[q] + selfReturn*made[q]) / (1 - selfReturn*selfCraft)
+			made[q] += crafts[q] * selfCraft
+			recycled[q] = made[q]
+		} else {
+			crafts[q] = sets[q]
+			made[q] += crafts[q] * yield * craftOdds[q]
+		}
+
+		for higher := q + 1; higher <= highest; higher++ {
+			made[higher] += crafts[q] * yield * craftOdds[higher]
+			sets[higher] += recycled[q] * returned * recycleOdds[higher]
+		}
+	}
+
+	if made[target.Quality] <= 0 {
+		return fmt.Errorf("quality loop for %s never reaches %s quality, add quality modules", item, target.Quality)
+	}
+	fresh := target.Rate / made[target.Quality] // fresh ingredient sets per minute
+
+	totalCrafts, totalMade, totalRecycled := 0.0, 0.0, 0.0
+	for q := QualityNormal; q <= highest; q++ {
+		totalCrafts += crafts[q] * fresh
+		totalMade += made[q] * fresh
+		totalRecycled += recycled[q] * fresh
+		if q >= target.Quality {
+			if plan.QualityOutput[item] == nil {
+				plan.QualityOutput[item] = make(map[Quality]float64)
+			}
+			plan.QualityOutput[item][q] += made[q] * fresh
+		}
+	}
+
+	plan.ResourceFlow[item] += totalMade
+	plan.MachineTypes[recipe.Name] = machine.Name
+	plan.MachineCounts[recipe.Name] += totalCrafts / 60.0 * recipe.CraftingTime /
+		(machine.CraftingSpeed * (1 + craftEffects.Speed))
+	plan.MachineTypes[recycling.Name] = recycler.Name
+	plan.MachineCounts[recycling.Name] += totalRecycled / 60.0 * recycling.CraftingTime /
+		(recycler.CraftingSpeed * (1 + recycleEffects.Speed))
+
+	for input, quantity := range recipe.Inputs {
+		if err := opt.expandItem(plan, input, fresh*quantity, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQualityDistribution(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    Quality
+		highest Quality
+		chance  float64
+		want    []float64
+	}{
+		{"no chance", QualityNormal, QualityRare, 0, []float64{1, 0, 0}},
+		{"ten percent to legendary", QualityNormal, QualityLegendary, 0.1, []float64{0.9, 0.09, 0.009, 0.0009, 0.0001}},
+		{"capped at highest", QualityNormal, QualityUncommon, 0.2, []float64{0.8, 0.2}},
+		{"from uncommon", QualityUncommon, QualityRare, 0.1, []float64{0, 0.9, 0.1}},
+		{"at highest", QualityRare, QualityRare, 0.1, []float64{0, 0, 1}},
+		{"chance clamped", QualityNormal, QualityUncommon, 1.5, []float64{0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := QualityDistribution(tt.from, tt.highest, tt.chance)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for q := range got {
+				if math.Abs(got[q]-tt.want[q]) > 1e-12 {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// simulateQualityLoop follows one fresh ingredient set through a quality
+// loop round by round and returns the items leaving it at the target
+// quality. Items above the target leave the loop as well.
+func simulateQualityLoop(target, highest Quality, yield, craftChance, recycleChance float64) float64 {
+	sets := make([]float64, highest+1)
+	sets[QualityNormal] = 1
+	output := 0.0
+
+	for round := 0; round < 1000; round++ {
+		next := make([]float64, highest+1)
+		for q, count := range sets {
+			for result, odds := range QualityDistribution(Quality(q), highest, craftChance) {
+				items := count * yield * odds
+				if Quality(result) >= target {
+					if Quality(result) == target {
+						output += items
+					}
+					continue
+				}
+				for returned, returnOdds := range QualityDistribution(Quality(result), highest, recycleChance) {
+					next[returned] += items * recyclingReturn / yield * returnOdds
+				}
+			}
+		}
+		sets = next
+	}
+	return output
+}
+
+func TestExpandQualityItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		quality Quality
+		highest Quality
+		output  float64 // items per craft
+	}{
+		{"uncommon", QualityUncommon, QualityUncommon, 1},
+		{"rare of epic", QualityRare, QualityEpic, 1},
+		{"legendary", QualityLegendary, QualityLegendary, 1},
+		{"legendary from two per craft", QualityLegendary, QualityLegendary, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := NewRecipeGraph()
+			graph.AddRecipe(&Recipe{
+				Name:         "gadget",
+				Inputs:       map[string]float64{"ore": 3},
+				Outputs:      map[string]float64{"gadget": tt.output},
+				CraftingTime: 2,
+				Category:     "crafting",
+			})
+
+			opt := NewOptimizer(graph, nil)
+			opt.Machines["crafting"] = &Entity{Name: "assembler", CraftingSpeed: 1, ModuleSlots: 4}
+			opt.Machines[RecipeCategoryRecycling] = &Entity{Name: "recycler", CraftingSpeed: 0.5, ModuleSlots: 4}
+			opt.QualityModule = &Module{Name: "quality", Quality: 0.025}
+			opt.MaxQuality = tt.highest
+
+			const rate = 10.0
+			plan, err := opt.OptimizeProduction([]ProductionTarget{{Item: "gadget", Rate: rate, Quality: tt.quality}})
+			if err != nil {
+				t.Fatalf("OptimizeProduction: %v", err)
+			}
+
+			perSet := simulateQualityLoop(tt.quality, tt.highest, tt.output, 0.1, 0.1)
+			wantOre := rate / perSet * 3
+			if got := plan.ResourceFlow["ore"]; math.Abs(got-wantOre) > 1e-6*wantOre {
+				t.Errorf("ore = %.6f/min, want %.6f/min", got, wantOre)
+			}
+
+			for q := range plan.QualityOutput["gadget"] {
+				if q < tt.quality {
+					t.Errorf("output at %s quality below the target", q)
+				}
+			}
+			if got := plan.QualityOutput["gadget"][tt.quality]; math.Abs(got-rate) > 1e-9 {
+				t.Errorf("output = %.6f/min, want %.6f/min", got, rate)
+			}
+		})
+	}
+}
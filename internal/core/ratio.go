@@ -0,0 +1,100 @@
+// Package core contains the whole-number machine ratio finder.
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ratioTolerance is how close a machine count must be to a whole number to
+// count as whole.
+const ratioTolerance = 1e-6
+
+// RatioSuggestion is a scaled set of targets where every recipe needs a
+// whole number of fully utilized machines.
+type RatioSuggestion struct {
+	Scale    float64            // factor applied to the requested target rates
+	Targets  []ProductionTarget // scaled targets
+	Machines map[string]int     // recipe name -> machines needed
+}
+
+// FindWholeRatios suggests target rates near the requested ones at which
+// every recipe in the plan runs a whole number of machines, like the classic
+// 10:12:3 science ratios. Machine counts scale linearly with the targets, so
+// each candidate scale makes one recipe's count whole and is kept if all the
+// others come out whole too. At most limit suggestions are returned, closest
+// to the requested rates first, and no recipe needs more than maxMachines.
+func (opt *Optimizer) FindWholeRatios(targets []ProductionTarget, maxMachines, limit int) ([]RatioSuggestion, error) {
+	plan, err := opt.OptimizeProduction(targets)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.MachineCounts) == 0 {
+		return nil, fmt.Errorf("plan has no machines to balance")
+	}
+
+	seen := make(map[float64]bool)
+	var suggestions []RatioSuggestion
+
+	for _, count := range plan.MachineCounts {
+		if count <= 0 {
+			continue
+		}
+		for n := 1; n <= maxMachines; n++ {
+			scale := float64(n) / count
+			key := math.Round(scale/ratioTolerance) * ratioTolerance
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			machines, ok := wholeMachineCounts(plan.MachineCounts, scale, maxMachines)
+			if !ok {
+				continue
+			}
+
+			scaled := make([]ProductionTarget, len(targets))
+			for i, target := range targets {
+				scaled[i] = target
+				scaled[i].Rate = target.Rate * scale
+			}
+			suggestions = append(suggestions, RatioSuggestion{
+				Scale:    scale,
+				Targets:  scaled,
+				Machines: machines,
+			})
+		}
+	}
+
+	if len(suggestions) == 0 {
+		return nil, fmt.Errorf("no whole-number ratio within %d machines per recipe", maxMachines)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		return math.Abs(math.Log(suggestions[i].Scale)) < math.Abs(math.Log(suggestions[j].Scale))
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
+
+// wholeMachineCounts scales fractional machine counts and reports whether
+// they all come out as whole numbers no larger than maxMachines.
+func wholeMachineCounts(counts map[string]float64, scale float64, maxMachines int) (map[string]int, bool) {
+	machines := make(map[string]int, len(counts))
+	for recipeName, count := range counts {
+		scaled := count * scale
+		rounded := math.Round(scaled)
+		if math.Abs(scaled-rounded) > ratioTolerance*math.Max(1, scaled) {
+			return nil, false
+		}
+		if rounded > float64(maxMachines) {
+			return nil, false
+		}
+		machines[recipeName] = int(rounded)
+	}
+	return machines, true
+}
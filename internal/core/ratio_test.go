@@ -0,0 +1,75 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFindWholeRatios(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        float64 // red packs per minute
+		maxMachines int
+		limit       int
+		want        []float64 // scales, closest to the requested rate first
+		wantErr     bool
+	}{
+		// 60/min needs 5 pack and 0.5 gear machines
+		{name: "doubled", rate: 60, maxMachines: 10, want: []float64{2}},
+		{name: "several scales", rate: 60, maxMachines: 20, want: []float64{2, 4}},
+		{name: "limited", rate: 60, maxMachines: 20, limit: 1, want: []float64{2}},
+		{name: "already whole", rate: 120, maxMachines: 20, want: []float64{1, 2}},
+		{name: "halved", rate: 240, maxMachines: 10, want: []float64{0.5}},
+		{name: "too few machines", rate: 60, maxMachines: 5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := NewRecipeGraph()
+			graph.AddRecipe(&Recipe{
+				Name:         "red-pack",
+				Inputs:       map[string]float64{"copper-plate": 1, "gear": 1},
+				Outputs:      map[string]float64{"red-pack": 1},
+				CraftingTime: 5,
+				Category:     "crafting",
+			})
+			graph.AddRecipe(&Recipe{
+				Name:         "gear",
+				Inputs:       map[string]float64{"iron-plate": 2},
+				Outputs:      map[string]float64{"gear": 1},
+				CraftingTime: 0.5,
+				Category:     "crafting",
+			})
+			opt := NewOptimizer(graph, nil)
+			opt.Machines["crafting"] = &Entity{Name: "assembler", CraftingSpeed: 1}
+
+			suggestions, err := opt.FindWholeRatios([]ProductionTarget{{Item: "red-pack", Rate: tt.rate}}, tt.maxMachines, tt.limit)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FindWholeRatios = %+v, want an error", suggestions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindWholeRatios: %v", err)
+			}
+
+			if len(suggestions) != len(tt.want) {
+				t.Fatalf("got %d suggestions %+v, want scales %v", len(suggestions), suggestions, tt.want)
+			}
+			for i, suggestion := range suggestions {
+				if math.Abs(suggestion.Scale-tt.want[i]) > 1e-9 {
+					t.Errorf("suggestion %d scale = %v, want %v", i, suggestion.Scale, tt.want[i])
+				}
+				if want := tt.rate * tt.want[i]; math.Abs(suggestion.Targets[0].Rate-want) > 1e-9 {
+					t.Errorf("suggestion %d rate = %v, want %v", i, suggestion.Targets[0].Rate, want)
+				}
+				// 1 pack/s takes 5 pack and 0.5 gear machines
+				packs := tt.rate / 60 * tt.want[i] * 5
+				if suggestion.Machines["red-pack"] != int(math.Round(packs)) || suggestion.Machines["gear"] != int(math.Round(packs/10)) {
+					t.Errorf("suggestion %d machines = %v, want %v packs and %v gears", i, suggestion.Machines, packs, packs/10)
+				}
+			}
+		})
+	}
+}
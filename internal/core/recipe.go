@@ -3,11 +3,12 @@ package core
 
 // Recipe represents a Factorio recipe with inputs, outputs, and production time.
 type Recipe struct {
-	Name         string
-	Inputs       map[string]float64 // item name -> quantity required
-	Outputs      map[string]float64 // item name -> quantity produced
-	CraftingTime float64            // time in seconds
-	Category     string             // crafting category (e.g., "crafting", "smelting")
+	Name         string             `json:"name"`
+	Inputs       map[string]float64 `json:"inputs"`               // item name -> quantity required
+	Outputs      map[string]float64 `json:"outputs"`              // item name -> quantity produced
+	CraftingTime float64            `json:"crafting_time"`        // time in seconds
+	Category     string             `json:"category"`             // crafting category (e.g., "crafting", "smelting")
+	NoQuality    bool               `json:"no_quality,omitempty"` // quality modules have no effect, like allow_quality = false
 }
 
 // RecipeGraph represents the dependency graph of all recipes.
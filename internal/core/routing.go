@@ -0,0 +1,1114 @@
+// Package core contains belt routing between the buildings of a layout.
+package core
+
+import (
+	"container/heap"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Belt routing costs and limits. Turns and underground belts cost extra so
+// routes prefer straight surface belts.
+const (
+	routeTurnCost        = 0.5
+	routeUndergroundCost = 4.0
+	routeSideCost        = 1.0   // extra for tiles beside a machine, left to inserters where possible
+	maxRouteExpansions   = 20000 // search states expanded per path before giving up
+	maxRoutingRounds     = 12    // attempts at routing all belt lines
+)
+
+// Underground belt ends.
+const (
+	UndergroundInput  = "input"
+	UndergroundOutput = "output"
+)
+
+// BeltRoute is a belt line carrying one item past the buildings loading
+// it and the buildings unloading it. A line starts at its first building,
+// or at the left edge of the layout for items made elsewhere, and ends at
+// its last building, or at the right edge for the plan's products.
+type BeltRoute struct {
+	Item   string
+	From   string     // ID of the building at the start of the line, empty when entering at the left edge
+	To     string     // ID of the building at the end of the line, empty when leaving at the right edge
+	Rate   float64    // items per second
+	Belt   string     // belt tier used
+	Start  Position   // first belt tile
+	End    Position   // last belt tile
+	Stops  []BeltStop // buildings loading and unloading the line, in belt order
+	Length int        // tiles covered, including underground stretches
+	Direct bool       // an inserter moves items straight from From to To without a belt
+}
+
+// BeltStop is a building an inserter loads a belt line from or unloads it
+// into. For direct insertion the inserter takes from the other building
+// instead of a belt.
+type BeltStop struct {
+	Building string   // building ID
+	Inserter Position // tile of the inserter beside the building
+	Belt     Position // belt tile the inserter reaches
+	Load     bool     // the inserter puts the building's products on the belt
+	Branch   bool     // the belt is a branch side-loading onto the line, for a building the line misses
+	Rate     float64  // items per second the inserter moves
+}
+
+// moved returns the route shifted by an offset.
+func (route BeltRoute) moved(offset Position) BeltRoute {
+	route.Start, route.End = route.Start.Add(offset), route.End.Add(offset)
+	stops := make([]BeltStop, len(route.Stops))
+	for i, stop := range route.Stops {
+		stop.Inserter, stop.Belt = stop.Inserter.Add(offset), stop.Belt.Add(offset)
+		stops[i] = stop
+	}
+	route.Stops = stops
+	return route
+}
+
+// Kinds of router tiles; underground belt ends also record their axis so
+// that other underground belts do not pass under them.
+type routeTile int
+
+const (
+	tileFree routeTile = iota
+	tileBlocked
+	tileUndergroundX // underground belt end running east or west
+	tileUndergroundY // underground belt end running north or south
+)
+
+// routeState is a search state: a tile, the direction the belt arrived
+// in (0 north to 3 west, or noDirection at the start of a new path) and
+// whether it is an underground exit.
+type routeState struct {
+	pos  Position
+	dir  int
+	exit bool
+}
+
+// noDirection is the direction of the first tile of a new path, which may
+// leave in any direction.
+const noDirection = -1
+
+// routeNode is a queued search state.
+type routeNode struct {
+	state  routeState
+	cost   float64    // cost from the start
+	score  float64    // cost plus heuristic
+	parent *routeNode // previous state on the path, nil at the start
+}
+
+// undergroundAxis returns the tile kind of an underground end running in
+// a direction (0 north to 3 west).
+func undergroundAxis(dir int) routeTile {
+	if DirectionOffset(dir*90).X != 0 {
+		return tileUndergroundX
+	}
+	return tileUndergroundY
+}
+
+// routeQueue is a priority queue of search nodes ordered by score.
+type routeQueue []*routeNode
+
+func (q routeQueue) Len() int           { return len(q) }
+func (q routeQueue) Less(i, j int) bool { return q[i].score < q[j].score }
+func (q routeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x any)        { *q = append(*q, x.(*routeNode)) }
+func (q *routeQueue) Pop() any {
+	old := *q
+	node := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return node
+}
+
+// tileGrid holds the kind of every tile of an area. Tiles outside it read
+// as free and ignore changes.
+type tileGrid struct {
+	area  Rect
+	kinds []routeTile
+}
+
+// newTileGrid creates a grid of free tiles.
+func newTileGrid(area Rect) tileGrid {
+	width, height := max(area.Max.X-area.Min.X, 0), max(area.Max.Y-area.Min.Y, 0)
+	return tileGrid{area: area, kinds: make([]routeTile, width*height)}
+}
+
+// index returns the position of a tile inside the area in kinds.
+func (g tileGrid) index(p Position) int {
+	return (p.Y-g.area.Min.Y)*(g.area.Max.X-g.area.Min.X) + p.X - g.area.Min.X
+}
+
+// at returns the kind of a tile.
+func (g tileGrid) at(p Position) routeTile {
+	if !g.area.Contains(p) {
+		return tileFree
+	}
+	return g.kinds[g.index(p)]
+}
+
+// set changes the kind of a tile.
+func (g tileGrid) set(p Position, kind routeTile) {
+	if g.area.Contains(p) {
+		g.kinds[g.index(p)] = kind
+	}
+}
+
+// clone returns a copy of the grid.
+func (g tileGrid) clone() tileGrid {
+	return tileGrid{area: g.area, kinds: slices.Clone(g.kinds)}
+}
+
+// beltRouter finds belt paths on the layout grid around placed buildings.
+type beltRouter struct {
+	area    Rect
+	tiles   tileGrid
+	fits    func(p Position) bool            // extra condition on path tiles, nil for none
+	beside  []bool                           // per tile, whether it is next to a machine; nil for none
+	opens   func(end Position, dir int) bool // whether a path may start or end with an underground end whose surface side faces dir; nil for any
+	scratch *searchGrid                      // reused by every search
+}
+
+// searchGrid holds the state costs and taken tiles of a search. Searches
+// reuse it, clearing it by moving on to a new stamp.
+type searchGrid struct {
+	stamp       uint32
+	costStamps  []uint32 // per state, the stamp of the search its cost is from
+	costs       []float64
+	takenStamps []uint32 // per tile, the stamp of the search it is taken in
+	taken       []routeTile
+}
+
+// newRouter creates a router for an area with every tile free.
+func newRouter(area Rect) *beltRouter {
+	return &beltRouter{area: area, tiles: newTileGrid(area)}
+}
+
+// newBeltRouter creates a router for an area, treating every tile covered
+// by a building as blocked.
+func newBeltRouter(layout *FactoryLayout, area Rect) *beltRouter {
+	router := newRouter(area)
+	for i := range layout.Buildings {
+		router.block(layout.Buildings[i].Bounds(), tileBlocked)
+	}
+	return router
+}
+
+// block marks all tiles of a rectangle.
+func (r *beltRouter) block(rect Rect, kind routeTile) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			r.tiles.set(Position{X: x, Y: y}, kind)
+		}
+	}
+}
+
+// markBeside marks the tiles around the machines of a layout, which belts
+// avoid so that inserters can go there.
+func (r *beltRouter) markBeside(layout *FactoryLayout) {
+	r.beside = make([]bool, len(r.tiles.kinds))
+	for i := range layout.Buildings {
+		if layout.Buildings[i].Recipe == "" {
+			continue
+		}
+		bounds := layout.Buildings[i].Bounds()
+		for y := bounds.Min.Y - 1; y <= bounds.Max.Y; y++ {
+			for x := bounds.Min.X - 1; x <= bounds.Max.X; x++ {
+				if p := (Position{X: x, Y: y}); r.area.Contains(p) {
+					r.beside[r.tiles.index(p)] = true
+				}
+			}
+		}
+	}
+}
+
+// stepCost returns the extra cost of a belt on a tile.
+func (r *beltRouter) stepCost(p Position) float64 {
+	if r.beside != nil && r.beside[r.tiles.index(p)] {
+		return routeSideCost
+	}
+	return 0
+}
+
+// free reports whether a belt can be placed on a tile.
+func (r *beltRouter) free(p Position) bool {
+	return r.area.Contains(p) && r.tiles.at(p) == tileFree && (r.fits == nil || r.fits(p))
+}
+
+// newSearch returns the scratch grid cleared for a new search.
+func (r *beltRouter) newSearch() *searchGrid {
+	if r.scratch == nil {
+		tiles := len(r.tiles.kinds)
+		r.scratch = &searchGrid{
+			costStamps:  make([]uint32, tiles*10),
+			costs:       make([]float64, tiles*10),
+			takenStamps: make([]uint32, tiles),
+			taken:       make([]routeTile, tiles),
+		}
+	}
+	r.scratch.stamp++
+	return r.scratch
+}
+
+// stateIndex returns the index of a search state in a searchGrid: five
+// directions, noDirection included, and whether it is an exit, per tile.
+func (r *beltRouter) stateIndex(state routeState) int {
+	index := (r.tiles.index(state.pos)*5 + state.dir + 1) * 2
+	if state.exit {
+		index++
+	}
+	return index
+}
+
+// routeStep is one tile of a found path.
+type routeStep struct {
+	pos         Position
+	dir         int    // direction the belt points in
+	underground string // UndergroundInput or UndergroundOutput, empty for a plain belt
+}
+
+// findPath searches for the cheapest belt path from any start tile to any
+// goal tile with A*. Belts may dive under up to maxUnderground tiles of
+// obstacles in a straight line. A path never returns to a tile it already
+// covers.
+func (r *beltRouter) findPath(starts, goals []Position, maxUnderground int) ([]routeStep, bool) {
+	var states []routeState
+	for _, start := range starts {
+		if r.free(start) {
+			states = append(states, routeState{pos: start, dir: noDirection})
+		}
+	}
+	return r.search(states, goals, maxUnderground)
+}
+
+// extendPath searches for the cheapest way to continue a path from its
+// last state to any goal tile. The last tile itself may be taken already;
+// the returned steps start with it.
+func (r *beltRouter) extendPath(from routeState, goals []Position, maxUnderground int) ([]routeStep, bool) {
+	return r.search([]routeState{from}, goals, maxUnderground)
+}
+
+// search runs the A* search of findPath from a set of start states,
+// expanding at most maxRouteExpansions states.
+func (r *beltRouter) search(starts []routeState, goals []Position, maxUnderground int) ([]routeStep, bool) {
+	if len(goals) == 0 {
+		return nil, false
+	}
+	isGoal := make(map[Position]bool, len(goals))
+	box := Rect{Min: goals[0], Max: goals[0]}
+	for _, goal := range goals {
+		isGoal[goal] = true
+		box.Min = Position{X: min(box.Min.X, goal.X), Y: min(box.Min.Y, goal.Y)}
+		box.Max = Position{X: max(box.Max.X, goal.X), Y: max(box.Max.Y, goal.Y)}
+	}
+	// The distance to the box around the goals never overestimates the
+	// distance to the nearest goal.
+	heuristic := func(p Position) float64 {
+		dx := max(box.Min.X-p.X, p.X-box.Max.X, 0)
+		dy := max(box.Min.Y-p.Y, p.Y-box.Max.Y, 0)
+		return float64(dx + dy)
+	}
+
+	grid := r.newSearch()
+	queue := &routeQueue{}
+	push := func(state routeState, cost float64, parent *routeNode) {
+		index := r.stateIndex(state)
+		if grid.costStamps[index] == grid.stamp && grid.costs[index] <= cost {
+			return
+		}
+		grid.costStamps[index], grid.costs[index] = grid.stamp, cost
+		heap.Push(queue, &routeNode{state: state, cost: cost, score: cost + heuristic(state.pos), parent: parent})
+	}
+	taken := func(p Position) routeTile {
+		if index := r.tiles.index(p); r.area.Contains(p) && grid.takenStamps[index] == grid.stamp {
+			return grid.taken[index]
+		}
+		return tileFree
+	}
+	take := func(p Position, kind routeTile) {
+		index := r.tiles.index(p)
+		grid.takenStamps[index], grid.taken[index] = grid.stamp, kind
+	}
+	for _, start := range starts {
+		push(start, 0, nil)
+	}
+
+	// Every tile is expanded once, by its first state off the queue, and
+	// then taken: a path only ever runs over expanded tiles, so it cannot
+	// return to one it already covers. Expanded underground ends also keep
+	// later belts of the same axis from passing under them.
+	for expansions := 0; queue.Len() > 0 && expansions < maxRouteExpansions; expansions++ {
+		node := heap.Pop(queue).(*routeNode)
+		state := node.state
+		if node.cost > grid.costs[r.stateIndex(state)] || taken(state.pos) != tileFree {
+			continue // superseded by a cheaper path, or the tile is taken
+		}
+		if isGoal[state.pos] {
+			if !state.exit || r.opens == nil || r.opens(state.pos, state.dir) {
+				return r.steps(node), true
+			}
+			continue // leave the goal to a state arriving from the side it opens to
+		}
+		take(state.pos, tileBlocked)
+		if state.exit {
+			take(state.pos, undergroundAxis(state.dir))
+			if node.parent != nil {
+				take(node.parent.state.pos, undergroundAxis(state.dir)) // the entrance
+			}
+		}
+
+		for dir := 0; dir < 4; dir++ {
+			if (state.exit && dir != state.dir) || (state.dir != noDirection && dir == (state.dir+2)%4) {
+				continue // underground exits only continue straight; no U-turns
+			}
+			next := state.pos.Add(DirectionOffset(dir * 90))
+			if !r.free(next) || taken(next) != tileFree {
+				continue
+			}
+			cost := node.cost + 1 + r.stepCost(next)
+			if dir != state.dir && state.dir != noDirection {
+				cost += routeTurnCost
+			}
+			push(routeState{pos: next, dir: dir}, cost, node)
+		}
+
+		if state.exit {
+			continue
+		}
+		for dir := 0; dir < 4; dir++ {
+			if dir != state.dir && state.dir != noDirection {
+				continue // underground belts enter straight on
+			}
+			if node.parent == nil && r.opens != nil && !r.opens(state.pos, (dir+2)%4) {
+				continue
+			}
+			offset := DirectionOffset(dir * 90)
+			axis := undergroundAxis(dir)
+			for length := 2; length <= maxUnderground+1; length++ {
+				exit := state.pos.Add(offset.Scale(length))
+				between := state.pos.Add(offset.Scale(length - 1))
+				if !r.area.Contains(exit) || r.tiles.at(between) == axis || taken(between) == axis {
+					break // same-axis underground ends in between would capture the belt
+				}
+				// An exit that cannot carry on straight is a dead end, and
+				// taking its tile would shut out paths that can.
+				if r.free(exit) && taken(exit) == tileFree && (isGoal[exit] || r.free(exit.Add(offset))) {
+					push(routeState{pos: exit, dir: dir, exit: true}, node.cost+float64(length)+routeUndergroundCost+r.stepCost(exit), node)
+				}
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// steps turns the chain of search nodes ending at last into path steps.
+func (r *beltRouter) steps(last *routeNode) []routeStep {
+	var states []routeState
+	for node := last; node != nil; node = node.parent {
+		states = append(states, node.state)
+	}
+	for i, j := 0, len(states)-1; i < j; i, j = i+1, j-1 {
+		states[i], states[j] = states[j], states[i]
+	}
+
+	steps := make([]routeStep, len(states))
+	for i, state := range states {
+		step := routeStep{pos: state.pos, dir: max(state.dir, 0)}
+		if state.exit {
+			step.underground = UndergroundOutput
+		}
+		if i+1 < len(states) {
+			next := states[i+1]
+			step.dir = next.dir
+			if next.exit {
+				step.underground = UndergroundInput
+			}
+		}
+		steps[i] = step
+	}
+	return steps
+}
+
+// reverseSteps turns a path around: the steps run from its last tile to
+// its first, and underground entrances become exits.
+func reverseSteps(steps []routeStep) []routeStep {
+	reversed := make([]routeStep, len(steps))
+	for i, step := range steps {
+		step.dir = (steps[max(i-1, 0)].dir + 2) % 4 // back the way the path came in
+		switch step.underground {
+		case UndergroundInput:
+			step.underground = UndergroundOutput
+		case UndergroundOutput:
+			step.underground = UndergroundInput
+		}
+		reversed[len(steps)-1-i] = step
+	}
+	return reversed
+}
+
+// claim marks the tiles of a path as taken.
+func (r *beltRouter) claim(steps []routeStep) {
+	for _, step := range steps {
+		kind := tileBlocked
+		if step.underground != "" {
+			kind = undergroundAxis(step.dir)
+		}
+		r.tiles.set(step.pos, kind)
+	}
+}
+
+// sidePair is a free tile next to a building for an inserter, with the
+// free tile beyond it for the belt end.
+type sidePair struct {
+	inserter Position
+	belt     Position
+}
+
+// sidePairs returns the free inserter and belt tile pairs along the sides
+// of a building. With longReach, a belt one tile further out is used where
+// another belt already runs past the inserter, for a long-handed inserter
+// reaching over it into the building.
+func (r *beltRouter) sidePairs(building *Building, longReach bool) []sidePair {
+	bounds := building.Bounds()
+	var pairs []sidePair
+	add := func(inserter, outward Position) {
+		if !r.free(inserter) {
+			return
+		}
+		belt := inserter.Add(outward)
+		if r.free(belt) {
+			pairs = append(pairs, sidePair{inserter: inserter, belt: belt})
+			return
+		}
+		far := belt.Add(outward)
+		if longReach && r.area.Contains(belt) && r.free(far) && bounds.Contains(inserter.Add(outward.Scale(-2))) {
+			pairs = append(pairs, sidePair{inserter: inserter, belt: far})
+		}
+	}
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		add(Position{X: x, Y: bounds.Min.Y - 1}, Position{Y: -1})
+		add(Position{X: x, Y: bounds.Max.Y}, Position{Y: 1})
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		add(Position{X: bounds.Min.X - 1, Y: y}, Position{X: -1})
+		add(Position{X: bounds.Max.X, Y: y}, Position{X: 1})
+	}
+	return pairs
+}
+
+// directTile returns a free tile in the one-tile gap between a machine and
+// the machine placed right of it, where an inserter can move items across.
+func (r *beltRouter) directTile(from, to *Building) (Position, bool) {
+	source, destination := from.Bounds(), to.Bounds()
+	if destination.Min.X-source.Max.X != 1 {
+		return Position{}, false
+	}
+	for y := max(source.Min.Y, destination.Min.Y); y < min(source.Max.Y, destination.Max.Y); y++ {
+		if tile := (Position{X: source.Max.X, Y: y}); r.free(tile) {
+			return tile, true
+		}
+	}
+	return Position{}, false
+}
+
+// edgeTiles returns the free tiles of one column of the layout.
+func (r *beltRouter) edgeTiles(x int) []Position {
+	var tiles []Position
+	for y := r.area.Min.Y; y < r.area.Max.Y; y++ {
+		if p := (Position{X: x, Y: y}); r.free(p) {
+			tiles = append(tiles, p)
+		}
+	}
+	return tiles
+}
+
+// lineStop is a building loading or unloading a belt line still to be
+// routed.
+type lineStop struct {
+	building *Building
+	load     bool
+	rate     float64 // items per second
+}
+
+// beltLine is a belt line still to be routed.
+type beltLine struct {
+	item   string
+	stops  []lineStop // loading buildings first, then unloading ones, each in layout order
+	source bool       // the line starts at the left edge
+	sink   bool       // the line ends at the right edge
+	rate   float64    // items per second
+}
+
+// routeBelts runs a shared belt line per item past every machine making it
+// and then every machine using it. Items made elsewhere enter at the left
+// edge and the plan's target products leave at the right edge. Items that
+// need more than one belt of the fastest tier are split over several lines,
+// each with its share of the producers and consumers. A single machine
+// feeding a single other machine next to it hands its product over with an
+// inserter instead.
+func (lg *LayoutGenerator) routeBelts(plan *ProductionPlan, layout *FactoryLayout) {
+	if len(lg.Belts) == 0 {
+		return
+	}
+
+	router := newBeltRouter(layout, Rect{Max: Position{X: layout.Width, Y: layout.Height}})
+	router.markBeside(layout)
+	for _, port := range lg.fluidPorts(layout) {
+		router.tiles.set(port.tile, tileBlocked) // kept for pipes
+	}
+
+	loads := make(map[string][]lineStop)
+	unloads := make(map[string][]lineStop)
+	var items []string
+	machines := len(layout.Buildings)
+	for i := 0; i < machines; i++ {
+		building := &layout.Buildings[i]
+		for _, requirement := range plan.InserterRequirements[building.Recipe] {
+			if len(loads[requirement.Item]) == 0 && len(unloads[requirement.Item]) == 0 {
+				items = append(items, requirement.Item)
+			}
+			stop := lineStop{building: building, load: requirement.Output, rate: requirement.Rate}
+			if stop.load {
+				loads[requirement.Item] = append(loads[requirement.Item], stop)
+			} else {
+				unloads[requirement.Item] = append(unloads[requirement.Item], stop)
+			}
+		}
+	}
+	lg.routeDirectFeeds(plan, layout, router, items, loads, unloads)
+
+	targets := make(map[string]bool, len(plan.Targets))
+	for _, target := range plan.Targets {
+		targets[target.Item] = true
+	}
+	fastest := lg.beltFor(math.Inf(1))
+	var lines []beltLine
+	for _, item := range items {
+		lines = append(lines, splitLine(item, loads[item], unloads[item], targets[item], fastest.Throughput)...)
+	}
+	// Lines leaving at the edge go first, while the edge is still open.
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].sink && !lines[j].sink })
+
+	// Lines routed early can wall off the stops of later ones. While stops
+	// are missed, route everything again with the lines that missed the
+	// most stops so far first, and keep the order missing the fewest.
+	buildings, routes, unrouted := len(layout.Buildings), len(layout.Routes), len(layout.Unrouted)
+	tiles := router.tiles.clone()
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	misses := make([]int, len(lines))
+	best, fewest := order, -1
+	for round := 0; round < maxRoutingRounds; round++ {
+		missed := 0
+		for _, i := range order {
+			n := lg.routeLine(router, layout, lines[i])
+			misses[i] += n
+			missed += n
+		}
+		if missed == 0 {
+			return
+		}
+		if fewest < 0 || missed < fewest {
+			best, fewest = order, missed
+		}
+		order = append([]int(nil), order...)
+		sort.SliceStable(order, func(a, b int) bool { return misses[order[a]] > misses[order[b]] })
+
+		layout.Buildings = layout.Buildings[:buildings]
+		layout.Routes, layout.Unrouted = layout.Routes[:routes], layout.Unrouted[:unrouted]
+		router.tiles = tiles.clone()
+	}
+	for _, i := range best {
+		lg.routeLine(router, layout, lines[i])
+	}
+}
+
+// routeDirectFeeds connects the directly feeding machine pairs placed side
+// by side with an inserter between them, and takes them off the belt
+// lines.
+func (lg *LayoutGenerator) routeDirectFeeds(plan *ProductionPlan, layout *FactoryLayout, router *beltRouter, items []string, loads, unloads map[string][]lineStop) {
+	feeds := lg.directFeeds(plan)
+	for _, item := range items {
+		producers, consumers := loads[item], unloads[item]
+		if len(producers) != 1 || len(consumers) != 1 || feeds[producers[0].building.Recipe] != consumers[0].building.Recipe {
+			continue
+		}
+		from, to := producers[0].building, consumers[0].building
+		tile, ok := router.directTile(from, to)
+		if !ok {
+			continue
+		}
+		router.tiles.set(tile, tileBlocked)
+		layout.Routes = append(layout.Routes, BeltRoute{
+			Item:   item,
+			From:   from.ID,
+			To:     to.ID,
+			Rate:   consumers[0].rate,
+			Start:  tile,
+			End:    tile,
+			Stops:  []BeltStop{{Building: to.ID, Inserter: tile, Belt: tile, Rate: consumers[0].rate}},
+			Direct: true,
+		})
+		delete(loads, item)
+		delete(unloads, item)
+	}
+}
+
+// splitLine divides the flow of an item into as many lines as the fastest
+// belt needs to carry it, giving each line a run of consecutive producers
+// and consumers with about the same share of the rate. Items nobody makes
+// here come in from the edge, and products nobody uses here, or targets,
+// go out to it.
+func splitLine(item string, loads, unloads []lineStop, target bool, throughput float64) []beltLine {
+	source := len(loads) == 0
+	sink := target || len(unloads) == 0
+	if (source && sink) || (source && len(unloads) == 0) {
+		return nil
+	}
+	rate := max(stopRate(loads), stopRate(unloads))
+
+	limit := max(len(loads), len(unloads)) // every line needs a stop of each kind there is
+	if len(loads) > 0 && len(unloads) > 0 {
+		limit = min(len(loads), len(unloads))
+	}
+	count := 1
+	if throughput > 0 {
+		count = int(math.Ceil(rate/throughput - 1e-9))
+	}
+	count = max(min(count, limit), 1)
+
+	// Runs of whole stops rarely share the rate evenly; add lines while
+	// one of them is more than a belt carries.
+	loadGroups, unloadGroups := splitStops(loads, count), splitStops(unloads, count)
+	for throughput > 0 && count < limit && overfull(loadGroups, unloadGroups, throughput) {
+		count++
+		loadGroups, unloadGroups = splitStops(loads, count), splitStops(unloads, count)
+	}
+
+	lines := make([]beltLine, count)
+	for i := range lines {
+		lines[i] = beltLine{
+			item:   item,
+			stops:  append(serpentine(loadGroups[i]), serpentine(unloadGroups[i])...),
+			source: source,
+			sink:   sink,
+			rate:   max(stopRate(loadGroups[i]), stopRate(unloadGroups[i])),
+		}
+	}
+	return lines
+}
+
+// overfull reports whether a run of stops moves more than throughput.
+func overfull(loadGroups, unloadGroups [][]lineStop, throughput float64) bool {
+	for _, groups := range [][][]lineStop{loadGroups, unloadGroups} {
+		for _, group := range groups {
+			if stopRate(group) > throughput+1e-9 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitStops divides stops into n runs of consecutive stops with about the
+// same total rate.
+func splitStops(stops []lineStop, n int) [][]lineStop {
+	groups := make([][]lineStop, n)
+	total, done := stopRate(stops), 0.0
+	for _, stop := range stops {
+		group := 0
+		if total > 0 {
+			group = min(int((done+stop.rate/2)/total*float64(n)), n-1)
+		}
+		groups[group] = append(groups[group], stop)
+		done += stop.rate
+	}
+	return groups
+}
+
+// serpentine orders stops row by row, running along every other row
+// backwards so that the line does not cross back over each row.
+func serpentine(stops []lineStop) []lineStop {
+	rows := make(map[int]int)
+	for _, stop := range stops {
+		rows[stop.building.Position.Y] = 0
+	}
+	tops := make([]int, 0, len(rows))
+	for y := range rows {
+		tops = append(tops, y)
+	}
+	sort.Ints(tops)
+	for i, y := range tops {
+		rows[y] = i
+	}
+
+	ordered := append([]lineStop(nil), stops...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i].building.Position, ordered[j].building.Position
+		if rows[a.Y] != rows[b.Y] {
+			return rows[a.Y] < rows[b.Y]
+		}
+		if rows[a.Y]%2 == 1 {
+			return a.X > b.X
+		}
+		return a.X < b.X
+	})
+	return ordered
+}
+
+// stopRate returns the total rate of stops.
+func stopRate(stops []lineStop) float64 {
+	total := 0.0
+	for _, stop := range stops {
+		total += stop.rate
+	}
+	return total
+}
+
+// routeLine finds and places the belts of one line, piece by piece from
+// each stop to the next. Stops no piece reaches are recorded as unrouted
+// and skipped; it returns how many ends of the line were missed.
+func (lg *LayoutGenerator) routeLine(router *beltRouter, layout *FactoryLayout, line beltLine) int {
+	belt := lg.beltFor(line.rate)
+	maxUnderground := 0
+	if belt.Underground != "" {
+		maxUnderground = belt.UndergroundLength
+	}
+	route := BeltRoute{Item: line.item, Rate: line.rate, Belt: belt.Name}
+
+	var path []routeStep
+	var starts []Position
+	startPairs := make(map[Position]Position)
+	stops := line.stops
+	var first lineStop // building at the start of the line
+	if line.source {
+		starts = router.edgeTiles(router.area.Min.X)
+	} else {
+		first = stops[0]
+		route.From = first.building.ID
+		for _, pair := range router.sidePairs(first.building, lg.longReach()) {
+			starts = append(starts, pair.belt)
+			startPairs[pair.belt] = pair.inserter
+		}
+		stops = stops[1:]
+	}
+	last := route.From // building at the end of the path so far
+	missed := 0
+	var branches []lineStop
+
+	// extend continues the line to one of the goal tiles, keeping it off
+	// the inserter tiles of the start and goal buildings; the ones left
+	// unused are freed again afterwards.
+	extend := func(goals []Position, goalPairs map[Position]Position) bool {
+		var reserved []Position
+		for _, pairs := range []map[Position]Position{startPairs, goalPairs} {
+			for _, inserter := range pairs {
+				if router.tiles.at(inserter) == tileFree {
+					router.tiles.set(inserter, tileBlocked)
+					reserved = append(reserved, inserter)
+				}
+			}
+		}
+		var steps []routeStep
+		found := false
+		if len(path) == 0 {
+			steps, found = router.findPath(starts, goals, maxUnderground)
+		} else {
+			last := path[len(path)-1]
+			from := routeState{pos: last.pos, dir: last.dir, exit: last.underground == UndergroundOutput}
+			steps, found = router.extendPath(from, goals, maxUnderground)
+		}
+		for _, inserter := range reserved {
+			router.tiles.set(inserter, tileFree)
+		}
+		if !found {
+			return false
+		}
+
+		if len(path) == 0 {
+			if inserter, exists := startPairs[steps[0].pos]; exists {
+				route.Stops = append(route.Stops, BeltStop{
+					Building: route.From, Inserter: inserter, Belt: steps[0].pos, Load: first.load, Rate: first.rate,
+				})
+				router.tiles.set(inserter, tileBlocked)
+			}
+			startPairs = nil
+			path = steps
+		} else {
+			path = append(path[:len(path)-1], steps...)
+		}
+		router.claim(path[:len(path)-1]) // the end stays free for the next piece
+		return true
+	}
+
+	// miss records a stop the line does not reach; loading buildings may
+	// still side-load onto it.
+	miss := func(stop lineStop) {
+		if stop.load {
+			branches = append(branches, stop)
+			return
+		}
+		lg.unrouteStop(layout, line.item, stop)
+		missed++
+	}
+
+	// previous is how the last piece extended the line, for taking it
+	// back when the line end turns out to be boxed in.
+	type piece struct {
+		stop       lineStop
+		length     int       // path tiles before the piece
+		end        routeStep // last step before the piece
+		stops      int       // stops reached before the piece
+		last       string
+		startPairs map[Position]Position
+	}
+	var previous *piece
+	reach := func(stop lineStop) bool {
+		var goals []Position
+		goalPairs := make(map[Position]Position)
+		for _, pair := range router.sidePairs(stop.building, lg.longReach()) {
+			if _, shared := startPairs[pair.belt]; shared {
+				continue // the first piece needs two tiles to separate the inserters
+			}
+			if len(path) > 0 && pair.inserter == path[len(path)-1].pos {
+				continue // the end of the line so far is still unclaimed
+			}
+			goals = append(goals, pair.belt)
+			goalPairs[pair.belt] = pair.inserter
+		}
+		before := piece{stop: stop, length: len(path), stops: len(route.Stops), last: last, startPairs: startPairs}
+		if len(path) > 0 {
+			before.end = path[len(path)-1]
+		}
+		if !extend(goals, goalPairs) {
+			return false
+		}
+		end := path[len(path)-1].pos
+		route.Stops = append(route.Stops, BeltStop{
+			Building: stop.building.ID, Inserter: goalPairs[end], Belt: end, Load: stop.load, Rate: stop.rate,
+		})
+		router.tiles.set(goalPairs[end], tileBlocked)
+		last = stop.building.ID
+		previous = &before
+		return true
+	}
+	takeBack := func(p *piece) {
+		for _, step := range path[max(p.length-1, 0):] {
+			router.tiles.set(step.pos, tileFree)
+		}
+		for _, taken := range route.Stops[p.stops:] {
+			router.tiles.set(taken.Inserter, tileFree)
+		}
+		path = path[:max(p.length-1, 0)]
+		if p.length > 0 {
+			path = append(path, p.end)
+		}
+		route.Stops = route.Stops[:p.stops]
+		last, startPairs = p.last, p.startPairs
+	}
+
+	for _, stop := range stops {
+		if reach(stop) {
+			continue
+		}
+		if previous == nil {
+			miss(stop)
+			continue
+		}
+		// Try again from before the last stop, and put that stop back
+		// when this fails as well.
+		dropped := previous
+		takeBack(dropped)
+		if reach(stop) {
+			miss(dropped.stop)
+			continue
+		}
+		if dropped.length == 0 && !line.source {
+			// The first building may be boxed in: start the line here.
+			saved := struct {
+				starts []Position
+				pairs  map[Position]Position
+				from   string
+			}{starts, startPairs, route.From}
+			starts, startPairs = nil, make(map[Position]Position)
+			for _, pair := range router.sidePairs(stop.building, lg.longReach()) {
+				starts = append(starts, pair.belt)
+				startPairs[pair.belt] = pair.inserter
+			}
+			route.From, last = stop.building.ID, stop.building.ID
+			boxed := first
+			first = stop
+			if reach(dropped.stop) {
+				miss(boxed)
+				continue
+			}
+			starts, startPairs, route.From, last, first = saved.starts, saved.pairs, saved.from, saved.from, boxed
+		}
+		reach(dropped.stop)
+		miss(stop)
+	}
+	edge := router.edgeTiles(router.area.Max.X - 1)
+	reachedEdge := line.sink && extend(edge, nil)
+	if line.sink && !reachedEdge && previous != nil {
+		// The end of the line may be boxed in: leave the edge from before
+		// the last stop, and put that stop back when this fails as well.
+		dropped := previous
+		takeBack(dropped)
+		if reachedEdge = extend(edge, nil); reachedEdge {
+			miss(dropped.stop)
+		} else {
+			reach(dropped.stop)
+		}
+	}
+	if line.sink && !reachedEdge {
+		if len(path) > 0 {
+			layout.Unrouted = append(layout.Unrouted, BeltRoute{Item: line.item, From: last, Rate: line.rate})
+		}
+		line.sink = false
+		missed++
+	}
+	if len(path) == 0 {
+		if !line.source {
+			branches = append(branches, first)
+		}
+		for _, stop := range branches {
+			lg.unrouteStop(layout, line.item, stop)
+		}
+		return missed + len(branches)
+	}
+	if line.sink {
+		path[len(path)-1].dir = 1 // leave the layout heading east
+	} else {
+		route.To = last
+	}
+
+	router.claim(path)
+	route.Start, route.End = path[0].pos, path[len(path)-1].pos
+	route.Length = 1
+	for i := 1; i < len(path); i++ {
+		route.Length += tileDistance(path[i].pos, path[i-1].pos)
+	}
+	for _, step := range path {
+		lg.addBelt(layout, belt, step.pos, step.dir*90, step.underground)
+	}
+	for _, stop := range branches {
+		if !lg.sideLoad(router, layout, &route, path, stop, belt, maxUnderground) {
+			lg.unrouteStop(layout, line.item, stop)
+			missed++
+		}
+	}
+	layout.Routes = append(layout.Routes, route)
+	return missed
+}
+
+// sideLoad connects a loading building its line missed with a branch belt
+// ending head-on against the side of a straight line belt, before the
+// first building unloading the line. The branch's items join the line
+// there.
+func (lg *LayoutGenerator) sideLoad(router *beltRouter, layout *FactoryLayout, route *BeltRoute, path []routeStep, stop lineStop, belt *Belt, maxUnderground int) bool {
+	unloads := make(map[Position]bool)
+	for _, lineStop := range route.Stops {
+		if !lineStop.Load && !lineStop.Branch {
+			unloads[lineStop.Belt] = true
+		}
+	}
+
+	joins := make(map[Position]int) // branch end -> direction it points in
+	var goals []Position
+	for i := 1; i < len(path) && !unloads[path[i-1].pos]; i++ {
+		step, previous := path[i], path[i-1]
+		if step.underground != "" || previous.underground == UndergroundInput || previous.dir != step.dir ||
+			previous.pos.Add(DirectionOffset(step.dir*90)) != step.pos {
+			continue // only plain straight belts take items from the side
+		}
+		for _, side := range []int{(step.dir + 1) % 4, (step.dir + 3) % 4} {
+			end := step.pos.Add(DirectionOffset(side * 90))
+			if _, taken := joins[end]; !taken && router.free(end) {
+				joins[end] = (side + 2) % 4
+				goals = append(goals, end)
+			}
+		}
+	}
+
+	var starts []Position
+	pairs := make(map[Position]Position)
+	for _, pair := range router.sidePairs(stop.building, lg.longReach()) {
+		starts = append(starts, pair.belt)
+		pairs[pair.belt] = pair.inserter
+	}
+	var reserved []Position
+	for _, inserter := range pairs {
+		if router.tiles.at(inserter) == tileFree {
+			router.tiles.set(inserter, tileBlocked)
+			reserved = append(reserved, inserter)
+		}
+	}
+	steps, found := router.findPath(starts, goals, maxUnderground)
+	for _, inserter := range reserved {
+		router.tiles.set(inserter, tileFree)
+	}
+	if !found {
+		return false
+	}
+	end := &steps[len(steps)-1]
+	if end.underground == UndergroundOutput && end.dir != joins[end.pos] {
+		return false // an underground exit cannot turn towards the line
+	}
+	end.dir = joins[end.pos]
+
+	router.claim(steps)
+	inserter := pairs[steps[0].pos]
+	router.tiles.set(inserter, tileBlocked)
+	route.Stops = append(route.Stops, BeltStop{
+		Building: stop.building.ID, Inserter: inserter, Belt: steps[0].pos, Load: true, Branch: true, Rate: stop.rate,
+	})
+	route.Length += 1
+	for i := 1; i < len(steps); i++ {
+		route.Length += tileDistance(steps[i].pos, steps[i-1].pos)
+	}
+	for _, step := range steps {
+		lg.addBelt(layout, belt, step.pos, step.dir*90, step.underground)
+	}
+	return true
+}
+
+// unrouteStop records a building no belt line reached.
+func (lg *LayoutGenerator) unrouteStop(layout *FactoryLayout, item string, stop lineStop) {
+	route := BeltRoute{Item: item, Rate: stop.rate, Stops: []BeltStop{{Building: stop.building.ID, Load: stop.load, Rate: stop.rate}}}
+	if stop.load {
+		route.From = stop.building.ID
+	} else {
+		route.To = stop.building.ID
+	}
+	layout.Unrouted = append(layout.Unrouted, route)
+}
+
+// beltFor returns the slowest unlocked belt tier that carries the rate, or
+// the fastest one when none does.
+func (lg *LayoutGenerator) beltFor(rate float64) *Belt {
+	belts := append([]*Belt(nil), lg.Belts...)
+	sort.SliceStable(belts, func(i, j int) bool {
+		return belts[i].Throughput < belts[j].Throughput
+	})
+	for _, belt := range belts {
+		if belt.Throughput >= rate {
+			return belt
+		}
+	}
+	return belts[len(belts)-1]
+}
+
+// tileDistance returns the Manhattan distance between two tiles.
+func tileDistance(a, b Position) int {
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+// abs returns the absolute value of an integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
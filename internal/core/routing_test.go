@@ -0,0 +1,235 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// routerFromRows creates a router from rows of "." (free), "#" (blocked),
+// "-" (east-west underground end) and "|" (north-south underground end).
+func routerFromRows(rows ...string) *beltRouter {
+	router := newRouter(Rect{Max: Position{X: len(rows[0]), Y: len(rows)}})
+	kinds := map[rune]routeTile{'#': tileBlocked, '-': tileUndergroundX, '|': tileUndergroundY}
+	for y, row := range rows {
+		for x, tile := range row {
+			if kind, exists := kinds[tile]; exists {
+				router.tiles.set(Position{X: x, Y: y}, kind)
+			}
+		}
+	}
+	return router
+}
+
+// checkPath reports the first way a path breaks the routing rules, or an
+// empty string for a valid path.
+func checkPath(router *beltRouter, steps []routeStep, maxUnderground int) string {
+	seen := make(map[Position]bool)
+	for i, step := range steps {
+		if !router.free(step.pos) {
+			return "path runs over a taken tile"
+		}
+		if seen[step.pos] {
+			return "path visits a tile twice"
+		}
+		seen[step.pos] = true
+		if i+1 == len(steps) {
+			break
+		}
+
+		next := steps[i+1]
+		offset := DirectionOffset(step.dir * 90)
+		if step.underground == UndergroundInput {
+			if next.underground != UndergroundOutput || next.dir != step.dir {
+				return "underground entrance without a matching exit"
+			}
+			length := tileDistance(step.pos, next.pos)
+			if length > maxUnderground+1 || step.pos.Add(offset.Scale(length)) != next.pos {
+				return "underground belt longer than allowed"
+			}
+			continue
+		}
+		if step.pos.Add(offset) != next.pos {
+			return "belt does not point at the next tile"
+		}
+	}
+	return ""
+}
+
+func TestFindPath(t *testing.T) {
+	tests := []struct {
+		name           string
+		rows           []string
+		start, goal    Position
+		maxUnderground int
+		found          bool
+		length         int // tiles covered, when found
+		undergrounds   int // underground entrances, when found
+	}{
+		{
+			name:  "straight",
+			rows:  []string{"....."},
+			start: Position{X: 0}, goal: Position{X: 4},
+			found: true, length: 5,
+		},
+		{
+			name:  "around an obstacle",
+			rows:  []string{"..#..", "....."},
+			start: Position{X: 0}, goal: Position{X: 4},
+			found: true, length: 7,
+		},
+		{
+			name:  "wall without underground belts",
+			rows:  []string{"..#.."},
+			start: Position{X: 0}, goal: Position{X: 4},
+		},
+		{
+			name:  "under a wall",
+			rows:  []string{"..#.."},
+			start: Position{X: 0}, goal: Position{X: 4}, maxUnderground: 4,
+			found: true, length: 5, undergrounds: 1,
+		},
+		{
+			name:  "wall as wide as the underground limit",
+			rows:  []string{".####."},
+			start: Position{X: 0}, goal: Position{X: 5}, maxUnderground: 4,
+			found: true, length: 6, undergrounds: 1,
+		},
+		{
+			name:  "wall wider than the underground limit",
+			rows:  []string{".#####."},
+			start: Position{X: 0}, goal: Position{X: 6}, maxUnderground: 4,
+		},
+		{
+			name:  "same-axis underground end in the way",
+			rows:  []string{".#-#."},
+			start: Position{X: 0}, goal: Position{X: 4}, maxUnderground: 4,
+		},
+		{
+			name:  "crossing under another axis",
+			rows:  []string{".#|#."},
+			start: Position{X: 0}, goal: Position{X: 4}, maxUnderground: 4,
+			found: true, length: 5, undergrounds: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := routerFromRows(tt.rows...)
+			steps, found := router.findPath([]Position{tt.start}, []Position{tt.goal}, tt.maxUnderground)
+			if found != tt.found {
+				t.Fatalf("found = %v, want %v (path %v)", found, tt.found, steps)
+			}
+			if !found {
+				return
+			}
+			if problem := checkPath(router, steps, tt.maxUnderground); problem != "" {
+				t.Fatalf("%s: %v", problem, steps)
+			}
+			if steps[0].pos != tt.start || steps[len(steps)-1].pos != tt.goal {
+				t.Fatalf("path runs from %v to %v, want %v to %v", steps[0].pos, steps[len(steps)-1].pos, tt.start, tt.goal)
+			}
+
+			length, undergrounds := 1, 0
+			for i := 1; i < len(steps); i++ {
+				length += tileDistance(steps[i].pos, steps[i-1].pos)
+				if steps[i].underground == UndergroundOutput {
+					undergrounds++
+				}
+			}
+			if length != tt.length || undergrounds != tt.undergrounds {
+				t.Errorf("length %d with %d underground belts, want %d with %d", length, undergrounds, tt.length, tt.undergrounds)
+			}
+		})
+	}
+}
+
+// TestFindPathNeverCrossesItself routes between random tiles of random
+// obstacle grids, where the cheapest way often doubles back over tiles a
+// path already covers.
+func TestFindPathNeverCrossesItself(t *testing.T) {
+	const size, maxUnderground = 14, 4
+	random := rand.New(rand.NewSource(1))
+
+	for round := 0; round < 300; round++ {
+		router := newRouter(Rect{Max: Position{X: size, Y: size}})
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if random.Float64() < 0.35 {
+					router.tiles.set(Position{X: x, Y: y}, tileBlocked)
+				}
+			}
+		}
+		start := Position{X: random.Intn(size), Y: random.Intn(size)}
+		goal := Position{X: random.Intn(size), Y: random.Intn(size)}
+
+		steps, found := router.findPath([]Position{start}, []Position{goal}, maxUnderground)
+		if !found {
+			continue
+		}
+		if problem := checkPath(router, steps, maxUnderground); problem != "" {
+			t.Fatalf("round %d: %s: %v", round, problem, steps)
+		}
+	}
+}
+
+func TestReverseSteps(t *testing.T) {
+	router := routerFromRows("..#..", ".....")
+	steps, found := router.findPath([]Position{{X: 0}}, []Position{{X: 3, Y: 1}}, 4)
+	if !found {
+		t.Fatal("no path found")
+	}
+
+	reversed := reverseSteps(steps)
+	if problem := checkPath(router, reversed, 4); problem != "" {
+		t.Fatalf("%s: %v", problem, reversed)
+	}
+	if reversed[0].pos != steps[len(steps)-1].pos || reversed[len(reversed)-1].pos != steps[0].pos {
+		t.Errorf("reversed path runs from %v to %v, want %v to %v", reversed[0].pos, reversed[len(reversed)-1].pos, steps[len(steps)-1].pos, steps[0].pos)
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	stops := func(count int, rate float64, load bool) []lineStop {
+		var stops []lineStop
+		for i := 0; i < count; i++ {
+			building := &Building{ID: "building", Position: Position{X: i * 5}}
+			stops = append(stops, lineStop{building: building, load: load, rate: rate})
+		}
+		return stops
+	}
+	tests := []struct {
+		name           string
+		loads, unloads []lineStop
+		target         bool
+		lines          int
+		source, sink   bool
+	}{
+		{name: "one belt", loads: stops(4, 2, true), unloads: stops(2, 4, false), lines: 1},
+		{name: "two belts", loads: stops(10, 2, true), unloads: stops(5, 4, false), lines: 2},
+		{name: "uneven runs split further", loads: stops(15, 2, true), unloads: stops(10, 3, false), lines: 3},
+		{name: "no more lines than consumers", loads: stops(6, 2, true), unloads: stops(1, 12, false), lines: 1},
+		{name: "from the edge", unloads: stops(3, 10, false), lines: 3, source: true},
+		{name: "to the edge", loads: stops(3, 2, true), unloads: stops(1, 6, false), target: true, lines: 1, sink: true},
+		{name: "nothing to carry", loads: nil, unloads: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := splitLine("item", tt.loads, tt.unloads, tt.target, 15)
+			if len(lines) != tt.lines {
+				t.Fatalf("got %d lines, want %d", len(lines), tt.lines)
+			}
+			for _, line := range lines {
+				if line.source != tt.source || line.sink != tt.sink {
+					t.Errorf("source, sink = %v, %v, want %v, %v", line.source, line.sink, tt.source, tt.sink)
+				}
+				if line.rate > 15 {
+					t.Errorf("line rate %v is more than a belt carries", line.rate)
+				}
+				if len(line.stops) == 0 || (!line.source && !line.stops[0].load) {
+					t.Errorf("line does not start at a loading building: %+v", line.stops)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Package core contains the registry of layout strategies.
+package core
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// LayoutStrategy lays out the machines of a production plan in one style.
+// Strategies get the generator for its settings and helpers such as
+// MachineBuilding and Connect. They must give the same layout for the same
+// inputs: iterate maps in sorted order and draw random numbers from Rand.
+type LayoutStrategy interface {
+	Name() string        // style name selecting the strategy, e.g. "bus"
+	Description() string // one line for help texts
+	Generate(lg *LayoutGenerator, plan *ProductionPlan) (*FactoryLayout, error)
+}
+
+// layoutStrategyFunc is a LayoutStrategy made from a function.
+type layoutStrategyFunc struct {
+	name, description string
+	generate          func(lg *LayoutGenerator, plan *ProductionPlan) (*FactoryLayout, error)
+}
+
+func (s layoutStrategyFunc) Name() string        { return s.name }
+func (s layoutStrategyFunc) Description() string { return s.description }
+func (s layoutStrategyFunc) Generate(lg *LayoutGenerator, plan *ProductionPlan) (*FactoryLayout, error) {
+	return s.generate(lg, plan)
+}
+
+// NewLayoutStrategy returns a layout strategy generating layouts with a
+// function.
+func NewLayoutStrategy(name, description string, generate func(lg *LayoutGenerator, plan *ProductionPlan) (*FactoryLayout, error)) LayoutStrategy {
+	return layoutStrategyFunc{name: name, description: description, generate: generate}
+}
+
+// layoutStrategies holds the registered strategies by name, starting with
+// the built-in ones.
+var layoutStrategies = struct {
+	sync.RWMutex
+	byName map[string]LayoutStrategy
+}{byName: map[string]LayoutStrategy{
+	LayoutStyleCompact: NewLayoutStrategy(LayoutStyleCompact, "machines packed into rows with belts routed between them",
+		func(lg *LayoutGenerator, plan *ProductionPlan) (*FactoryLayout, error) {
+			return lg.generateCompactLayout(plan), nil
+		}),
+	LayoutStyleBus: NewLayoutStrategy(LayoutStyleBus, "production blocks beside a main bus",
+		(*LayoutGenerator).generateBusLayout),
+	LayoutStyleCity: NewLayoutStrategy(LayoutStyleCity, "one recipe per rail-bounded block with train stations",
+		(*LayoutGenerator).generateCityBlockLayout),
+}}
+
+// RegisterLayoutStrategy adds a layout strategy, selectable by its name
+// through LayoutGenerator.Style. Names must be unique.
+func RegisterLayoutStrategy(strategy LayoutStrategy) error {
+	if strategy == nil || strategy.Name() == "" {
+		return fmt.Errorf("layout strategy needs a name")
+	}
+	layoutStrategies.Lock()
+	defer layoutStrategies.Unlock()
+	if _, exists := layoutStrategies.byName[strategy.Name()]; exists {
+		return fmt.Errorf("layout strategy %q is already registered", strategy.Name())
+	}
+	layoutStrategies.byName[strategy.Name()] = strategy
+	return nil
+}
+
+// GetLayoutStrategy retrieves a registered layout strategy by name.
+func GetLayoutStrategy(name string) (LayoutStrategy, bool) {
+	layoutStrategies.RLock()
+	defer layoutStrategies.RUnlock()
+	strategy, exists := layoutStrategies.byName[name]
+	return strategy, exists
+}
+
+// LayoutStrategies returns the registered layout strategies, sorted by
+// name.
+func LayoutStrategies() []LayoutStrategy {
+	layoutStrategies.RLock()
+	defer layoutStrategies.RUnlock()
+	result := make([]LayoutStrategy, 0, len(layoutStrategies.byName))
+	for _, strategy := range layoutStrategies.byName {
+		result = append(result, strategy)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result
+}
+
+// layoutStrategyNames lists the registered strategy names for messages.
+func layoutStrategyNames() string {
+	var names []string
+	for _, strategy := range LayoutStrategies() {
+		names = append(names, strategy.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// Connect routes belts between the machines of a layout and places their
+// inserters, pipes and electric poles, as the compact style does. Custom
+// strategies call it after placing machines and setting the layout size.
+func (lg *LayoutGenerator) Connect(plan *ProductionPlan, layout *FactoryLayout) {
+	lg.routeBelts(plan, layout)
+	lg.placeInserters(layout)
+	lg.routePipes(plan, layout)
+	lg.placePoles(layout)
+}
+
+// Rand returns a random number source seeded with the generator's Seed,
+// for strategies searching randomly. The built-in strategies search
+// deterministically and need none.
+func (lg *LayoutGenerator) Rand() *rand.Rand {
+	return rand.New(rand.NewSource(lg.Seed))
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// singleRowStrategy places every machine of a plan in one row.
+var singleRowStrategy = NewLayoutStrategy("single-row", "all machines in one row",
+	func(lg *LayoutGenerator, plan *ProductionPlan) (*FactoryLayout, error) {
+		layout := &FactoryLayout{Title: "Single row", Height: 3 + 2*layoutMargin}
+		x := layoutMargin
+		for _, recipeName := range producerOrder(plan) {
+			for i := 0; i < plan.RequiredMachines[recipeName]; i++ {
+				machine := lg.MachineBuilding(len(layout.Buildings), plan, recipeName, Position{X: x, Y: layoutMargin})
+				layout.Buildings = append(layout.Buildings, machine)
+				x += machine.Footprint().Width + lg.MinSpacing
+			}
+		}
+		layout.Width = x + layoutMargin
+		lg.Connect(plan, layout)
+		return layout, nil
+	})
+
+// registerTestStrategy registers a strategy for the duration of a test.
+func registerTestStrategy(t *testing.T, strategy LayoutStrategy) {
+	t.Helper()
+	if err := RegisterLayoutStrategy(strategy); err != nil {
+		t.Fatalf("RegisterLayoutStrategy: %v", err)
+	}
+	t.Cleanup(func() {
+		layoutStrategies.Lock()
+		defer layoutStrategies.Unlock()
+		delete(layoutStrategies.byName, strategy.Name())
+	})
+}
+
+func TestRegisterLayoutStrategy(t *testing.T) {
+	registerTestStrategy(t, singleRowStrategy)
+
+	failing := func(lg *LayoutGenerator, plan *ProductionPlan) (*FactoryLayout, error) {
+		return nil, errors.New("no layout")
+	}
+	tests := []struct {
+		name     string
+		strategy LayoutStrategy
+	}{
+		{name: "nil strategy"},
+		{name: "no name", strategy: NewLayoutStrategy("", "nameless", failing)},
+		{name: "built-in name", strategy: NewLayoutStrategy(LayoutStyleBus, "another bus", failing)},
+		{name: "registered name", strategy: NewLayoutStrategy("single-row", "another row", failing)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RegisterLayoutStrategy(tt.strategy); err == nil {
+				t.Error("RegisterLayoutStrategy succeeded, want an error")
+			}
+		})
+	}
+
+	var names []string
+	for _, strategy := range LayoutStrategies() {
+		names = append(names, strategy.Name())
+	}
+	if want := []string{LayoutStyleBus, LayoutStyleCity, LayoutStyleCompact, "single-row"}; !slices.Equal(names, want) {
+		t.Errorf("strategies = %v, want %v", names, want)
+	}
+	if strategy, exists := GetLayoutStrategy("single-row"); !exists || strategy.Description() != "all machines in one row" {
+		t.Errorf("GetLayoutStrategy(single-row) = %v, %v, want the registered strategy", strategy, exists)
+	}
+}
+
+func TestGenerateLayoutStyle(t *testing.T) {
+	registerTestStrategy(t, singleRowStrategy)
+
+	tests := []struct {
+		style     string
+		wantTitle string
+		wantErr   bool
+	}{
+		{style: "", wantTitle: "inserter"},
+		{style: LayoutStyleCompact, wantTitle: "inserter"},
+		{style: "single-row", wantTitle: "Single row"},
+		{style: "spiral", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			lg, plan := testLayoutGenerator(), testInserterPlan()
+			lg.Style = tt.style
+
+			layout, err := lg.GenerateLayout(plan)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("GenerateLayout succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateLayout: %v", err)
+			}
+			if layout.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", layout.Title, tt.wantTitle)
+			}
+			for _, violation := range lg.ValidateLayout(layout) {
+				t.Errorf("violation %s", violation)
+			}
+		})
+	}
+}
+
+func TestGenerateLayoutDeterministic(t *testing.T) {
+	for _, strategy := range LayoutStrategies() {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			var layouts [2]*FactoryLayout
+			for i := range layouts {
+				lg := testLayoutGenerator()
+				lg.Style = strategy.Name()
+				layout, err := lg.GenerateLayout(testInserterPlan())
+				if err != nil {
+					t.Fatalf("GenerateLayout: %v", err)
+				}
+				layouts[i] = layout
+			}
+			if !reflect.DeepEqual(layouts[0].Buildings, layouts[1].Buildings) {
+				t.Error("generating the same plan twice gave different buildings")
+			}
+		})
+	}
+}
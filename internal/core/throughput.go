@@ -0,0 +1,187 @@
+// Package core contains belt and inserter throughput requirements.
+package core
+
+import (
+	"math"
+	"sort"
+)
+
+// FluidProvider reports which items are fluids.
+type FluidProvider interface {
+	IsFluid(itemName string) bool
+}
+
+// Belt represents a transport belt tier.
+type Belt struct {
+	Name              string
+	Throughput        float64 // items per second over both lanes
+	Underground       string  // underground belt of the same tier
+	UndergroundLength int     // most tiles an underground belt passes under
+	Splitter          string  // splitter of the same tier
+}
+
+// LaneThroughput returns the items per second carried by a single lane.
+func (b *Belt) LaneThroughput() float64 {
+	return b.Throughput / 2
+}
+
+// Inserter represents an inserter type.
+type Inserter struct {
+	Name            string
+	SwingsPerSecond float64 // chest-to-chest round trips per second
+	Stack           bool    // uses the stack inserter capacity bonus
+	EnergyUsage     float64 // in kW while swinging
+	EnergySource    string  // one of the EnergySource constants
+}
+
+// BeltRequirement is the belt capacity needed to carry an item flow on one
+// belt tier.
+type BeltRequirement struct {
+	Belt  string  // belt entity name
+	Belts float64 // exact number of full belts
+	Lanes int     // whole lanes needed
+}
+
+// InserterRequirement is the inserters each machine needs to move one item.
+type InserterRequirement struct {
+	Item     string  // item moved
+	Rate     float64 // items per second per machine
+	Inserter string  // inserter entity name
+	Count    int     // inserters per machine
+	Output   bool    // true when the inserter takes products out of the machine
+}
+
+// FluidRequirement is a fluid each machine takes in or puts out through
+// pipes.
+type FluidRequirement struct {
+	Fluid  string  // fluid moved
+	Rate   float64 // units per second per machine
+	Output bool    // true when the fluid leaves the machine
+}
+
+// calculateThroughput fills in belt requirements for every item flow and
+// inserter and fluid requirements for every recipe in the plan.
+func (opt *Optimizer) calculateThroughput(plan *ProductionPlan) {
+	belts := append([]*Belt(nil), opt.Belts...)
+	sort.SliceStable(belts, func(i, j int) bool {
+		return belts[i].Throughput < belts[j].Throughput
+	})
+
+	for item, rate := range plan.ResourceFlow {
+		if opt.isFluid(item) {
+			continue
+		}
+		itemsPerSecond := rate / 60.0
+		for _, belt := range belts {
+			if belt.Throughput <= 0 {
+				continue
+			}
+			plan.BeltRequirements[item] = append(plan.BeltRequirements[item], BeltRequirement{
+				Belt:  belt.Name,
+				Belts: itemsPerSecond / belt.Throughput,
+				Lanes: int(math.Ceil(itemsPerSecond/belt.LaneThroughput() - 1e-9)),
+			})
+		}
+	}
+
+	for recipeName := range plan.MachineCounts {
+		requirements, fluids := opt.recipeRequirements(plan, recipeName)
+		if len(requirements) > 0 {
+			plan.InserterRequirements[recipeName] = requirements
+		}
+		if len(fluids) > 0 {
+			plan.FluidRequirements[recipeName] = fluids
+		}
+	}
+}
+
+// recipeRequirements returns the inserters and fluid connections each
+// machine running a recipe of the plan needs.
+func (opt *Optimizer) recipeRequirements(plan *ProductionPlan, recipeName string) ([]InserterRequirement, []FluidRequirement) {
+	recipe := opt.RecipeGraph.Recipes[recipeName]
+	craftsPerSecond := opt.craftsPerSecond(plan, recipe)
+	effects := CombineModules(opt.modules(plan, recipeName))
+
+	var requirements []InserterRequirement
+	var fluids []FluidRequirement
+	for _, item := range sortedKeys(recipe.Inputs) {
+		if opt.isFluid(item) {
+			fluids = append(fluids, FluidRequirement{Fluid: item, Rate: recipe.Inputs[item] * craftsPerSecond})
+			continue
+		}
+		requirements = append(requirements,
+			opt.inserterFor(item, recipe.Inputs[item]*craftsPerSecond, false))
+	}
+	for _, item := range sortedKeys(recipe.Outputs) {
+		rate := recipe.Outputs[item] * (1 + effects.Productivity) * craftsPerSecond
+		if opt.isFluid(item) {
+			fluids = append(fluids, FluidRequirement{Fluid: item, Rate: rate, Output: true})
+			continue
+		}
+		requirements = append(requirements, opt.inserterFor(item, rate, true))
+	}
+	return requirements, fluids
+}
+
+// craftsPerSecond returns how many times one machine completes a recipe per
+// second at full speed.
+func (opt *Optimizer) craftsPerSecond(plan *ProductionPlan, recipe *Recipe) float64 {
+	speed := 1.0
+	if machine := opt.machineFor(recipe); machine != nil {
+		speed = machine.CraftingSpeed
+	}
+	speed *= 1 + CombineModules(opt.modules(plan, recipe.Name)).Speed
+	if recipe.CraftingTime <= 0 {
+		return 0
+	}
+	return speed / recipe.CraftingTime
+}
+
+// inserterFor picks the slowest available inserter that can move an item
+// at the given rate on its own, or as many of the fastest as needed.
+func (opt *Optimizer) inserterFor(item string, rate float64, output bool) InserterRequirement {
+	requirement := InserterRequirement{Item: item, Rate: rate, Output: output}
+
+	var fastest *Inserter
+	bestThroughput := 0.0
+	for _, inserter := range opt.Inserters {
+		throughput := opt.InserterThroughput(inserter)
+		if throughput >= rate && (requirement.Inserter == "" || throughput < bestThroughput) {
+			requirement.Inserter = inserter.Name
+			requirement.Count = 1
+			bestThroughput = throughput
+		}
+		if fastest == nil || throughput > opt.InserterThroughput(fastest) {
+			fastest = inserter
+		}
+	}
+
+	if requirement.Inserter == "" && fastest != nil {
+		requirement.Inserter = fastest.Name
+		requirement.Count = int(math.Ceil(rate / opt.InserterThroughput(fastest)))
+	}
+
+	return requirement
+}
+
+// InserterThroughput returns the items per second an inserter moves with
+// the researched stack size bonuses applied.
+func (opt *Optimizer) InserterThroughput(inserter *Inserter) float64 {
+	return inserter.SwingsPerSecond * opt.Bonuses.StackSize(inserter)
+}
+
+// isFluid reports whether an item is a fluid, which moves through pipes
+// rather than belts and inserters.
+func (opt *Optimizer) isFluid(item string) bool {
+	return opt.FluidProvider != nil && opt.FluidProvider.IsFluid(item)
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+// testFluids is a fluid provider listing the fluids.
+type testFluids map[string]bool
+
+func (f testFluids) IsFluid(item string) bool {
+	return f[item]
+}
+
+func TestCalculateThroughput(t *testing.T) {
+	tests := []struct {
+		name         string
+		craftingTime float64 // seconds per gear
+		water        float64 // water per gear, piped in
+		rate         float64 // gears per minute, 120 when zero
+		bonuses      ResearchBonuses
+		modules      []*Module
+		want         []InserterRequirement
+		wantFluids   []FluidRequirement
+		wantLanes    int // lanes of the slower belt carrying the iron plates
+	}{
+		{
+			name:         "slowest inserter keeping up",
+			craftingTime: 5,
+			want: []InserterRequirement{
+				{Item: "iron-plate", Rate: 0.4, Inserter: "inserter", Count: 1},
+				{Item: "iron-gear-wheel", Rate: 0.2, Inserter: "inserter", Count: 1, Output: true},
+			},
+			wantLanes: 1,
+		},
+		{
+			name:         "several of the fastest inserter",
+			craftingTime: 0.5,
+			want: []InserterRequirement{
+				{Item: "iron-plate", Rate: 4, Inserter: "fast-inserter", Count: 2},
+				{Item: "iron-gear-wheel", Rate: 2, Inserter: "fast-inserter", Count: 1, Output: true},
+			},
+			wantLanes: 1,
+		},
+		{
+			name:         "stack size bonus",
+			craftingTime: 0.5,
+			bonuses:      ResearchBonuses{InserterStackSize: 1},
+			want: []InserterRequirement{
+				{Item: "iron-plate", Rate: 4, Inserter: "fast-inserter", Count: 1},
+				{Item: "iron-gear-wheel", Rate: 2, Inserter: "fast-inserter", Count: 1, Output: true},
+			},
+			wantLanes: 1,
+		},
+		{
+			name:         "productivity module",
+			craftingTime: 5,
+			modules:      []*Module{{Name: "productivity", Productivity: 0.25}},
+			want: []InserterRequirement{
+				{Item: "iron-plate", Rate: 0.4, Inserter: "inserter", Count: 1},
+				{Item: "iron-gear-wheel", Rate: 0.25, Inserter: "inserter", Count: 1, Output: true},
+			},
+			wantLanes: 1,
+		},
+		{
+			name:         "piped fluid",
+			craftingTime: 5,
+			water:        10,
+			want: []InserterRequirement{
+				{Item: "iron-plate", Rate: 0.4, Inserter: "inserter", Count: 1},
+				{Item: "iron-gear-wheel", Rate: 0.2, Inserter: "inserter", Count: 1, Output: true},
+			},
+			wantFluids: []FluidRequirement{{Fluid: "water", Rate: 2}},
+			wantLanes:  1,
+		},
+		{
+			name:         "several belt lanes",
+			craftingTime: 5,
+			rate:         1200,
+			want: []InserterRequirement{
+				{Item: "iron-plate", Rate: 0.4, Inserter: "inserter", Count: 1},
+				{Item: "iron-gear-wheel", Rate: 0.2, Inserter: "inserter", Count: 1, Output: true},
+			},
+			wantLanes: 6, // 40 plates per second on lanes of 7.5
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs := map[string]float64{"iron-plate": 2}
+			if tt.water > 0 {
+				inputs["water"] = tt.water
+			}
+			graph := NewRecipeGraph()
+			graph.AddRecipe(&Recipe{
+				Name:         "iron-gear-wheel",
+				Inputs:       inputs,
+				Outputs:      map[string]float64{"iron-gear-wheel": 1},
+				CraftingTime: tt.craftingTime,
+				Category:     "crafting",
+			})
+
+			opt := NewOptimizer(graph, nil)
+			opt.Machines["crafting"] = &Entity{Name: "assembler", CraftingSpeed: 1}
+			opt.Modules["iron-gear-wheel"] = tt.modules
+			opt.Bonuses = tt.bonuses
+			opt.FluidProvider = testFluids{"water": true}
+			opt.Inserters = []*Inserter{{Name: "inserter", SwingsPerSecond: 0.83}, {Name: "fast-inserter", SwingsPerSecond: 2.31}}
+			opt.Belts = []*Belt{{Name: "fast-belt", Throughput: 30}, {Name: "belt", Throughput: 15}}
+
+			rate := tt.rate
+			if rate == 0 {
+				rate = 120
+			}
+			plan, err := opt.OptimizeProduction([]ProductionTarget{{Item: "iron-gear-wheel", Rate: rate}})
+			if err != nil {
+				t.Fatalf("OptimizeProduction: %v", err)
+			}
+
+			requirements := plan.InserterRequirements["iron-gear-wheel"]
+			if len(requirements) != len(tt.want) {
+				t.Fatalf("inserter requirements = %+v, want %+v", requirements, tt.want)
+			}
+			for i, want := range tt.want {
+				got := requirements[i]
+				if got.Item != want.Item || math.Abs(got.Rate-want.Rate) > 1e-9 || got.Inserter != want.Inserter || got.Count != want.Count || got.Output != want.Output {
+					t.Errorf("inserter requirement %d = %+v, want %+v", i, got, want)
+				}
+			}
+
+			fluids := plan.FluidRequirements["iron-gear-wheel"]
+			if len(fluids) != len(tt.wantFluids) {
+				t.Fatalf("fluid requirements = %+v, want %+v", fluids, tt.wantFluids)
+			}
+			for i, want := range tt.wantFluids {
+				if got := fluids[i]; got.Fluid != want.Fluid || math.Abs(got.Rate-want.Rate) > 1e-9 || got.Output != want.Output {
+					t.Errorf("fluid requirement %d = %+v, want %+v", i, got, want)
+				}
+			}
+			if _, belted := plan.BeltRequirements["water"]; belted {
+				t.Error("water has belt requirements")
+			}
+
+			// Belt tiers are listed slowest first.
+			belts := plan.BeltRequirements["iron-plate"]
+			if len(belts) != 2 || belts[0].Belt != "belt" || belts[1].Belt != "fast-belt" {
+				t.Fatalf("iron plate belts = %+v, want belt and fast-belt", belts)
+			}
+			ironPerSecond := plan.ResourceFlow["iron-plate"] / 60
+			if math.Abs(belts[0].Belts-ironPerSecond/15) > 1e-9 || belts[0].Lanes != tt.wantLanes {
+				t.Errorf("belt requirement = %+v, want %v belts on %d lanes", belts[0], ironPerSecond/15, tt.wantLanes)
+			}
+		})
+	}
+}
@@ -0,0 +1,440 @@
+// Package core contains layout validation rules.
+package core
+
+import "fmt"
+
+// Layout rules checked by ValidateLayout.
+const (
+	RuleEmptyLayout   = "empty-layout"
+	RuleOverlap       = "overlap"
+	RuleOutOfBounds   = "out-of-bounds"
+	RuleInserterReach = "inserter-reach"
+	RuleBeltDeadEnd   = "belt-dead-end"
+	RuleUnpowered     = "unpowered"
+	RuleFluidMix      = "fluid-mix"
+	RuleFluidBoxMix   = "fluid-box-mix"
+	RuleUnpipedInput  = "unpiped-fluid-input"
+	RuleUnpipedOutput = "unpiped-fluid-output"
+	RulePipeFlow      = "pipe-flow"
+	RuleUnroutedBelt  = "unrouted-belt"
+	RuleUnroutedPipe  = "unrouted-pipe"
+)
+
+// LayoutViolation is one broken layout rule.
+type LayoutViolation struct {
+	Rule     string   // one of the Rule constants
+	Building string   // ID of the offending building, empty for the whole layout
+	Other    string   // ID of the other building involved, e.g. in an overlap
+	Position Position // tile where the problem is
+	Message  string
+}
+
+// String formats the violation for display.
+func (v LayoutViolation) String() string {
+	return fmt.Sprintf("(%d,%d) %s: %s (%s)", v.Position.X, v.Position.Y, v.Building, v.Message, v.Rule)
+}
+
+// layoutGrid indexes the buildings of a layout by the tiles they cover.
+type layoutGrid struct {
+	layout *FactoryLayout
+	tiles  map[Position]int // tile -> index of the first building covering it
+}
+
+// newLayoutGrid indexes a layout's buildings by tile.
+func newLayoutGrid(layout *FactoryLayout) *layoutGrid {
+	grid := &layoutGrid{layout: layout, tiles: make(map[Position]int)}
+	for i := range layout.Buildings {
+		bounds := layout.Buildings[i].Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if _, taken := grid.tiles[Position{X: x, Y: y}]; !taken {
+					grid.tiles[Position{X: x, Y: y}] = i
+				}
+			}
+		}
+	}
+	return grid
+}
+
+// at returns the building covering a tile, or nil.
+func (g *layoutGrid) at(p Position) *Building {
+	if i, exists := g.tiles[p]; exists {
+		return &g.layout.Buildings[i]
+	}
+	return nil
+}
+
+// ValidateLayout checks a layout against the placement rules and returns
+// every violation found: overlapping footprints, buildings outside the
+// layout, inserters missing a pickup or drop target, belts leading nowhere,
+// electric buildings outside every pole's supply area, pipes joining two
+// fluids, pipes joining a machine fluid box of another fluid, machine fluid
+// inputs and outputs without a pipe, pipelines too long for their flow and
+// belt or pipe lines no path was found for. Checks that need entity data
+// are skipped for buildings the entity provider does not know.
+func (lg *LayoutGenerator) ValidateLayout(layout *FactoryLayout) []LayoutViolation {
+	if len(layout.Buildings) == 0 {
+		return []LayoutViolation{{Rule: RuleEmptyLayout, Message: "layout contains no buildings"}}
+	}
+
+	grid := newLayoutGrid(layout)
+	var violations []LayoutViolation
+	violations = append(violations, lg.checkOverlaps(layout, grid)...)
+	violations = append(violations, lg.checkBounds(layout)...)
+	violations = append(violations, lg.checkInserters(layout, grid)...)
+	violations = append(violations, lg.checkBelts(layout, grid)...)
+	violations = append(violations, lg.checkPower(layout)...)
+	violations = append(violations, lg.checkFluids(layout, grid)...)
+	violations = append(violations, lg.checkUnrouted(layout)...)
+	return violations
+}
+
+// checkOverlaps reports buildings sharing a tile with an earlier building.
+func (lg *LayoutGenerator) checkOverlaps(layout *FactoryLayout, grid *layoutGrid) []LayoutViolation {
+	var violations []LayoutViolation
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		bounds := building.Bounds()
+		reported := make(map[int]bool)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				tile := Position{X: x, Y: y}
+				first := grid.tiles[tile]
+				if first >= i || reported[first] {
+					continue
+				}
+				reported[first] = true
+				other := &layout.Buildings[first]
+				violations = append(violations, LayoutViolation{
+					Rule:     RuleOverlap,
+					Building: building.ID,
+					Other:    other.ID,
+					Position: tile,
+					Message:  fmt.Sprintf("overlaps %s", other.ID),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// checkBounds reports buildings reaching outside the layout area.
+func (lg *LayoutGenerator) checkBounds(layout *FactoryLayout) []LayoutViolation {
+	area := Rect{Max: Position{X: layout.Width, Y: layout.Height}}
+	var violations []LayoutViolation
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		bounds := building.Bounds()
+		if bounds.Min.X < 0 || bounds.Min.Y < 0 || bounds.Max.X > area.Max.X || bounds.Max.Y > area.Max.Y {
+			violations = append(violations, LayoutViolation{
+				Rule:     RuleOutOfBounds,
+				Building: building.ID,
+				Position: building.Position,
+				Message:  fmt.Sprintf("extends outside the %dx%d layout", layout.Width, layout.Height),
+			})
+		}
+	}
+	return violations
+}
+
+// checkInserters reports inserters whose pickup or drop position holds no
+// building. An inserter's rotation is the direction it moves items in.
+func (lg *LayoutGenerator) checkInserters(layout *FactoryLayout, grid *layoutGrid) []LayoutViolation {
+	var violations []LayoutViolation
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		if building.Type != EntityTypeInserter {
+			continue
+		}
+		entity := lg.entity(building)
+		if entity == nil {
+			continue
+		}
+
+		offset := DirectionOffset(building.Rotation).Scale(max(entity.Reach, 1))
+		targets := []struct {
+			name string
+			tile Position
+		}{
+			{"pickup", building.Position.Add(offset.Scale(-1))},
+			{"drop", building.Position.Add(offset)},
+		}
+		for _, target := range targets {
+			other := grid.at(target.tile)
+			if other != nil && other.Type != EntityTypeInserter && other.Type != EntityTypeElectricPole {
+				continue
+			}
+			violations = append(violations, LayoutViolation{
+				Rule:     RuleInserterReach,
+				Building: building.ID,
+				Position: target.tile,
+				Message:  fmt.Sprintf("%s position has nothing to %s", target.name, inserterAction(target.name)),
+			})
+		}
+	}
+	return violations
+}
+
+// inserterAction returns what an inserter does at one of its positions.
+func inserterAction(position string) string {
+	if position == "pickup" {
+		return "take from"
+	}
+	return "insert into"
+}
+
+// checkBelts reports belts that lead nowhere: the tile ahead holds no
+// belt to continue on, the belt does not leave the layout there, and no
+// inserter takes items off it.
+func (lg *LayoutGenerator) checkBelts(layout *FactoryLayout, grid *layoutGrid) []LayoutViolation {
+	area := Rect{Max: Position{X: layout.Width, Y: layout.Height}}
+
+	pickedUp := make(map[Position]bool)
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		if building.Type != EntityTypeInserter {
+			continue
+		}
+		reach := 1
+		if entity := lg.entity(building); entity != nil {
+			reach = max(entity.Reach, 1)
+		}
+		pickedUp[building.Position.Add(DirectionOffset(building.Rotation).Scale(-reach))] = true
+	}
+
+	var violations []LayoutViolation
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		if building.Type != EntityTypeBelt {
+			continue
+		}
+
+		ahead := building.Position.Add(DirectionOffset(building.Rotation))
+		if !area.Contains(ahead) || pickedUp[building.Position] {
+			continue
+		}
+		if next := grid.at(ahead); next != nil && isBeltLike(next.Type) && !facesBack(building, next) {
+			continue
+		}
+		violations = append(violations, LayoutViolation{
+			Rule:     RuleBeltDeadEnd,
+			Building: building.ID,
+			Position: ahead,
+			Message:  "belt leads nowhere",
+		})
+	}
+	return violations
+}
+
+// isBeltLike reports whether items on a belt can continue onto a building
+// of the given type.
+func isBeltLike(buildingType string) bool {
+	return buildingType == EntityTypeBelt || buildingType == EntityTypeUndergroundBelt || buildingType == EntityTypeSplitter
+}
+
+// facesBack reports whether next points straight back into belt, so the
+// two belts run head-on into each other.
+func facesBack(belt, next *Building) bool {
+	return next.Position.Add(DirectionOffset(next.Rotation)) == belt.Position
+}
+
+// checkPower reports electric buildings that no pole's supply area
+// touches.
+func (lg *LayoutGenerator) checkPower(layout *FactoryLayout) []LayoutViolation {
+	var supplies []Rect // supply areas in half tiles
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		entity := lg.entity(building)
+		if building.Type != EntityTypeElectricPole || entity == nil {
+			continue
+		}
+		supplies = append(supplies, poleSupply(building.Position, building.Footprint(), entity.SupplyArea))
+	}
+
+	var violations []LayoutViolation
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		entity := lg.entity(building)
+		if entity == nil || !entity.IsElectric() {
+			continue
+		}
+
+		bounds := building.Bounds()
+		powered := false
+		for _, supply := range supplies {
+			if suppliesBounds(supply, bounds) {
+				powered = true
+				break
+			}
+		}
+		if !powered {
+			violations = append(violations, LayoutViolation{
+				Rule:     RuleUnpowered,
+				Building: building.ID,
+				Position: building.Position,
+				Message:  "not covered by any electric pole",
+			})
+		}
+	}
+	return violations
+}
+
+// checkFluids reports connected pipes carrying different fluids, fluid
+// boxes in use by a machine's recipe that connect to a pipe of another
+// fluid or to no pipe, and pipelines whose busiest pipe carries more than
+// the pipeline's length allows. Pipelines branching off each other share
+// their trunk pipes, so a pipe carries the flow of every pipeline passing
+// through it.
+func (lg *LayoutGenerator) checkFluids(layout *FactoryLayout, grid *layoutGrid) []LayoutViolation {
+	var violations []LayoutViolation
+	visited := make(map[int]bool) // pipes whose joins are reported
+	for i := range layout.Buildings {
+		building := &layout.Buildings[i]
+		if !isPipe(building.Type) {
+			continue
+		}
+		visited[i] = true
+		for dir := 0; dir < 4; dir++ {
+			tile := building.Position.Add(DirectionOffset(dir * 90))
+			index, exists := grid.tiles[tile]
+			if !exists || visited[index] {
+				continue
+			}
+			other := &layout.Buildings[index]
+			if !isPipe(other.Type) || other.Fluid == building.Fluid {
+				continue
+			}
+			if pipeOpensTo(building, dir*90) && pipeOpensTo(other, dir*90+180) {
+				violations = append(violations, LayoutViolation{
+					Rule:     RuleFluidMix,
+					Building: building.ID,
+					Other:    other.ID,
+					Position: tile,
+					Message:  fmt.Sprintf("joins %s with %s", building.Fluid, other.Fluid),
+				})
+			}
+		}
+	}
+
+	for _, port := range lg.fluidPorts(layout) {
+		pipe := grid.at(port.tile)
+		connected := pipe != nil && isPipe(pipe.Type) && pipeOpensTo(pipe, port.side+180)
+		kind := "input"
+		if port.output {
+			kind = "output"
+		}
+		switch {
+		case connected && pipe.Fluid != port.fluid:
+			violations = append(violations, LayoutViolation{
+				Rule:     RuleFluidBoxMix,
+				Building: port.building.ID,
+				Other:    pipe.ID,
+				Position: port.tile,
+				Message:  fmt.Sprintf("joins its %s %s with %s", port.fluid, kind, pipe.Fluid),
+			})
+		case !connected && port.output:
+			violations = append(violations, LayoutViolation{
+				Rule:     RuleUnpipedOutput,
+				Building: port.building.ID,
+				Position: port.tile,
+				Message:  fmt.Sprintf("has no pipe carrying away its %s", port.fluid),
+			})
+		case !connected:
+			violations = append(violations, LayoutViolation{
+				Rule:     RuleUnpipedInput,
+				Building: port.building.ID,
+				Position: port.tile,
+				Message:  fmt.Sprintf("has no pipe bringing its %s", port.fluid),
+			})
+		}
+	}
+
+	flows := make(map[Position]float64) // units per second through each pipe
+	for _, route := range layout.Pipes {
+		for _, pipe := range route.Pipes {
+			flows[pipe] += route.Rate
+		}
+	}
+	for _, route := range layout.Pipes {
+		flow := route.Rate
+		for _, pipe := range route.Pipes {
+			flow = max(flow, flows[pipe])
+		}
+		if limit := MaxPipeFlow(route.Segments); flow > limit {
+			violations = append(violations, LayoutViolation{
+				Rule:     RulePipeFlow,
+				Building: route.To,
+				Position: route.End,
+				Message: fmt.Sprintf("needs %.0f %s/s through its busiest pipe, more than the %.0f/s of a %d-segment pipeline",
+					flow, route.Fluid, limit, route.Segments),
+			})
+		}
+	}
+	return violations
+}
+
+// checkUnrouted reports every belt and pipe line the router found no path
+// for, at the building left without its items or fluid.
+func (lg *LayoutGenerator) checkUnrouted(layout *FactoryLayout) []LayoutViolation {
+	positions := make(map[string]Position)
+	for _, building := range layout.Buildings {
+		positions[building.ID] = building.Position
+	}
+	violation := func(rule, kind, item, from, to string) LayoutViolation {
+		building := to
+		if building == "" {
+			building = from
+		}
+		return LayoutViolation{
+			Rule:     rule,
+			Building: building,
+			Position: positions[building],
+			Message:  fmt.Sprintf("no %s path for %s from %s to %s", kind, item, routeEndName(from, "input edge"), routeEndName(to, "output edge")),
+		}
+	}
+
+	var violations []LayoutViolation
+	for _, route := range layout.Unrouted {
+		violations = append(violations, violation(RuleUnroutedBelt, "belt", route.Item, route.From, route.To))
+	}
+	for _, route := range layout.UnroutedPipes {
+		violations = append(violations, violation(RuleUnroutedPipe, "pipe", route.Fluid, route.From, route.To))
+	}
+	return violations
+}
+
+// routeEndName names the end of a line, falling back to the layout edge
+// for lines entering or leaving the layout.
+func routeEndName(id, edge string) string {
+	if id == "" {
+		return edge
+	}
+	return id
+}
+
+// isPipe reports whether a building of the given type carries fluid.
+func isPipe(buildingType string) bool {
+	return buildingType == EntityTypePipe || buildingType == EntityTypePipeToGround
+}
+
+// pipeOpensTo reports whether a pipe connects on the side the rotation
+// points to. Pipes connect on every side, a pipe-to-ground only on its
+// surface side, which its rotation points to.
+func pipeOpensTo(pipe *Building, rotation int) bool {
+	if pipe.Type != EntityTypePipeToGround {
+		return true
+	}
+	return DirectionOffset(pipe.Rotation) == DirectionOffset(rotation)
+}
+
+// entity returns the entity data of a building, or nil when unknown.
+func (lg *LayoutGenerator) entity(building *Building) *Entity {
+	if lg.Entities == nil || building.Entity == "" {
+		return nil
+	}
+	entity, exists := lg.Entities.GetEntity(building.Entity)
+	if !exists {
+		return nil
+	}
+	return entity
+}
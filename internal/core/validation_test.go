@@ -0,0 +1,294 @@
+package core
+
+import "testing"
+
+// testEntities is an entity provider backed by a map.
+type testEntities map[string]*Entity
+
+func (e testEntities) GetEntity(name string) (*Entity, bool) {
+	entity, exists := e[name]
+	return entity, exists
+}
+
+// validationEntities has a 3x3 electric assembler, a 3x3 plant with a
+// fluid input at the middle of its top and an output at the middle of its
+// bottom, an inserter, a long inserter and a pole powering a 5x5 square.
+var validationEntities = testEntities{
+	"assembler": {Name: "assembler", Type: "assembler", EnergySource: EnergySourceElectric, Width: 3, Height: 3},
+	"plant": {Name: "plant", Type: "assembler", Width: 3, Height: 3, FluidBoxes: []FluidBox{
+		{Position: Position{X: 1, Y: 0}, Direction: 0},
+		{Position: Position{X: 1, Y: 2}, Direction: 180, Output: true},
+	}},
+	"inserter":      {Name: "inserter", Type: EntityTypeInserter, EnergySource: EnergySourceElectric, Reach: 1},
+	"long-inserter": {Name: "long-inserter", Type: EntityTypeInserter, EnergySource: EnergySourceElectric, Reach: 2},
+	"pole":          {Name: "pole", Type: EntityTypeElectricPole, SupplyArea: 5},
+}
+
+func assembler(id string, x, y int) Building {
+	return Building{ID: id, Type: "assembler", Entity: "assembler", Position: Position{X: x, Y: y}, Size: Size{Width: 3, Height: 3}}
+}
+
+// plant returns a plant at (0,1) piping in its first fluid and piping out
+// the others, whose pipes connect at (1,0) and (1,4).
+func plant(id string, fluids ...string) Building {
+	var requirements []FluidRequirement
+	for i, fluid := range fluids {
+		requirements = append(requirements, FluidRequirement{Fluid: fluid, Rate: 10, Output: i > 0})
+	}
+	return Building{ID: id, Type: "assembler", Entity: "plant", Position: Position{X: 0, Y: 1}, Size: Size{Width: 3, Height: 3}, Fluids: requirements}
+}
+
+func inserter(id string, x, y, rotation int) Building {
+	return Building{ID: id, Type: EntityTypeInserter, Entity: "inserter", Position: Position{X: x, Y: y}, Rotation: rotation}
+}
+
+func belt(id string, x, y, rotation int) Building {
+	return Building{ID: id, Type: EntityTypeBelt, Position: Position{X: x, Y: y}, Rotation: rotation}
+}
+
+func pole(id string, x, y int) Building {
+	return Building{ID: id, Type: EntityTypeElectricPole, Entity: "pole", Position: Position{X: x, Y: y}}
+}
+
+func pipe(id string, x, y int, fluid string) Building {
+	return Building{ID: id, Type: EntityTypePipe, Position: Position{X: x, Y: y}, Fluid: fluid}
+}
+
+// row returns the first tiles of a row.
+func row(y, tiles int) []Position {
+	positions := make([]Position, tiles)
+	for x := range positions {
+		positions[x] = Position{X: x, Y: y}
+	}
+	return positions
+}
+
+func TestValidateLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      string
+		buildings []Building
+		pipes     []PipeRoute
+		unrouted  []BeltRoute
+		dry       []PipeRoute // pipe lines without a path
+		want      []string    // IDs of the buildings violating the rule
+	}{
+		{
+			name:      "side by side",
+			rule:      RuleOverlap,
+			buildings: []Building{assembler("a", 0, 0), assembler("b", 3, 0)},
+		},
+		{
+			name:      "overlapping corners",
+			rule:      RuleOverlap,
+			buildings: []Building{assembler("a", 0, 0), assembler("b", 2, 2)},
+			want:      []string{"b"},
+		},
+		{
+			name:      "touching the edge",
+			rule:      RuleOutOfBounds,
+			buildings: []Building{assembler("a", 7, 7)},
+		},
+		{
+			name:      "past the edge",
+			rule:      RuleOutOfBounds,
+			buildings: []Building{assembler("a", 8, 7)},
+			want:      []string{"a"},
+		},
+		{
+			name:      "belt to machine",
+			rule:      RuleInserterReach,
+			buildings: []Building{assembler("a", 4, 0), inserter("i", 5, 3, 0), belt("b", 5, 4, 90)},
+		},
+		{
+			name: "long reach over a belt",
+			rule: RuleInserterReach,
+			buildings: []Building{
+				assembler("a", 4, 0), belt("b1", 5, 3, 90),
+				{ID: "i", Type: EntityTypeInserter, Entity: "long-inserter", Position: Position{X: 5, Y: 4}},
+				belt("b2", 5, 6, 90),
+			},
+		},
+		{
+			name:      "nothing to pick up",
+			rule:      RuleInserterReach,
+			buildings: []Building{assembler("a", 4, 0), inserter("i", 5, 3, 0)},
+			want:      []string{"i"},
+		},
+		{
+			name:      "belt leaves the layout",
+			rule:      RuleBeltDeadEnd,
+			buildings: []Building{belt("b1", 8, 2, 90), belt("b2", 9, 2, 90)},
+		},
+		{
+			name:      "belt emptied by an inserter",
+			rule:      RuleBeltDeadEnd,
+			buildings: []Building{belt("b1", 2, 2, 90), belt("b2", 3, 2, 90), inserter("i", 3, 1, 0)},
+		},
+		{
+			name:      "belt stops in the open",
+			rule:      RuleBeltDeadEnd,
+			buildings: []Building{belt("b1", 2, 2, 90), belt("b2", 3, 2, 90)},
+			want:      []string{"b2"},
+		},
+		{
+			name:      "belts head-on",
+			rule:      RuleBeltDeadEnd,
+			buildings: []Building{belt("b1", 2, 2, 90), belt("b2", 3, 2, 270)},
+			want:      []string{"b1", "b2"},
+		},
+		{
+			name:      "pole beside the machine",
+			rule:      RuleUnpowered,
+			buildings: []Building{assembler("a", 0, 0), pole("p", 3, 1)},
+		},
+		{
+			name:      "pole too far away",
+			rule:      RuleUnpowered,
+			buildings: []Building{assembler("a", 0, 0), pole("p", 6, 1)},
+			want:      []string{"a"},
+		},
+		{
+			name:      "one fluid",
+			rule:      RuleFluidMix,
+			buildings: []Building{pipe("p1", 0, 0, "water"), pipe("p2", 1, 0, "water")},
+		},
+		{
+			name: "pipe-to-ground facing away",
+			rule: RuleFluidMix,
+			buildings: []Building{
+				pipe("p1", 0, 0, "water"),
+				{ID: "p2", Type: EntityTypePipeToGround, Position: Position{X: 1, Y: 0}, Rotation: 90, Fluid: "crude-oil"},
+			},
+		},
+		{
+			name:      "two fluids",
+			rule:      RuleFluidMix,
+			buildings: []Building{pipe("p1", 0, 0, "water"), pipe("p2", 1, 0, "crude-oil")},
+			want:      []string{"p1"},
+		},
+		{
+			name:      "pipes of the box fluids",
+			rule:      RuleFluidBoxMix,
+			buildings: []Building{plant("m", "water", "steam"), pipe("p1", 1, 0, "water"), pipe("p2", 1, 4, "steam")},
+		},
+		{
+			name:      "pipe of another fluid",
+			rule:      RuleFluidBoxMix,
+			buildings: []Building{plant("m", "water", "steam"), pipe("p1", 1, 0, "crude-oil"), pipe("p2", 1, 4, "steam")},
+			want:      []string{"m"},
+		},
+		{
+			name:      "piped input",
+			rule:      RuleUnpipedInput,
+			buildings: []Building{plant("m", "water"), pipe("p1", 1, 0, "water")},
+		},
+		{
+			name:      "input without a pipe",
+			rule:      RuleUnpipedInput,
+			buildings: []Building{plant("m", "water", "steam"), pipe("p1", 2, 0, "water"), pipe("p2", 1, 4, "steam")},
+			want:      []string{"m"},
+		},
+		{
+			name: "pipe-to-ground facing away from the input",
+			rule: RuleUnpipedInput,
+			buildings: []Building{
+				plant("m", "water"),
+				{ID: "p1", Type: EntityTypePipeToGround, Position: Position{X: 1, Y: 0}, Rotation: 0, Fluid: "water"},
+			},
+			want: []string{"m"},
+		},
+		{
+			name:      "piped output",
+			rule:      RuleUnpipedOutput,
+			buildings: []Building{plant("m", "water", "steam"), pipe("p1", 1, 0, "water"), pipe("p2", 1, 4, "steam")},
+		},
+		{
+			name:      "output without a pipe",
+			rule:      RuleUnpipedOutput,
+			buildings: []Building{plant("m", "water", "steam"), pipe("p1", 1, 0, "water")},
+			want:      []string{"m"},
+		},
+		{
+			name:      "short pipeline",
+			rule:      RulePipeFlow,
+			buildings: []Building{pipe("p1", 0, 0, "water")},
+			pipes:     []PipeRoute{{Fluid: "water", To: "a", Rate: 1000, Segments: 10}},
+		},
+		{
+			name:      "long pipeline",
+			rule:      RulePipeFlow,
+			buildings: []Building{pipe("p1", 0, 0, "water")},
+			pipes:     []PipeRoute{{Fluid: "water", To: "a", Rate: 2500, Segments: 10}},
+			want:      []string{"a"},
+		},
+		{
+			name:      "separate pipelines",
+			rule:      RulePipeFlow,
+			buildings: []Building{pipe("p1", 0, 0, "water")},
+			pipes: []PipeRoute{
+				{Fluid: "water", To: "a", Rate: 800, Segments: 10, Pipes: row(0, 10)},
+				{Fluid: "water", To: "b", Rate: 800, Segments: 10, Pipes: row(1, 10)},
+			},
+		},
+		{
+			name:      "pipelines sharing a trunk",
+			rule:      RulePipeFlow,
+			buildings: []Building{pipe("p1", 0, 0, "water")},
+			pipes: []PipeRoute{
+				{Fluid: "water", To: "a", Rate: 800, Segments: 10, Pipes: row(0, 10)},
+				{Fluid: "water", To: "b", Rate: 800, Segments: 10, Pipes: append(row(0, 5), row(1, 5)...)},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name:      "every belt routed",
+			rule:      RuleUnroutedBelt,
+			buildings: []Building{assembler("a", 0, 0)},
+		},
+		{
+			name:      "belt line without a path",
+			rule:      RuleUnroutedBelt,
+			buildings: []Building{assembler("a", 0, 0)},
+			unrouted:  []BeltRoute{{Item: "iron-plate", To: "a", Rate: 1}, {Item: "iron-gear-wheel", From: "a", Rate: 1}},
+			want:      []string{"a", "a"},
+		},
+		{
+			name:      "every pipe routed",
+			rule:      RuleUnroutedPipe,
+			buildings: []Building{assembler("a", 0, 0)},
+			pipes:     []PipeRoute{{Fluid: "water", To: "a", Rate: 10, Segments: 3}},
+		},
+		{
+			name:      "pipe line without a path",
+			rule:      RuleUnroutedPipe,
+			buildings: []Building{assembler("a", 0, 0)},
+			dry:       []PipeRoute{{Fluid: "water", To: "a", Rate: 10}},
+			want:      []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule+"/"+tt.name, func(t *testing.T) {
+			lg := NewLayoutGenerator()
+			lg.Entities = validationEntities
+			layout := &FactoryLayout{Buildings: tt.buildings, Width: 10, Height: 10,
+				Pipes: tt.pipes, Unrouted: tt.unrouted, UnroutedPipes: tt.dry}
+
+			var got []string
+			for _, violation := range lg.ValidateLayout(layout) {
+				if violation.Rule == tt.rule {
+					got = append(got, violation.Building)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("%s violations by %v, want %v", tt.rule, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("%s violations by %v, want %v", tt.rule, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+// Package data contains entity and module definitions.
+package data
+
+import (
+	"sort"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+// EntityData holds machine and module statistics.
+type EntityData struct {
+	Version   string                    `json:"version"`
+	Entities  map[string]*core.Entity   `json:"entities"`
+	Modules   map[string]*core.Module   `json:"modules"`
+	Belts     map[string]*core.Belt     `json:"belts"`
+	Inserters map[string]*core.Inserter `json:"inserters"`
+	Pipes     map[string]*core.Pipe     `json:"pipes"`
+}
+
+// LoadEntities loads entity and module data.
+func LoadEntities() (*EntityData, error) {
+	// TODO: Implement entity data loading from Factorio data files
+
+	// Placeholder: Create base game entity definitions
+	entityData := &EntityData{
+		Version:   "1.1.0",
+		Entities:  make(map[string]*core.Entity),
+		Modules:   make(map[string]*core.Module),
+		Belts:     make(map[string]*core.Belt),
+		Inserters: make(map[string]*core.Inserter),
+		Pipes:     make(map[string]*core.Pipe),
+	}
+
+	entities := []*core.Entity{
+		// Crafting machines
+		{Name: "assembling-machine-1", Type: "assembler", CraftingSpeed: 0.5, EnergyUsage: 75, EnergySource: core.EnergySourceElectric, Emissions: 4, Categories: []string{"crafting"}, Width: 3, Height: 3},
+		{Name: "assembling-machine-2", Type: "assembler", CraftingSpeed: 0.75, EnergyUsage: 150, EnergySource: core.EnergySourceElectric, Emissions: 3, ModuleSlots: 2, Categories: []string{"crafting"}, Width: 3, Height: 3},
+		{Name: "assembling-machine-3", Type: "assembler", CraftingSpeed: 1.25, EnergyUsage: 375, EnergySource: core.EnergySourceElectric, Emissions: 2, ModuleSlots: 4, Categories: []string{"crafting"}, Width: 3, Height: 3},
+		{Name: "stone-furnace", Type: "furnace", CraftingSpeed: 1, EnergyUsage: 90, EnergySource: core.EnergySourceBurner, Emissions: 2, Categories: []string{"smelting"}, Width: 2, Height: 2},
+		{Name: "steel-furnace", Type: "furnace", CraftingSpeed: 2, EnergyUsage: 90, EnergySource: core.EnergySourceBurner, Emissions: 4, Categories: []string{"smelting"}, Width: 2, Height: 2},
+		{Name: "electric-furnace", Type: "furnace", CraftingSpeed: 2, EnergyUsage: 180, EnergySource: core.EnergySourceElectric, Emissions: 1, ModuleSlots: 2, Categories: []string{"smelting"}, Width: 3, Height: 3},
+		{Name: "chemical-plant", Type: "assembler", CraftingSpeed: 1, EnergyUsage: 210, EnergySource: core.EnergySourceElectric, Emissions: 4, ModuleSlots: 3, Categories: []string{"chemistry"}, Width: 3, Height: 3, FluidBoxes: []core.FluidBox{
+			{Position: core.Position{X: 0, Y: 2}, Direction: 180},
+			{Position: core.Position{X: 2, Y: 2}, Direction: 180},
+			{Position: core.Position{X: 0, Y: 0}, Direction: 0, Output: true},
+			{Position: core.Position{X: 2, Y: 0}, Direction: 0, Output: true},
+		}},
+		{Name: "oil-refinery", Type: "assembler", CraftingSpeed: 1, EnergyUsage: 420, EnergySource: core.EnergySourceElectric, Emissions: 6, ModuleSlots: 3, Categories: []string{"oil-processing"}, Width: 5, Height: 5, FluidBoxes: []core.FluidBox{
+			{Position: core.Position{X: 1, Y: 4}, Direction: 180},
+			{Position: core.Position{X: 3, Y: 4}, Direction: 180},
+			{Position: core.Position{X: 0, Y: 0}, Direction: 0, Output: true},
+			{Position: core.Position{X: 2, Y: 0}, Direction: 0, Output: true},
+			{Position: core.Position{X: 4, Y: 0}, Direction: 0, Output: true},
+		}},
+		{Name: "recycler", Type: "furnace", CraftingSpeed: 0.5, EnergyUsage: 180, EnergySource: core.EnergySourceElectric, Emissions: 2, ModuleSlots: 4, Categories: []string{core.RecipeCategoryRecycling}, Width: 2, Height: 4},
+
+		// Mining
+		{Name: "burner-mining-drill", Type: "mining-drill", CraftingSpeed: 0.25, EnergyUsage: 150, EnergySource: core.EnergySourceBurner, Emissions: 12, Width: 2, Height: 2},
+		{Name: "electric-mining-drill", Type: "mining-drill", CraftingSpeed: 0.5, EnergyUsage: 90, EnergySource: core.EnergySourceElectric, Emissions: 10, ModuleSlots: 3, Width: 3, Height: 3},
+		{Name: "big-mining-drill", Type: "mining-drill", CraftingSpeed: 2.5, EnergyUsage: 300, EnergySource: core.EnergySourceElectric, Emissions: 40, ModuleSlots: 4, Width: 5, Height: 5},
+		{Name: "pumpjack", Type: "pumpjack", CraftingSpeed: 1, EnergyUsage: 90, EnergySource: core.EnergySourceElectric, Emissions: 10, ModuleSlots: 2, Width: 3, Height: 3},
+		{Name: "offshore-pump", Type: "offshore-pump", PumpingSpeed: 1200, EnergySource: core.EnergySourceNone, Width: 1, Height: 2},
+
+		// Research
+		{Name: "lab", Type: "lab", CraftingSpeed: 1, EnergyUsage: 60, EnergySource: core.EnergySourceElectric, Width: 3, Height: 3},
+
+		// Logistics
+		{Name: "transport-belt", Type: core.EntityTypeBelt, EnergySource: core.EnergySourceNone, Width: 1, Height: 1},
+		{Name: "fast-transport-belt", Type: core.EntityTypeBelt, EnergySource: core.EnergySourceNone, Width: 1, Height: 1},
+		{Name: "express-transport-belt", Type: core.EntityTypeBelt, EnergySource: core.EnergySourceNone, Width: 1, Height: 1},
+		{Name: "underground-belt", Type: core.EntityTypeUndergroundBelt, EnergySource: core.EnergySourceNone, Width: 1, Height: 1},
+		{Name: "fast-underground-belt", Type: core.EntityTypeUndergroundBelt, EnergySource: core.EnergySourceNone, Width: 1, Height: 1},
+		{Name: "express-underground-belt", Type: core.EntityTypeUndergroundBelt, EnergySource: core.EnergySourceNone, Width: 1, Height: 1},
+		{Name: "splitter", Type: core.EntityTypeSplitter, EnergySource: core.EnergySourceNone, Width: 2, Height: 1},
+		{Name: "fast-splitter", Type: core.EntityTypeSplitter, EnergySource: core.EnergySourceNone, Width: 2, Height: 1},
+		{Name: "express-splitter", Type: core.EntityTypeSplitter, EnergySource: core.EnergySourceNone, Width: 2, Height: 1},
+		{Name: "burner-inserter", Type: core.EntityTypeInserter, EnergyUsage: 94.2, EnergySource: core.EnergySourceBurner, Width: 1, Height: 1, Reach: 1},
+		{Name: "inserter", Type: core.EntityTypeInserter, EnergyUsage: 13.2, EnergySource: core.EnergySourceElectric, Width: 1, Height: 1, Reach: 1},
+		{Name: "long-handed-inserter", Type: core.EntityTypeInserter, EnergyUsage: 18, EnergySource: core.EnergySourceElectric, Width: 1, Height: 1, Reach: 2},
+		{Name: "fast-inserter", Type: core.EntityTypeInserter, EnergyUsage: 46, EnergySource: core.EnergySourceElectric, Width: 1, Height: 1, Reach: 1},
+		{Name: "stack-inserter", Type: core.EntityTypeInserter, EnergyUsage: 132, EnergySource: core.EnergySourceElectric, Width: 1, Height: 1, Reach: 1},
+
+		{Name: "pipe", Type: core.EntityTypePipe, EnergySource: core.EnergySourceNone, Width: 1, Height: 1},
+		{Name: "pipe-to-ground", Type: core.EntityTypePipeToGround, EnergySource: core.EnergySourceNone, Width: 1, Height: 1},
+		{Name: "straight-rail", Type: core.EntityTypeRail, EnergySource: core.EnergySourceNone, Width: 2, Height: 2},
+		{Name: "train-stop", Type: core.EntityTypeTrainStop, EnergySource: core.EnergySourceNone, Width: 2, Height: 2},
+
+		// Power
+		{Name: "small-electric-pole", Type: core.EntityTypeElectricPole, EnergySource: core.EnergySourceNone, Width: 1, Height: 1, SupplyArea: 5, WireReach: 7.5},
+		{Name: "medium-electric-pole", Type: core.EntityTypeElectricPole, EnergySource: core.EnergySourceNone, Width: 1, Height: 1, SupplyArea: 7, WireReach: 9},
+		{Name: "big-electric-pole", Type: core.EntityTypeElectricPole, EnergySource: core.EnergySourceNone, Width: 2, Height: 2, SupplyArea: 4, WireReach: 30},
+		{Name: "substation", Type: core.EntityTypeElectricPole, EnergySource: core.EnergySourceNone, Width: 2, Height: 2, SupplyArea: 18, WireReach: 18},
+		{Name: "boiler", Type: "boiler", EnergyUsage: 1800, EnergySource: core.EnergySourceBurner, Emissions: 30, Width: 3, Height: 2},
+	}
+
+	for _, entity := range entities {
+		entityData.Entities[entity.Name] = entity
+	}
+
+	modules := []*core.Module{
+		{Name: "speed-module", Speed: 0.2, Consumption: 0.5},
+		{Name: "speed-module-2", Speed: 0.3, Consumption: 0.6},
+		{Name: "speed-module-3", Speed: 0.5, Consumption: 0.7},
+		{Name: "effectivity-module", Consumption: -0.3},
+		{Name: "effectivity-module-2", Consumption: -0.4},
+		{Name: "effectivity-module-3", Consumption: -0.5},
+		{Name: "productivity-module", Speed: -0.05, Productivity: 0.04, Consumption: 0.4, Pollution: 0.05},
+		{Name: "productivity-module-2", Speed: -0.1, Productivity: 0.06, Consumption: 0.6, Pollution: 0.07},
+		{Name: "productivity-module-3", Speed: -0.15, Productivity: 0.1, Consumption: 0.8, Pollution: 0.1},
+		{Name: "quality-module", Speed: -0.05, Quality: 0.01},
+		{Name: "quality-module-2", Speed: -0.05, Quality: 0.02},
+		{Name: "quality-module-3", Speed: -0.05, Quality: 0.025},
+	}
+
+	for _, module := range modules {
+		entityData.Modules[module.Name] = module
+	}
+
+	belts := []*core.Belt{
+		{Name: "transport-belt", Throughput: 15, Underground: "underground-belt", UndergroundLength: 4, Splitter: "splitter"},
+		{Name: "fast-transport-belt", Throughput: 30, Underground: "fast-underground-belt", UndergroundLength: 6, Splitter: "fast-splitter"},
+		{Name: "express-transport-belt", Throughput: 45, Underground: "express-underground-belt", UndergroundLength: 8, Splitter: "express-splitter"},
+	}
+
+	for _, belt := range belts {
+		entityData.Belts[belt.Name] = belt
+	}
+
+	inserters := []*core.Inserter{
+		{Name: "burner-inserter", SwingsPerSecond: 0.6, EnergyUsage: 94.2, EnergySource: core.EnergySourceBurner},
+		{Name: "inserter", SwingsPerSecond: 0.83, EnergyUsage: 13.2, EnergySource: core.EnergySourceElectric},
+		{Name: "long-handed-inserter", SwingsPerSecond: 1.15, EnergyUsage: 18, EnergySource: core.EnergySourceElectric},
+		{Name: "fast-inserter", SwingsPerSecond: 2.31, EnergyUsage: 46, EnergySource: core.EnergySourceElectric},
+		{Name: "stack-inserter", SwingsPerSecond: 2.31, Stack: true, EnergyUsage: 132, EnergySource: core.EnergySourceElectric},
+	}
+
+	for _, inserter := range inserters {
+		entityData.Inserters[inserter.Name] = inserter
+	}
+
+	entityData.Pipes["pipe"] = &core.Pipe{Name: "pipe", Underground: "pipe-to-ground", UndergroundLength: 9}
+
+	return entityData, nil
+}
+
+// GetEntity retrieves an entity by name.
+func (ed *EntityData) GetEntity(name string) (*core.Entity, bool) {
+	entity, exists := ed.Entities[name]
+	return entity, exists
+}
+
+// GetModule retrieves a module by name.
+func (ed *EntityData) GetModule(name string) (*core.Module, bool) {
+	module, exists := ed.Modules[name]
+	return module, exists
+}
+
+// GetPipe retrieves a pipe by name.
+func (ed *EntityData) GetPipe(name string) (*core.Pipe, bool) {
+	pipe, exists := ed.Pipes[name]
+	return pipe, exists
+}
+
+// GetEntitiesByType returns all entities of a specific type, sorted by name.
+func (ed *EntityData) GetEntitiesByType(entityType string) []*core.Entity {
+	var result []*core.Entity
+	for _, entity := range ed.Entities {
+		if entity.Type == entityType {
+			result = append(result, entity)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// UnlockedBelts returns the belt tiers whose recipes are unlocked, sorted
+// by name.
+func (ed *EntityData) UnlockedBelts(unlocked map[string]bool) []*core.Belt {
+	var result []*core.Belt
+	for name, belt := range ed.Belts {
+		if unlocked[name] {
+			result = append(result, belt)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// UnlockedInserters returns the inserter types whose recipes are unlocked,
+// sorted by name.
+func (ed *EntityData) UnlockedInserters(unlocked map[string]bool) []*core.Inserter {
+	var result []*core.Inserter
+	for name, inserter := range ed.Inserters {
+		if unlocked[name] {
+			result = append(result, inserter)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// UnlockedPoles returns the electric poles whose recipes are unlocked,
+// sorted by name.
+func (ed *EntityData) UnlockedPoles(unlocked map[string]bool) []*core.Entity {
+	var result []*core.Entity
+	for _, pole := range ed.GetEntitiesByType(core.EntityTypeElectricPole) {
+		if unlocked[pole.Name] {
+			result = append(result, pole)
+		}
+	}
+	return result
+}
+
+// UnlockedDrills returns the mining drills whose recipes are unlocked,
+// sorted by name.
+func (ed *EntityData) UnlockedDrills(unlocked map[string]bool) []*core.Entity {
+	var result []*core.Entity
+	for _, drill := range ed.GetEntitiesByType("mining-drill") {
+		if unlocked[drill.Name] {
+			result = append(result, drill)
+		}
+	}
+	return result
+}
+
+// DefaultMachines returns the machine used for each crafting category
+// in an early-game factory.
+func (ed *EntityData) DefaultMachines() map[string]*core.Entity {
+	machines := make(map[string]*core.Entity)
+	for category, name := range map[string]string{
+		"crafting": "assembling-machine-1",
+		"smelting": "stone-furnace",
+
+		"chemistry":      "chemical-plant",
+		"oil-processing": "oil-refinery",
+
+		core.RecipeCategoryRecycling: "recycler",
+	} {
+		if entity, exists := ed.GetEntity(name); exists {
+			machines[category] = entity
+		}
+	}
+	return machines
+}
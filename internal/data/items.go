@@ -1,7 +1,13 @@
 // Package data contains item definitions and properties.
 package data
 
-import "image/color"
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"reflect"
+	"strings"
+)
 
 // ItemType represents the category of an item.
 type ItemType string
@@ -16,11 +22,69 @@ const (
 
 // Item represents a Factorio item with its properties.
 type Item struct {
-	Name       string                 `json:"name"`
-	Type       ItemType               `json:"type"`
-	StackSize  int                    `json:"stack_size"`
-	FuelValue  float64                `json:"fuel_value,omitempty"` // in MJ
-	Properties map[string]interface{} `json:"properties,omitempty"`
+	Name                 string             `json:"name"`
+	Type                 ItemType           `json:"type"`
+	StackSize            int                `json:"stack_size"`
+	FuelValue            float64            `json:"fuel_value,omitempty"` // in MJ
+	FuelCategory         string             `json:"fuel_category,omitempty"`
+	MiningTime           float64            `json:"mining_time,omitempty"` // seconds per item at mining speed 1, 0 when drills cannot mine it
+	Fluid                bool               `json:"fluid,omitempty"`
+	Color                *Color             `json:"color,omitempty"` // color used when rendering
+	IconPath             string             `json:"icon,omitempty"`
+	Subgroup             string             `json:"subgroup,omitempty"`
+	Order                string             `json:"order,omitempty"`
+	PlaceResult          string             `json:"place_result,omitempty"`           // entity placed by the item
+	RocketLaunchProducts map[string]float64 `json:"rocket_launch_products,omitempty"` // item name -> amount
+}
+
+// Color is an item color that encodes to JSON as a "#rrggbb" or
+// "#rrggbbaa" hex string.
+type Color color.RGBA
+
+// rgb creates an opaque color.
+func rgb(r, g, b uint8) *Color {
+	return &Color{R: r, G: g, B: b, A: 255}
+}
+
+// MarshalJSON encodes the color as a hex string, leaving out the alpha
+// channel when the color is opaque.
+func (c Color) MarshalJSON() ([]byte, error) {
+	hex := fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	if c.A != 255 {
+		hex += fmt.Sprintf("%02x", c.A)
+	}
+	return json.Marshal(hex)
+}
+
+// UnmarshalJSON decodes a "#rrggbb" or "#rrggbbaa" hex string. Invalid
+// colors are reported as type errors; the decoder adds no field path to
+// errors of custom decoders, so the loader reports them at the enclosing
+// entry.
+func (c *Color) UnmarshalJSON(data []byte) error {
+	invalid := &json.UnmarshalTypeError{
+		Value: fmt.Sprintf("%s (expected \"#rrggbb\" or \"#rrggbbaa\")", data),
+		Type:  reflect.TypeOf(*c),
+	}
+
+	var hex string
+	if err := json.Unmarshal(data, &hex); err != nil {
+		return invalid
+	}
+
+	digits := strings.TrimPrefix(hex, "#")
+	if len(digits) != 6 && len(digits) != 8 {
+		return invalid
+	}
+	if len(digits) == 6 {
+		digits += "ff"
+	}
+
+	var r, g, b, a uint8
+	if _, err := fmt.Sscanf(digits, "%02x%02x%02x%02x", &r, &g, &b, &a); err != nil {
+		return invalid
+	}
+	*c = Color{R: r, G: g, B: b, A: a}
+	return nil
 }
 
 // ItemDatabase holds all item definitions.
@@ -41,19 +105,41 @@ func LoadItems() (*ItemDatabase, error) {
 
 	// Raw materials
 	items := []*Item{
-		{Name: "iron-ore", Type: ItemTypeRaw, StackSize: 50},
-		{Name: "copper-ore", Type: ItemTypeRaw, StackSize: 50},
-		{Name: "coal", Type: ItemTypeRaw, StackSize: 50, FuelValue: 4.0},
-		{Name: "stone", Type: ItemTypeRaw, StackSize: 50},
-		{Name: "wood", Type: ItemTypeRaw, StackSize: 50, FuelValue: 2.0},
+		{Name: "iron-ore", Type: ItemTypeRaw, StackSize: 50, MiningTime: 1},
+		{Name: "copper-ore", Type: ItemTypeRaw, StackSize: 50, MiningTime: 1},
+		{Name: "coal", Type: ItemTypeRaw, StackSize: 50, FuelValue: 4.0, FuelCategory: "chemical", MiningTime: 1},
+		{Name: "stone", Type: ItemTypeRaw, StackSize: 50, MiningTime: 1},
+		{Name: "wood", Type: ItemTypeRaw, StackSize: 50, FuelValue: 2.0, FuelCategory: "chemical"},
+		{Name: "crude-oil", Type: ItemTypeRaw, Fluid: true},
+		{Name: "water", Type: ItemTypeRaw, Fluid: true},
+		{Name: "petroleum-gas", Type: ItemTypeIntermediate, Fluid: true},
+		{Name: "sulfuric-acid", Type: ItemTypeIntermediate, Fluid: true},
 
 		// Intermediate products
 		{Name: "iron-plate", Type: ItemTypeIntermediate, StackSize: 100},
 		{Name: "copper-plate", Type: ItemTypeIntermediate, StackSize: 100},
 		{Name: "steel-plate", Type: ItemTypeIntermediate, StackSize: 100},
+		{Name: "stone-brick", Type: ItemTypeIntermediate, StackSize: 100},
+		{Name: "iron-stick", Type: ItemTypeIntermediate, StackSize: 100},
 		{Name: "iron-gear-wheel", Type: ItemTypeIntermediate, StackSize: 100},
 		{Name: "copper-cable", Type: ItemTypeIntermediate, StackSize: 200},
 		{Name: "electronic-circuit", Type: ItemTypeIntermediate, StackSize: 200},
+		{Name: "plastic-bar", Type: ItemTypeIntermediate, StackSize: 100},
+		{Name: "advanced-circuit", Type: ItemTypeIntermediate, StackSize: 200},
+		{Name: "engine-unit", Type: ItemTypeIntermediate, StackSize: 50},
+		{Name: "sulfur", Type: ItemTypeIntermediate, StackSize: 50},
+		{Name: "processing-unit", Type: ItemTypeIntermediate, StackSize: 100},
+
+		// Modules
+		{Name: "speed-module", Type: ItemTypeIntermediate, StackSize: 50},
+		{Name: "productivity-module", Type: ItemTypeIntermediate, StackSize: 50},
+		{Name: "quality-module", Type: ItemTypeIntermediate, StackSize: 50},
+		{Name: "quality-module-2", Type: ItemTypeIntermediate, StackSize: 50},
+		{Name: "quality-module-3", Type: ItemTypeIntermediate, StackSize: 50},
+
+		// Fuels
+		{Name: "solid-fuel", Type: ItemTypeConsumable, StackSize: 50, FuelValue: 12.0, FuelCategory: "chemical"},
+		{Name: "rocket-fuel", Type: ItemTypeConsumable, StackSize: 10, FuelValue: 100.0, FuelCategory: "chemical"},
 
 		// Science packs
 		{Name: "automation-science-pack", Type: ItemTypeConsumable, StackSize: 200},
@@ -63,24 +149,62 @@ func LoadItems() (*ItemDatabase, error) {
 		{Name: "utility-science-pack", Type: ItemTypeConsumable, StackSize: 200},
 
 		// Buildings
-		{Name: "assembling-machine-1", Type: ItemTypeBuilding, StackSize: 50, Properties: map[string]interface{}{"color": color.RGBA{100, 150, 255, 255}}},
-		{Name: "assembling-machine-2", Type: ItemTypeBuilding, StackSize: 50, Properties: map[string]interface{}{"color": color.RGBA{100, 150, 255, 255}}},
-		{Name: "assembling-machine-3", Type: ItemTypeBuilding, StackSize: 50, Properties: map[string]interface{}{"color": color.RGBA{100, 150, 255, 255}}},
-		{Name: "stone-furnace", Type: ItemTypeBuilding, StackSize: 50, Properties: map[string]interface{}{"color": color.RGBA{255, 100, 100, 255}}},
-		{Name: "steel-furnace", Type: ItemTypeBuilding, StackSize: 50, Properties: map[string]interface{}{"color": color.RGBA{255, 100, 100, 255}}},
-		{Name: "electric-furnace", Type: ItemTypeBuilding, StackSize: 50, Properties: map[string]interface{}{"color": color.RGBA{255, 100, 100, 255}}},
-		{Name: "transport-belt", Type: ItemTypeBuilding, StackSize: 100, Properties: map[string]interface{}{"color": color.RGBA{255, 255, 100, 255}}},
-		{Name: "inserter", Type: ItemTypeBuilding, StackSize: 50, Properties: map[string]interface{}{"color": color.RGBA{100, 255, 100, 255}}},
-		{Name: "small-electric-pole", Type: ItemTypeBuilding, StackSize: 50, Properties: map[string]interface{}{"color": color.RGBA{255, 150, 100, 255}}},
+		{Name: "assembling-machine-1", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "assembling-machine-1", Color: rgb(100, 150, 255)},
+		{Name: "assembling-machine-2", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "assembling-machine-2", Color: rgb(100, 150, 255)},
+		{Name: "assembling-machine-3", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "assembling-machine-3", Color: rgb(100, 150, 255)},
+		{Name: "stone-furnace", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "stone-furnace", Color: rgb(255, 100, 100)},
+		{Name: "steel-furnace", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "steel-furnace", Color: rgb(255, 100, 100)},
+		{Name: "electric-furnace", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "electric-furnace", Color: rgb(255, 100, 100)},
+		{Name: "transport-belt", Type: ItemTypeBuilding, StackSize: 100, PlaceResult: "transport-belt", Color: rgb(255, 255, 100)},
+		{Name: "fast-transport-belt", Type: ItemTypeBuilding, StackSize: 100, PlaceResult: "fast-transport-belt", Color: rgb(220, 40, 40)},
+		{Name: "express-transport-belt", Type: ItemTypeBuilding, StackSize: 100, PlaceResult: "express-transport-belt", Color: rgb(100, 200, 255)},
+		{Name: "underground-belt", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "underground-belt", Color: rgb(200, 200, 80)},
+		{Name: "fast-underground-belt", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "fast-underground-belt", Color: rgb(200, 80, 80)},
+		{Name: "express-underground-belt", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "express-underground-belt", Color: rgb(80, 160, 200)},
+		{Name: "splitter", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "splitter", Color: rgb(230, 230, 60)},
+		{Name: "fast-splitter", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "fast-splitter", Color: rgb(230, 60, 60)},
+		{Name: "express-splitter", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "express-splitter", Color: rgb(60, 180, 230)},
+		{Name: "burner-inserter", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "burner-inserter", Color: rgb(100, 255, 100)},
+		{Name: "inserter", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "inserter", Color: rgb(100, 255, 100)},
+		{Name: "long-handed-inserter", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "long-handed-inserter", Color: rgb(100, 255, 100)},
+		{Name: "fast-inserter", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "fast-inserter", Color: rgb(100, 255, 100)},
+		{Name: "stack-inserter", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "stack-inserter", Color: rgb(100, 255, 100)},
+		{Name: "pipe", Type: ItemTypeBuilding, StackSize: 100, PlaceResult: "pipe", Color: rgb(120, 120, 140)},
+		{Name: "pipe-to-ground", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "pipe-to-ground", Color: rgb(90, 90, 110)},
+		{Name: "burner-mining-drill", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "burner-mining-drill", Color: rgb(150, 120, 90)},
+		{Name: "electric-mining-drill", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "electric-mining-drill", Color: rgb(120, 140, 170)},
+		{Name: "offshore-pump", Type: ItemTypeBuilding, StackSize: 20, PlaceResult: "offshore-pump", Color: rgb(70, 110, 200)},
+		{Name: "boiler", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "boiler", Color: rgb(180, 90, 60)},
+		{Name: "rail", Type: ItemTypeBuilding, StackSize: 100, PlaceResult: "straight-rail", Color: rgb(110, 100, 90)},
+		{Name: "train-stop", Type: ItemTypeBuilding, StackSize: 10, PlaceResult: "train-stop", Color: rgb(200, 60, 160)},
+		{Name: "small-electric-pole", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "small-electric-pole", Color: rgb(255, 150, 100)},
+		{Name: "medium-electric-pole", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "medium-electric-pole", Color: rgb(230, 120, 80)},
+		{Name: "big-electric-pole", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "big-electric-pole", Color: rgb(200, 100, 60)},
+		{Name: "substation", Type: ItemTypeBuilding, StackSize: 50, PlaceResult: "substation", Color: rgb(180, 140, 90)},
 	}
 
 	for _, item := range items {
+		item.IconPath = builtinIconPath(item)
 		itemDB.Items[item.Name] = item
 	}
 
 	return itemDB, nil
 }
 
+// builtinIconPath returns the game's icon path of a built-in item. Fluid
+// icons have their own directory and quality modules ship with the quality
+// mod; items of other mods set their icon in their data files.
+func builtinIconPath(item *Item) string {
+	switch {
+	case item.Fluid:
+		return "__base__/graphics/icons/fluid/" + item.Name + ".png"
+	case strings.HasPrefix(item.Name, "quality-module"):
+		return "__quality__/graphics/icons/" + item.Name + ".png"
+	default:
+		return "__base__/graphics/icons/" + item.Name + ".png"
+	}
+}
+
 // GetItem retrieves an item by name.
 func (db *ItemDatabase) GetItem(name string) (*Item, bool) {
 	item, exists := db.Items[name]
@@ -114,16 +238,43 @@ func (db *ItemDatabase) IsFuel(itemName string) bool {
 	return false
 }
 
-// GetItemColor retrieves the color property of an item.
-func (db *ItemDatabase) GetItemColor(itemName string) (color.Color, bool) {
+// IsFluid checks if an item is a fluid.
+func (db *ItemDatabase) IsFluid(itemName string) bool {
 	if item, exists := db.GetItem(itemName); exists {
-		if item.Properties != nil {
-			if colorValue, hasColor := item.Properties["color"]; hasColor {
-				if c, ok := colorValue.(color.Color); ok {
-					return c, true
-				}
-			}
-		}
+		return item.Fluid
+	}
+	return false
+}
+
+// GetFuelValue retrieves the fuel value of an item in MJ.
+func (db *ItemDatabase) GetFuelValue(itemName string) (float64, bool) {
+	if item, exists := db.GetItem(itemName); exists && item.FuelValue > 0 {
+		return item.FuelValue, true
+	}
+	return 0, false
+}
+
+// GetMiningTime retrieves the seconds a drill with mining speed 1 takes
+// to mine one item of a resource.
+func (db *ItemDatabase) GetMiningTime(itemName string) (float64, bool) {
+	if item, exists := db.GetItem(itemName); exists && item.MiningTime > 0 {
+		return item.MiningTime, true
+	}
+	return 0, false
+}
+
+// GetStackSize retrieves the number of items in a full stack.
+func (db *ItemDatabase) GetStackSize(itemName string) (int, bool) {
+	if item, exists := db.GetItem(itemName); exists && item.StackSize > 0 {
+		return item.StackSize, true
+	}
+	return 0, false
+}
+
+// GetItemColor retrieves the color of an item.
+func (db *ItemDatabase) GetItemColor(itemName string) (color.Color, bool) {
+	if item, exists := db.GetItem(itemName); exists && item.Color != nil {
+		return color.RGBA(*item.Color), true
 	}
 	return nil, false
 }
@@ -0,0 +1,102 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestItemJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		item      Item
+		wantColor string // encoded color, empty for none
+	}{
+		{
+			name: "all fields",
+			item: Item{
+				Name: "rocket-part", Type: ItemTypeIntermediate, StackSize: 5,
+				FuelValue: 100, FuelCategory: "chemical",
+				Color:    rgb(200, 100, 0),
+				IconPath: "icons/rocket-part.png", Subgroup: "space-related", Order: "a[rocket-part]",
+				PlaceResult:          "rocket-silo",
+				RocketLaunchProducts: map[string]float64{"space-science-pack": 1000},
+			},
+			wantColor: `"#c86400"`,
+		},
+		{
+			name:      "translucent color",
+			item:      Item{Name: "water", Type: ItemTypeRaw, Fluid: true, Color: &Color{R: 0, G: 68, B: 255, A: 128}},
+			wantColor: `"#0044ff80"`,
+		},
+		{
+			name: "no color",
+			item: Item{Name: "iron-ore", Type: ItemTypeRaw, StackSize: 50, MiningTime: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(&tt.item)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(encoded, &fields); err != nil {
+				t.Fatalf("Unmarshal fields: %v", err)
+			}
+			if got := string(fields["color"]); got != tt.wantColor {
+				t.Errorf("color encodes as %s, want %s", got, tt.wantColor)
+			}
+
+			var decoded Item
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.item) {
+				t.Errorf("round trip gave %+v, want %+v", decoded, tt.item)
+			}
+		})
+	}
+}
+
+func TestColorUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Color
+		wantErr bool
+	}{
+		{name: "opaque", json: `"#ff8000"`, want: Color{R: 255, G: 128, A: 255}},
+		{name: "with alpha", json: `"#ff800040"`, want: Color{R: 255, G: 128, A: 64}},
+		{name: "upper case", json: `"#FF8000"`, want: Color{R: 255, G: 128, A: 255}},
+		{name: "no hash", json: `"ff8000"`, want: Color{R: 255, G: 128, A: 255}},
+		{name: "too short", json: `"#ff80"`, wantErr: true},
+		{name: "not hex", json: `"#ff80zz"`, wantErr: true},
+		{name: "color name", json: `"orange"`, wantErr: true},
+		{name: "not a string", json: `[255, 128, 0]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Color
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if tt.wantErr {
+				var typeErr *json.UnmarshalTypeError
+				if !errors.As(err, &typeErr) || !strings.Contains(typeErr.Value, `expected "#rrggbb" or "#rrggbbaa"`) {
+					t.Fatalf("got error %v, want a type error naming the hex formats", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("color = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Package data contains JSON loading and saving of game data files.
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+// FileError describes a problem in a data file, with the position and
+// field where it was found when known.
+type FileError struct {
+	Path   string // data file path
+	Line   int    // 1-based line, 0 if unknown
+	Column int    // 1-based column, 0 if unknown
+	Field  string // dotted path of the offending field, empty if unknown
+	Err    error
+}
+
+// Error formats the error as "path:line:column: field: message".
+func (fe *FileError) Error() string {
+	location := fe.Path
+	if fe.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", fe.Path, fe.Line, fe.Column)
+	}
+	if fe.Field != "" {
+		return fmt.Sprintf("%s: field %s: %v", location, fe.Field, fe.Err)
+	}
+	return fmt.Sprintf("%s: %v", location, fe.Err)
+}
+
+// Unwrap returns the underlying error.
+func (fe *FileError) Unwrap() error {
+	return fe.Err
+}
+
+// lineAndColumn converts a byte offset into a 1-based line and column.
+func lineAndColumn(content []byte, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	line, column := 1, 1
+	for _, b := range content[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+// writeJSONFile encodes v as indented JSON and writes it to path.
+func writeJSONFile(path string, v any) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	content = append(content, '\n')
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write data file: %w", err)
+	}
+	return nil
+}
+
+// LoadItemsFromFiles loads an item database from one or more JSON files.
+// Each file is layered on top of the previous ones: its items add new items
+// or change the given fields of items of the same name, and a non-empty
+// version replaces the version.
+func LoadItemsFromFiles(paths ...string) (*ItemDatabase, error) {
+	itemDB := &ItemDatabase{Items: make(map[string]*Item)}
+
+	version, err := loadLayers(paths, "items",
+		func(item *Item) *string { return &item.Name },
+		func(key string) *Item { return itemDB.Items[key] },
+		func(key string, item *Item) { itemDB.Items[key] = item })
+	if err != nil {
+		return nil, err
+	}
+
+	itemDB.Version = version
+	return itemDB, nil
+}
+
+// SaveJSON writes the item database to a JSON file.
+func (db *ItemDatabase) SaveJSON(path string) error {
+	return writeJSONFile(path, db)
+}
+
+// LoadRecipesFromFiles loads recipe data from one or more JSON files,
+// layered the same way as LoadItemsFromFiles.
+func LoadRecipesFromFiles(paths ...string) (*RecipeData, error) {
+	recipes := &RecipeData{Recipes: make(map[string]*core.Recipe)}
+
+	version, err := loadLayers(paths, "recipes",
+		func(recipe *core.Recipe) *string { return &recipe.Name },
+		func(key string) *core.Recipe { return recipes.Recipes[key] },
+		func(key string, recipe *core.Recipe) { recipes.Recipes[key] = recipe })
+	if err != nil {
+		return nil, err
+	}
+
+	recipes.Version = version
+	return recipes, nil
+}
+
+// SaveJSON writes the recipe data to a JSON file.
+func (rd *RecipeData) SaveJSON(path string) error {
+	return writeJSONFile(path, rd)
+}
+
+// LoadTechnologiesFromFiles loads technology data from one or more JSON
+// files, layered the same way as LoadItemsFromFiles.
+func LoadTechnologiesFromFiles(paths ...string) (*TechnologyData, error) {
+	techData := &TechnologyData{Technologies: make(map[string]*Technology)}
+
+	version, err := loadLayers(paths, "technologies",
+		func(tech *Technology) *string { return &tech.Name },
+		func(key string) *Technology { return techData.Technologies[key] },
+		func(key string, tech *Technology) { techData.Technologies[key] = tech })
+	if err != nil {
+		return nil, err
+	}
+
+	techData.Version = version
+	return techData, nil
+}
+
+// SaveJSON writes the technology data to a JSON file.
+func (td *TechnologyData) SaveJSON(path string) error {
+	return writeJSONFile(path, td)
+}
@@ -0,0 +1,317 @@
+// Package data contains layered decoding of data files, positioning
+// errors at the entry and field where they occur.
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// dataLayer is one parsed data file, with its entries still undecoded so
+// that each can be decoded and positioned on its own.
+type dataLayer struct {
+	Path     string
+	Content  []byte
+	Version  string
+	Sections map[string]map[string]json.RawMessage // section -> key -> entry
+}
+
+// readDataLayer reads a data file holding a version and the given sections
+// of named entries, such as {"version": "1.1.0", "items": {...}}.
+func readDataLayer(path string, sections ...string) (*dataLayer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(content, &top); err != nil {
+		return nil, positionError(path, content, 0, "", content, err)
+	}
+
+	layer := &dataLayer{
+		Path:     path,
+		Content:  content,
+		Sections: make(map[string]map[string]json.RawMessage),
+	}
+	for _, key := range sortedKeys(top) {
+		if key == "version" {
+			if err := json.Unmarshal(top[key], &layer.Version); err != nil {
+				return nil, layer.entryError("version", top[key], err)
+			}
+			continue
+		}
+		if !slices.Contains(sections, key) {
+			return nil, layer.entryError(key, top[key], fmt.Errorf("unknown field %q", key))
+		}
+
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(top[key], &entries); err != nil {
+			return nil, layer.entryError(key, top[key], err)
+		}
+		layer.Sections[key] = entries
+	}
+
+	return layer, nil
+}
+
+// decodeEntry decodes one entry of the layer's section, rejecting unknown
+// fields so that typos in hand-written override files are caught.
+func (dl *dataLayer) decodeEntry(section, key string, v any) error {
+	raw := dl.Sections[section][key]
+	if string(raw) == "null" {
+		return dl.entryError(section+"."+key, raw, fmt.Errorf("entry cannot be null"))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return dl.entryError(section+"."+key, raw, err)
+	}
+	return nil
+}
+
+// entryError positions an error found while decoding the value at a dotted
+// field path of the layer.
+func (dl *dataLayer) entryError(field string, raw json.RawMessage, err error) *FileError {
+	start, ok := locateField(dl.Content, func(fieldPath []string) bool {
+		return strings.Join(fieldPath, ".") == field
+	})
+	if ok {
+		start = valueStart(dl.Content, start)
+	} else {
+		start = -1
+	}
+	return positionError(dl.Path, dl.Content, start, field, raw, err)
+}
+
+// positionError wraps a JSON decoding error with its file position. Offsets
+// reported inside raw are shifted by base, the offset of raw in content; a
+// negative base means the position is unknown.
+func positionError(path string, content []byte, base int64, field string, raw []byte, err error) *FileError {
+	fileErr := &FileError{Path: path, Field: field, Err: err}
+	offset := base
+	if base < 0 {
+		return fileErr
+	}
+
+	joinField := func(inner string) string {
+		if field == "" {
+			return inner
+		}
+		return field + "." + inner
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// the offending character is the last one read
+		offset = base + max(syntaxErr.Offset-1, 0)
+	case errors.As(err, &typeErr):
+		fileErr.Err = fmt.Errorf("cannot use JSON %s as %s", typeErr.Value, typeErr.Type)
+		if typeErr.Field != "" {
+			fileErr.Field = joinField(typeErr.Field)
+		}
+		// the error offset lies past the value, so point at the value itself
+		if found, ok := locateField(raw, func(fieldPath []string) bool {
+			return strings.Join(fieldPath, ".") == typeErr.Field
+		}); ok && typeErr.Field != "" {
+			offset = base + valueStart(raw, found)
+		} else if typeErr.Offset > 0 {
+			offset = base + typeErr.Offset
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		fileErr.Err = fmt.Errorf("unknown field %q", name)
+		if found, ok := locateField(raw, func(fieldPath []string) bool {
+			if fieldPath[len(fieldPath)-1] == name {
+				fileErr.Field = joinField(strings.Join(fieldPath, "."))
+				return true
+			}
+			return false
+		}); ok {
+			offset = base + found
+		}
+	}
+
+	if offset >= 0 {
+		fileErr.Line, fileErr.Column = lineAndColumn(content, offset)
+	}
+	return fileErr
+}
+
+// locateField walks a JSON document and returns the offset of the first
+// object key whose dotted path (array indices left out) satisfies match.
+func locateField(content []byte, match func(fieldPath []string) bool) (int64, bool) {
+	type frame struct {
+		object    bool   // object rather than array
+		key       string // current key of an object
+		expectKey bool   // next string token is a key
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	var stack []*frame
+
+	// valueDone marks the current key's value as consumed
+	valueDone := func() {
+		if len(stack) > 0 && stack[len(stack)-1].object {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, false
+		}
+
+		switch value := token.(type) {
+		case json.Delim:
+			switch value {
+			case '{':
+				stack = append(stack, &frame{object: true, expectKey: true})
+			case '[':
+				stack = append(stack, &frame{})
+			default:
+				stack = stack[:len(stack)-1]
+				valueDone()
+			}
+		case string:
+			if len(stack) == 0 || !stack[len(stack)-1].object || !stack[len(stack)-1].expectKey {
+				valueDone()
+				continue
+			}
+
+			top := stack[len(stack)-1]
+			top.key = value
+			top.expectKey = false
+
+			var fieldPath []string
+			for _, f := range stack {
+				if f.object {
+					fieldPath = append(fieldPath, f.key)
+				}
+			}
+			if match(fieldPath) {
+				for offset < int64(len(content)) && strings.ContainsRune(" \t\r\n,", rune(content[offset])) {
+					offset++
+				}
+				return offset, true
+			}
+		default:
+			valueDone()
+		}
+	}
+}
+
+// valueStart returns the offset of the value following the object key that
+// starts at offset.
+func valueStart(content []byte, offset int64) int64 {
+	i := offset + 1 // skip opening quote
+	for i < int64(len(content)) && content[i] != '"' {
+		if content[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	i++ // skip closing quote
+	for i < int64(len(content)) && strings.ContainsRune(" \t\r\n:", rune(content[i])) {
+		i++
+	}
+	return i
+}
+
+// loadLayers reads data files in order and decodes every entry of their
+// section, handing each to apply. Entries of later files are merged into
+// the entries of earlier files that current returns. The version of the
+// last file that sets one is returned.
+func loadLayers[T any](paths []string, section string, name func(*T) *string, current func(key string) *T, apply func(key string, entry *T)) (string, error) {
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no %s data files given", section)
+	}
+
+	version := ""
+	for _, path := range paths {
+		layer, err := readDataLayer(path, section)
+		if err != nil {
+			return "", err
+		}
+		if layer.Version != "" {
+			version = layer.Version
+		}
+
+		if err := decodeSection(layer, section, name, current, apply); err != nil {
+			return "", err
+		}
+	}
+
+	return version, nil
+}
+
+// decodeSection decodes every entry of a layer's section in key order and
+// hands each to apply. Entries without a name take their key as name; a
+// name that differs from the key is an error. An entry whose key current
+// returns an existing entry for only changes the fields it gives, so
+// {"iron-plate": {"crafting_time": 1.6}} keeps the recipe's ingredients.
+func decodeSection[T any](layer *dataLayer, section string, name func(*T) *string, current func(key string) *T, apply func(key string, entry *T)) error {
+	entries := layer.Sections[section]
+	for _, key := range sortedKeys(entries) {
+		entry := new(T)
+		if err := layer.decodeEntry(section, key, entry); err != nil {
+			return err
+		}
+
+		entryName := name(entry)
+		if *entryName != "" && *entryName != key {
+			return layer.entryError(section+"."+key+".name", entries[key],
+				fmt.Errorf("name %q does not match key %q", *entryName, key))
+		}
+
+		if existing := current(key); existing != nil {
+			merged, err := mergeEntry(existing, entries[key])
+			if err != nil {
+				return layer.entryError(section+"."+key, entries[key], err)
+			}
+			entry = merged
+		}
+		*name(entry) = key
+
+		apply(key, entry)
+	}
+	return nil
+}
+
+// mergeEntry returns a copy of an entry with the top-level fields given in
+// raw replaced. The copy shares no maps or slices with the entry.
+func mergeEntry[T any](entry *T, raw json.RawMessage) (*T, error) {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	var fields, changes map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &changes); err != nil {
+		return nil, err
+	}
+	maps.Copy(fields, changes)
+
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	result := new(T)
+	if err := json.Unmarshal(merged, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,234 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+func TestPositionError(t *testing.T) {
+	const base = `{
+  "version": "1.1.0",
+  "recipes": {
+    "iron-plate": {
+      "name": "iron-plate",
+      "inputs": {"iron-ore": 1},
+      "outputs": {"iron-plate": 1},
+      "crafting_time": 3.2,
+      "category": "smelting"
+    }
+  }
+}
+`
+
+	tests := []struct {
+		name    string
+		files   []string // data file contents, later files layered on earlier ones
+		errFile int      // index of the file the error is reported in
+		line    int
+		column  int
+		field   string
+		message string
+	}{
+		{
+			name: "syntax error",
+			files: []string{`{
+  "recipes": {
+    "iron-plate": {,
+  }
+}
+`},
+			line: 3, column: 20,
+			message: "invalid character ',' looking for beginning of object key string",
+		},
+		{
+			name: "type mismatch",
+			files: []string{`{
+  "recipes": {
+    "iron-plate": {
+      "name": "iron-plate",
+      "inputs": {"iron-ore": "one"}
+    }
+  }
+}
+`},
+			line: 5, column: 30,
+			field:   "recipes.iron-plate.inputs.iron-ore",
+			message: "cannot use JSON string as float64",
+		},
+		{
+			name: "unknown field",
+			files: []string{`{
+  "recipes": {
+    "iron-plate": {
+      "name": "iron-plate",
+      "crafting_tme": 3.2
+    }
+  }
+}
+`},
+			line: 5, column: 7,
+			field:   "recipes.iron-plate.crafting_tme",
+			message: `unknown field "crafting_tme"`,
+		},
+		{
+			name: "layered override",
+			files: []string{base, `{
+  "recipes": {
+    "iron-plate": {
+      "crafting_time": "fast"
+    }
+  }
+}
+`},
+			errFile: 1,
+			line:    4, column: 24,
+			field:   "recipes.iron-plate.crafting_time",
+			message: "cannot use JSON string as float64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var paths []string
+			for i, content := range tt.files {
+				path := filepath.Join(dir, string(rune('a'+i))+".json")
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, path)
+			}
+
+			_, err := LoadRecipesFromFiles(paths...)
+			var fileErr *FileError
+			if !errors.As(err, &fileErr) {
+				t.Fatalf("got error %v, want a FileError", err)
+			}
+
+			if fileErr.Path != paths[tt.errFile] {
+				t.Errorf("path = %s, want %s", fileErr.Path, paths[tt.errFile])
+			}
+			if fileErr.Line != tt.line || fileErr.Column != tt.column {
+				t.Errorf("position = %d:%d, want %d:%d", fileErr.Line, fileErr.Column, tt.line, tt.column)
+			}
+			if fileErr.Field != tt.field {
+				t.Errorf("field = %q, want %q", fileErr.Field, tt.field)
+			}
+			if fileErr.Err.Error() != tt.message {
+				t.Errorf("message = %q, want %q", fileErr.Err, tt.message)
+			}
+		})
+	}
+}
+
+func TestLoadRecipesFromFilesMerges(t *testing.T) {
+	const base = `{"version": "1.1.0", "recipes": {
+  "iron-plate": {"inputs": {"iron-ore": 1}, "outputs": {"iron-plate": 1}, "crafting_time": 3.2, "category": "smelting"},
+  "iron-gear-wheel": {"inputs": {"iron-plate": 2}, "outputs": {"iron-gear-wheel": 1}, "crafting_time": 0.5, "category": "crafting"}
+}}`
+
+	tests := []struct {
+		name     string
+		override string
+		want     core.Recipe // the iron plate recipe
+	}{
+		{
+			name:     "changed field",
+			override: `{"recipes": {"iron-plate": {"crafting_time": 1.6}}}`,
+			want: core.Recipe{Name: "iron-plate", Inputs: map[string]float64{"iron-ore": 1}, Outputs: map[string]float64{"iron-plate": 1},
+				CraftingTime: 1.6, Category: "smelting"},
+		},
+		{
+			name:     "replaced map",
+			override: `{"recipes": {"iron-plate": {"name": "iron-plate", "inputs": {"iron-scrap": 2}}}}`,
+			want: core.Recipe{Name: "iron-plate", Inputs: map[string]float64{"iron-scrap": 2}, Outputs: map[string]float64{"iron-plate": 1},
+				CraftingTime: 3.2, Category: "smelting"},
+		},
+		{
+			name:     "other entry",
+			override: `{"recipes": {"iron-gear-wheel": {"crafting_time": 1}}}`,
+			want: core.Recipe{Name: "iron-plate", Inputs: map[string]float64{"iron-ore": 1}, Outputs: map[string]float64{"iron-plate": 1},
+				CraftingTime: 3.2, Category: "smelting"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var paths []string
+			for i, content := range []string{base, tt.override} {
+				path := filepath.Join(dir, string(rune('a'+i))+".json")
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, path)
+			}
+
+			recipes, err := LoadRecipesFromFiles(paths...)
+			if err != nil {
+				t.Fatalf("LoadRecipesFromFiles: %v", err)
+			}
+			if got := recipes.Recipes["iron-plate"]; !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("iron plate recipe = %+v, want %+v", *got, tt.want)
+			}
+			if len(recipes.Recipes) != 2 || recipes.Version != "1.1.0" {
+				t.Errorf("got %d recipes of version %q, want 2 of version 1.1.0", len(recipes.Recipes), recipes.Version)
+			}
+		})
+	}
+}
+
+// TestExportRoundTrip saves the built-in data the way export-data does and
+// loads it back unchanged.
+func TestExportRoundTrip(t *testing.T) {
+	items, recipes, _, technologies := loadBuiltinData(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		save func(path string) error
+		load func(paths ...string) (any, error)
+		want any
+	}{
+		{
+			name: "items",
+			save: items.SaveJSON,
+			load: func(paths ...string) (any, error) { return LoadItemsFromFiles(paths...) },
+			want: items,
+		},
+		{
+			name: "recipes",
+			save: recipes.SaveJSON,
+			load: func(paths ...string) (any, error) { return LoadRecipesFromFiles(paths...) },
+			want: recipes,
+		},
+		{
+			name: "technologies",
+			save: technologies.SaveJSON,
+			load: func(paths ...string) (any, error) { return LoadTechnologiesFromFiles(paths...) },
+			want: technologies,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if err := tt.save(path); err != nil {
+				t.Fatalf("SaveJSON: %v", err)
+			}
+			// layering the export on itself changes nothing either
+			got, err := tt.load(path, path)
+			if err != nil {
+				t.Fatalf("loading the export: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loaded %s differ from the exported ones", tt.name)
+			}
+		})
+	}
+}
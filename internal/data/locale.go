@@ -0,0 +1,132 @@
+// Package data contains localized display names read from locale files.
+package data
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Locale sections holding display names.
+const (
+	localeItemNames       = "item-name"
+	localeFluidNames      = "fluid-name"
+	localeEntityNames     = "entity-name"
+	localeRecipeNames     = "recipe-name"
+	localeTechnologyNames = "technology-name"
+)
+
+// Locale maps internal names to display names in one language. A nil
+// Locale is valid and returns internal names unchanged.
+type Locale struct {
+	Language string
+	Sections map[string]map[string]string // section -> key -> display name
+}
+
+// LoadLocale reads the locale files of a language from <dir>/<language>/*.cfg,
+// the layout of the game's locale directories. Files are read in name
+// order, so later files override keys of earlier ones.
+func LoadLocale(dir, language string) (*Locale, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, language, "*.cfg"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list locale files: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no locale files found for %s in %s", language, dir)
+	}
+	sort.Strings(paths)
+
+	locale := &Locale{
+		Language: language,
+		Sections: make(map[string]map[string]string),
+	}
+	for _, path := range paths {
+		if err := locale.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return locale, nil
+}
+
+// readFile adds the keys of one locale file. Files hold "[section]"
+// headers followed by "key=value" lines; blank lines and lines starting
+// with "#" or ";" are ignored.
+func (l *Locale) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read locale file: %w", err)
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, "\ufeff") // byte order mark
+		}
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("%s:%d: unterminated section header", path, lineNumber)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+		default:
+			key, value, found := strings.Cut(line, "=")
+			if !found {
+				return fmt.Errorf("%s:%d: expected key=value", path, lineNumber)
+			}
+			if l.Sections[section] == nil {
+				l.Sections[section] = make(map[string]string)
+			}
+			l.Sections[section][strings.TrimSpace(key)] = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read locale file: %w", err)
+	}
+	return nil
+}
+
+// lookup returns the display name of the first section that has one, or
+// the internal name when none does.
+func (l *Locale) lookup(name string, sections ...string) string {
+	if l == nil {
+		return name
+	}
+	for _, section := range sections {
+		if value, exists := l.Sections[section][name]; exists && value != "" {
+			return value
+		}
+	}
+	return name
+}
+
+// ItemName returns the display name of an item or fluid. Items that place
+// an entity often only have the entity's name, so that is tried last.
+func (l *Locale) ItemName(name string) string {
+	return l.lookup(name, localeItemNames, localeFluidNames, localeEntityNames)
+}
+
+// EntityName returns the display name of an entity.
+func (l *Locale) EntityName(name string) string {
+	return l.lookup(name, localeEntityNames, localeItemNames)
+}
+
+// RecipeName returns the display name of a recipe. Recipes named after
+// their product usually take the product's name, as in the game.
+func (l *Locale) RecipeName(name string) string {
+	return l.lookup(name, localeRecipeNames, localeItemNames, localeFluidNames, localeEntityNames)
+}
+
+// TechnologyName returns the display name of a technology.
+func (l *Locale) TechnologyName(name string) string {
+	return l.lookup(name, localeTechnologyNames)
+}
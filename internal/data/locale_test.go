@@ -0,0 +1,91 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadLocale(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.cfg": "\ufeff# Basisspiel\n[item-name]\niron-plate=Eisenplatte\nelectronic-circuit=Elektronischer Schaltkreis\n\n" +
+			"[entity-name]\nstone-furnace=Steinofen\n\n[recipe-name]\nbasic-oil-processing=Einfache Ölverarbeitung\n\n" +
+			"[technology-name]\nautomation=Automatisierung\n",
+		"mod.cfg": "; mod overrides\n[item-name]\niron-plate=Eisenblech\n[fluid-name]\npetroleum-gas=Petroleumgas\n",
+	}
+	if err := os.Mkdir(filepath.Join(dir, "de"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, "de", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	locale, err := LoadLocale(dir, "de")
+	if err != nil {
+		t.Fatalf("LoadLocale: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		lookup func(*Locale, string) string
+		key    string
+		want   string
+	}{
+		{"later file overrides", (*Locale).ItemName, "iron-plate", "Eisenblech"},
+		{"item", (*Locale).ItemName, "electronic-circuit", "Elektronischer Schaltkreis"},
+		{"fluid", (*Locale).ItemName, "petroleum-gas", "Petroleumgas"},
+		{"item from entity", (*Locale).ItemName, "stone-furnace", "Steinofen"},
+		{"entity", (*Locale).EntityName, "stone-furnace", "Steinofen"},
+		{"recipe", (*Locale).RecipeName, "basic-oil-processing", "Einfache Ölverarbeitung"},
+		{"recipe from product", (*Locale).RecipeName, "electronic-circuit", "Elektronischer Schaltkreis"},
+		{"technology", (*Locale).TechnologyName, "automation", "Automatisierung"},
+		{"technology not from items", (*Locale).TechnologyName, "iron-plate", "iron-plate"},
+		{"missing", (*Locale).ItemName, "copper-plate", "copper-plate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lookup(locale, tt.key); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if got := tt.lookup(nil, tt.key); got != tt.key {
+				t.Errorf("nil locale gave %q, want %q", got, tt.key)
+			}
+		})
+	}
+}
+
+func TestLoadLocaleErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // content of de/base.cfg, empty for no file
+		err     string
+	}{
+		{name: "no files", err: "no locale files found for de"},
+		{name: "unterminated header", content: "[item-name\niron-plate=Eisenplatte\n", err: "base.cfg:1: unterminated section header"},
+		{name: "missing value", content: "[item-name]\niron-plate\n", err: "base.cfg:2: expected key=value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "de"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(dir, "de", "base.cfg"), []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := LoadLocale(dir, "de")
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
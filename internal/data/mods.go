@@ -0,0 +1,230 @@
+// Package data contains mod list loading and mod data overlays.
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+// baseMod is the name of the base game in a mod list. Its data is the
+// built-in or file-loaded data, so it has no overlay.
+const baseMod = "base"
+
+// Overlay sections understood in mod data files.
+const (
+	sectionItems        = "items"
+	sectionRecipes      = "recipes"
+	sectionTechnologies = "technologies"
+	sectionDelete       = "delete"
+)
+
+// ModEntry is one mod in a mod list.
+type ModEntry struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// ModList is the ordered list of mods to load, in the format of the
+// game's mod-list.json. Mods are applied in list order, so later mods
+// override earlier ones.
+type ModList struct {
+	Mods []ModEntry `json:"mods"`
+}
+
+// LoadModList reads a mod list from a mod-list.json file.
+func LoadModList(path string) (*ModList, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mod list: %w", err)
+	}
+
+	var modList ModList
+	if err := json.Unmarshal(content, &modList); err != nil {
+		return nil, positionError(path, content, 0, "", content, err)
+	}
+	return &modList, nil
+}
+
+// EnabledMods returns the names of the enabled mods in load order, leaving
+// out the base game.
+func (ml *ModList) EnabledMods() []string {
+	var names []string
+	for _, mod := range ml.Mods {
+		if mod.Enabled && mod.Name != baseMod {
+			names = append(names, mod.Name)
+		}
+	}
+	return names
+}
+
+// modTouch records which mod last added, replaced or deleted an entry.
+type modTouch struct {
+	Mod    string
+	Action string // "added", "replaced" or "deleted"
+}
+
+// modOverlay applies mod data files to the game data and collects the
+// resulting diagnostics.
+type modOverlay struct {
+	items        *ItemDatabase
+	recipes      *RecipeData
+	technologies *TechnologyData
+
+	touched     map[string]modTouch // "section.name" -> last mod to change it
+	diagnostics []Diagnostic
+}
+
+// ApplyMods applies the data of each enabled mod in the list on top of the
+// given items, recipes and technologies. A mod's data is read from
+// <modsDir>/<name>.json and may hold "items", "recipes" and "technologies"
+// sections, whose entries add new entries or change the given fields of
+// entries of the same name, and a "delete" section listing names to remove
+// per section, e.g.
+//
+//	{"recipes": {"iron-plate": {"crafting_time": 1.6}}, "delete": {"recipes": ["iron-gear-wheel"]}}
+//
+// Entries changed by more than one mod are reported as conflicts, with the
+// later mod winning, and deleting an entry that does not exist is reported
+// as a warning. Diagnostics are returned in load order.
+func ApplyMods(modList *ModList, modsDir string, items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) ([]Diagnostic, error) {
+	overlay := &modOverlay{
+		items:        items,
+		recipes:      recipes,
+		technologies: technologies,
+		touched:      make(map[string]modTouch),
+	}
+
+	for _, mod := range modList.EnabledMods() {
+		path := filepath.Join(modsDir, mod+".json")
+		if err := overlay.apply(mod, path); err != nil {
+			return nil, fmt.Errorf("failed to apply mod %s: %w", mod, err)
+		}
+	}
+
+	return overlay.diagnostics, nil
+}
+
+// apply reads one mod's data file and applies its deletions, then its
+// additions and replacements.
+func (mo *modOverlay) apply(mod, path string) error {
+	layer, err := readDataLayer(path, sectionItems, sectionRecipes, sectionTechnologies, sectionDelete)
+	if err != nil {
+		return err
+	}
+
+	if err := mo.applyDeletions(mod, layer); err != nil {
+		return err
+	}
+
+	if err := decodeSection(layer, sectionItems,
+		func(item *Item) *string { return &item.Name },
+		func(key string) *Item { return mo.items.Items[key] },
+		func(key string, item *Item) {
+			_, exists := mo.items.Items[key]
+			mo.touch(mod, sectionItems, key, exists)
+			mo.items.Items[key] = item
+		}); err != nil {
+		return err
+	}
+
+	if err := decodeSection(layer, sectionRecipes,
+		func(recipe *core.Recipe) *string { return &recipe.Name },
+		func(key string) *core.Recipe { return mo.recipes.Recipes[key] },
+		func(key string, recipe *core.Recipe) {
+			_, exists := mo.recipes.Recipes[key]
+			mo.touch(mod, sectionRecipes, key, exists)
+			mo.recipes.Recipes[key] = recipe
+		}); err != nil {
+		return err
+	}
+
+	return decodeSection(layer, sectionTechnologies,
+		func(tech *Technology) *string { return &tech.Name },
+		func(key string) *Technology { return mo.technologies.Technologies[key] },
+		func(key string, tech *Technology) {
+			_, exists := mo.technologies.Technologies[key]
+			mo.touch(mod, sectionTechnologies, key, exists)
+			mo.technologies.Technologies[key] = tech
+		})
+}
+
+// applyDeletions removes the entries listed in a layer's delete section.
+func (mo *modOverlay) applyDeletions(mod string, layer *dataLayer) error {
+	deletions := layer.Sections[sectionDelete]
+	for _, section := range sortedKeys(deletions) {
+		var names []string
+		if err := layer.decodeEntry(sectionDelete, section, &names); err != nil {
+			return err
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			var exists bool
+			switch section {
+			case sectionItems:
+				_, exists = mo.items.Items[name]
+				delete(mo.items.Items, name)
+			case sectionRecipes:
+				_, exists = mo.recipes.Recipes[name]
+				delete(mo.recipes.Recipes, name)
+			case sectionTechnologies:
+				_, exists = mo.technologies.Technologies[name]
+				delete(mo.technologies.Technologies, name)
+			default:
+				return layer.entryError(sectionDelete+"."+section, deletions[section],
+					fmt.Errorf("unknown section %q", section))
+			}
+
+			if !exists {
+				mo.diagnostics = append(mo.diagnostics, Diagnostic{
+					Severity: SeverityWarning,
+					Code:     "mod-delete-missing",
+					Subject:  name,
+					Message:  fmt.Sprintf("mod %s deletes %s %s, which does not exist", mod, singular(section), name),
+				})
+				continue
+			}
+			mo.record(mod, section, name, "deleted")
+		}
+	}
+	return nil
+}
+
+// touch records an entry added or replaced by a mod.
+func (mo *modOverlay) touch(mod, section, name string, exists bool) {
+	action := "added"
+	if exists {
+		action = "replaced"
+	}
+	mo.record(mod, section, name, action)
+}
+
+// record notes that a mod changed an entry, reporting a conflict when an
+// earlier mod already changed it.
+func (mo *modOverlay) record(mod, section, name, action string) {
+	key := section + "." + name
+	if previous, ok := mo.touched[key]; ok && previous.Mod != mod {
+		mo.diagnostics = append(mo.diagnostics, Diagnostic{
+			Severity: SeverityWarning,
+			Code:     "mod-conflict",
+			Subject:  name,
+			Message: fmt.Sprintf("%s %s was %s by mod %s and is %s by mod %s, which wins",
+				singular(section), name, previous.Action, previous.Mod, action, mod),
+		})
+	}
+	mo.touched[key] = modTouch{Mod: mod, Action: action}
+}
+
+// singular returns the entry kind of an overlay section, e.g. "recipe".
+func singular(section string) string {
+	if section == sectionTechnologies {
+		return "technology"
+	}
+	return strings.TrimSuffix(section, "s")
+}
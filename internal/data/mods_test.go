@@ -0,0 +1,150 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+func TestApplyMods(t *testing.T) {
+	const ironPlateSlow = `{"recipes": {"iron-plate": {"inputs": {"iron-ore": 1}, "outputs": {"iron-plate": 1}, "crafting_time": 6.4, "category": "smelting"}}}`
+	const ironPlateFast = `{"recipes": {"iron-plate": {"inputs": {"iron-ore": 1}, "outputs": {"iron-plate": 1}, "crafting_time": 1.6, "category": "smelting"}}}`
+
+	tests := []struct {
+		name        string
+		mods        []ModEntry
+		files       map[string]string // mod name -> data file
+		diagnostics []string          // "code: message" in load order
+		recipes     map[string]float64
+		deleted     []string
+		err         string
+	}{
+		{
+			name:    "replace",
+			mods:    []ModEntry{{Name: baseMod, Enabled: true}, {Name: "slow", Enabled: true}},
+			files:   map[string]string{"slow": ironPlateSlow},
+			recipes: map[string]float64{"iron-plate": 6.4, "iron-gear-wheel": 0.5},
+		},
+		{
+			name:    "partial override",
+			mods:    []ModEntry{{Name: "fast", Enabled: true}},
+			files:   map[string]string{"fast": `{"recipes": {"iron-plate": {"crafting_time": 1.6}}}`},
+			recipes: map[string]float64{"iron-plate": 1.6, "iron-gear-wheel": 0.5},
+		},
+		{
+			name:    "disabled mod skipped",
+			mods:    []ModEntry{{Name: "slow", Enabled: false}},
+			recipes: map[string]float64{"iron-plate": 3.2},
+		},
+		{
+			name:  "conflict, later mod wins",
+			mods:  []ModEntry{{Name: "slow", Enabled: true}, {Name: "fast", Enabled: true}},
+			files: map[string]string{"slow": ironPlateSlow, "fast": ironPlateFast},
+			diagnostics: []string{
+				"mod-conflict: recipe iron-plate was replaced by mod slow and is replaced by mod fast, which wins",
+			},
+			recipes: map[string]float64{"iron-plate": 1.6},
+		},
+		{
+			name: "deleted then added",
+			mods: []ModEntry{{Name: "no-gears", Enabled: true}, {Name: "gears", Enabled: true}},
+			files: map[string]string{
+				"no-gears": `{"delete": {"recipes": ["iron-gear-wheel"]}}`,
+				"gears":    `{"recipes": {"iron-gear-wheel": {"inputs": {"iron-plate": 1}, "outputs": {"iron-gear-wheel": 1}, "crafting_time": 1, "category": "crafting"}}}`,
+			},
+			diagnostics: []string{
+				"mod-conflict: recipe iron-gear-wheel was deleted by mod no-gears and is added by mod gears, which wins",
+			},
+			recipes: map[string]float64{"iron-gear-wheel": 1},
+		},
+		{
+			name:    "delete",
+			mods:    []ModEntry{{Name: "no-gears", Enabled: true}},
+			files:   map[string]string{"no-gears": `{"delete": {"recipes": ["iron-gear-wheel"], "items": ["iron-gear-wheel"]}}`},
+			recipes: map[string]float64{"iron-plate": 3.2},
+			deleted: []string{"iron-gear-wheel"},
+		},
+		{
+			name:  "delete missing entry",
+			mods:  []ModEntry{{Name: "tidy", Enabled: true}},
+			files: map[string]string{"tidy": `{"delete": {"technologies": ["rocket-silo"]}}`},
+			diagnostics: []string{
+				"mod-delete-missing: mod tidy deletes technology rocket-silo, which does not exist",
+			},
+		},
+		{
+			name:  "unknown delete section",
+			mods:  []ModEntry{{Name: "tidy", Enabled: true}},
+			files: map[string]string{"tidy": `{"delete": {"entities": ["lab"]}}`},
+			err:   `field delete.entities: unknown section "entities"`,
+		},
+		{
+			name: "missing data file",
+			mods: []ModEntry{{Name: "ghost", Enabled: true}},
+			err:  "failed to apply mod ghost",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for mod, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, mod+".json"), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			items := &ItemDatabase{Items: map[string]*Item{
+				"iron-ore":        {Name: "iron-ore", Type: ItemTypeRaw},
+				"iron-plate":      {Name: "iron-plate", Type: ItemTypeIntermediate},
+				"iron-gear-wheel": {Name: "iron-gear-wheel", Type: ItemTypeIntermediate},
+			}}
+			recipes := &RecipeData{Recipes: map[string]*core.Recipe{
+				"iron-plate":      {Name: "iron-plate", Inputs: map[string]float64{"iron-ore": 1}, Outputs: map[string]float64{"iron-plate": 1}, CraftingTime: 3.2, Category: "smelting"},
+				"iron-gear-wheel": {Name: "iron-gear-wheel", Inputs: map[string]float64{"iron-plate": 2}, Outputs: map[string]float64{"iron-gear-wheel": 1}, CraftingTime: 0.5, Category: "crafting"},
+			}}
+			technologies := &TechnologyData{Technologies: make(map[string]*Technology)}
+
+			diagnostics, err := ApplyMods(&ModList{Mods: tt.mods}, dir, items, recipes, technologies)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyMods: %v", err)
+			}
+
+			var got []string
+			for _, diagnostic := range diagnostics {
+				got = append(got, diagnostic.Code+": "+diagnostic.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.diagnostics, "\n") {
+				t.Errorf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.diagnostics, "\n"))
+			}
+
+			for name, craftingTime := range tt.recipes {
+				recipe, exists := recipes.Recipes[name]
+				if !exists {
+					t.Errorf("recipe %s missing", name)
+				} else if recipe.CraftingTime != craftingTime {
+					t.Errorf("recipe %s crafting time = %v, want %v", name, recipe.CraftingTime, craftingTime)
+				} else if len(recipe.Inputs) == 0 || len(recipe.Outputs) == 0 || recipe.Category == "" {
+					t.Errorf("recipe %s = %+v, want ingredients, products and a category", name, recipe)
+				}
+			}
+			for _, name := range tt.deleted {
+				if _, exists := recipes.Recipes[name]; exists {
+					t.Errorf("recipe %s not deleted", name)
+				}
+				if _, exists := items.Items[name]; exists {
+					t.Errorf("item %s not deleted", name)
+				}
+			}
+		})
+	}
+}
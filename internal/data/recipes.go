@@ -80,6 +80,753 @@ func LoadRecipes() (*RecipeData, error) {
 	}
 	recipes.Recipes["copper-plate"] = copperPlate
 
+	// Copper cable recipe
+	copperCable := &core.Recipe{
+		Name: "copper-cable",
+		Inputs: map[string]float64{
+			"copper-plate": 1.0,
+		},
+		Outputs: map[string]float64{
+			"copper-cable": 2.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["copper-cable"] = copperCable
+
+	// Electronic circuit recipe
+	electronicCircuit := &core.Recipe{
+		Name: "electronic-circuit",
+		Inputs: map[string]float64{
+			"iron-plate":   1.0,
+			"copper-cable": 3.0,
+		},
+		Outputs: map[string]float64{
+			"electronic-circuit": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["electronic-circuit"] = electronicCircuit
+
+	// Transport belt recipe
+	transportBelt := &core.Recipe{
+		Name: "transport-belt",
+		Inputs: map[string]float64{
+			"iron-plate":      1.0,
+			"iron-gear-wheel": 1.0,
+		},
+		Outputs: map[string]float64{
+			"transport-belt": 2.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["transport-belt"] = transportBelt
+
+	// Fast transport belt recipe
+	fastTransportBelt := &core.Recipe{
+		Name: "fast-transport-belt",
+		Inputs: map[string]float64{
+			"iron-gear-wheel": 5.0,
+			"transport-belt":  1.0,
+		},
+		Outputs: map[string]float64{
+			"fast-transport-belt": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["fast-transport-belt"] = fastTransportBelt
+
+	// Splitter recipe
+	splitter := &core.Recipe{
+		Name: "splitter",
+		Inputs: map[string]float64{
+			"electronic-circuit": 5.0,
+			"iron-plate":         5.0,
+			"transport-belt":     4.0,
+		},
+		Outputs: map[string]float64{
+			"splitter": 1.0,
+		},
+		CraftingTime: 1.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["splitter"] = splitter
+
+	// Fast splitter recipe
+	fastSplitter := &core.Recipe{
+		Name: "fast-splitter",
+		Inputs: map[string]float64{
+			"electronic-circuit": 10.0,
+			"iron-gear-wheel":    10.0,
+			"splitter":           1.0,
+		},
+		Outputs: map[string]float64{
+			"fast-splitter": 1.0,
+		},
+		CraftingTime: 2.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["fast-splitter"] = fastSplitter
+
+	// Inserter recipe
+	inserter := &core.Recipe{
+		Name: "inserter",
+		Inputs: map[string]float64{
+			"electronic-circuit": 1.0,
+			"iron-gear-wheel":    1.0,
+			"iron-plate":         1.0,
+		},
+		Outputs: map[string]float64{
+			"inserter": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["inserter"] = inserter
+
+	// Long-handed inserter recipe
+	longHandedInserter := &core.Recipe{
+		Name: "long-handed-inserter",
+		Inputs: map[string]float64{
+			"inserter":        1.0,
+			"iron-gear-wheel": 1.0,
+			"iron-plate":      1.0,
+		},
+		Outputs: map[string]float64{
+			"long-handed-inserter": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["long-handed-inserter"] = longHandedInserter
+
+	// Fast inserter recipe
+	fastInserter := &core.Recipe{
+		Name: "fast-inserter",
+		Inputs: map[string]float64{
+			"electronic-circuit": 2.0,
+			"iron-plate":         2.0,
+			"inserter":           1.0,
+		},
+		Outputs: map[string]float64{
+			"fast-inserter": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["fast-inserter"] = fastInserter
+
+	// Stack inserter recipe
+	stackInserter := &core.Recipe{
+		Name: "stack-inserter",
+		Inputs: map[string]float64{
+			"iron-gear-wheel":    15.0,
+			"electronic-circuit": 15.0,
+			"advanced-circuit":   1.0,
+			"fast-inserter":      1.0,
+		},
+		Outputs: map[string]float64{
+			"stack-inserter": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["stack-inserter"] = stackInserter
+
+	// Assembling machine recipe
+	assemblingMachine1 := &core.Recipe{
+		Name: "assembling-machine-1",
+		Inputs: map[string]float64{
+			"electronic-circuit": 3.0,
+			"iron-gear-wheel":    5.0,
+			"iron-plate":         9.0,
+		},
+		Outputs: map[string]float64{
+			"assembling-machine-1": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["assembling-machine-1"] = assemblingMachine1
+
+	// Logistic science pack recipe
+	sciencePack2 := &core.Recipe{
+		Name: "logistic-science-pack",
+		Inputs: map[string]float64{
+			"inserter":       1.0,
+			"transport-belt": 1.0,
+		},
+		Outputs: map[string]float64{
+			"logistic-science-pack": 1.0,
+		},
+		CraftingTime: 6.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["logistic-science-pack"] = sciencePack2
+
+	// Advanced circuit recipe
+	advancedCircuit := &core.Recipe{
+		Name: "advanced-circuit",
+		Inputs: map[string]float64{
+			"electronic-circuit": 2.0,
+			"plastic-bar":        2.0,
+			"copper-cable":       4.0,
+		},
+		Outputs: map[string]float64{
+			"advanced-circuit": 1.0,
+		},
+		CraftingTime: 6.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["advanced-circuit"] = advancedCircuit
+
+	// Quality module recipe
+	qualityModule := &core.Recipe{
+		Name: "quality-module",
+		Inputs: map[string]float64{
+			"electronic-circuit": 5.0,
+			"advanced-circuit":   5.0,
+		},
+		Outputs: map[string]float64{
+			"quality-module": 1.0,
+		},
+		CraftingTime: 15.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["quality-module"] = qualityModule
+
+	// Chemical science pack recipes
+	steelPlate := &core.Recipe{
+		Name: "steel-plate",
+		Inputs: map[string]float64{
+			"iron-plate": 5.0,
+		},
+		Outputs: map[string]float64{
+			"steel-plate": 1.0,
+		},
+		CraftingTime: 16.0,
+		Category:     "smelting",
+	}
+	recipes.Recipes["steel-plate"] = steelPlate
+
+	engineUnit := &core.Recipe{
+		Name: "engine-unit",
+		Inputs: map[string]float64{
+			"steel-plate":     1.0,
+			"iron-gear-wheel": 1.0,
+			"pipe":            2.0,
+		},
+		Outputs: map[string]float64{
+			"engine-unit": 1.0,
+		},
+		CraftingTime: 10.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["engine-unit"] = engineUnit
+
+	sulfur := &core.Recipe{
+		Name: "sulfur",
+		Inputs: map[string]float64{
+			"water":         30.0,
+			"petroleum-gas": 30.0,
+		},
+		Outputs: map[string]float64{
+			"sulfur": 2.0,
+		},
+		CraftingTime: 1.0,
+		Category:     "chemistry",
+	}
+	recipes.Recipes["sulfur"] = sulfur
+
+	sciencePack3 := &core.Recipe{
+		Name: "chemical-science-pack",
+		Inputs: map[string]float64{
+			"engine-unit":      2.0,
+			"advanced-circuit": 3.0,
+			"sulfur":           1.0,
+		},
+		Outputs: map[string]float64{
+			"chemical-science-pack": 2.0,
+		},
+		CraftingTime: 24.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["chemical-science-pack"] = sciencePack3
+
+	// Pipe recipes
+	pipe := &core.Recipe{
+		Name: "pipe",
+		Inputs: map[string]float64{
+			"iron-plate": 1.0,
+		},
+		Outputs: map[string]float64{
+			"pipe": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["pipe"] = pipe
+
+	pipeToGround := &core.Recipe{
+		Name: "pipe-to-ground",
+		Inputs: map[string]float64{
+			"iron-plate": 5.0,
+			"pipe":       10.0,
+		},
+		Outputs: map[string]float64{
+			"pipe-to-ground": 2.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["pipe-to-ground"] = pipeToGround
+
+	// Railway recipes
+	ironStick := &core.Recipe{
+		Name: "iron-stick",
+		Inputs: map[string]float64{
+			"iron-plate": 1.0,
+		},
+		Outputs: map[string]float64{
+			"iron-stick": 2.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["iron-stick"] = ironStick
+
+	rail := &core.Recipe{
+		Name: "rail",
+		Inputs: map[string]float64{
+			"iron-stick":  1.0,
+			"steel-plate": 1.0,
+			"stone":       1.0,
+		},
+		Outputs: map[string]float64{
+			"rail": 2.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["rail"] = rail
+
+	trainStop := &core.Recipe{
+		Name: "train-stop",
+		Inputs: map[string]float64{
+			"electronic-circuit": 5.0,
+			"iron-plate":         6.0,
+			"iron-stick":         6.0,
+			"steel-plate":        3.0,
+		},
+		Outputs: map[string]float64{
+			"train-stop": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["train-stop"] = trainStop
+
+	// Oil recipes
+	basicOilProcessing := &core.Recipe{
+		Name: "basic-oil-processing",
+		Inputs: map[string]float64{
+			"crude-oil": 100.0,
+		},
+		Outputs: map[string]float64{
+			"petroleum-gas": 45.0,
+		},
+		CraftingTime: 5.0,
+		Category:     "oil-processing",
+	}
+	recipes.Recipes["basic-oil-processing"] = basicOilProcessing
+
+	plasticBar := &core.Recipe{
+		Name: "plastic-bar",
+		Inputs: map[string]float64{
+			"coal":          1.0,
+			"petroleum-gas": 20.0,
+		},
+		Outputs: map[string]float64{
+			"plastic-bar": 2.0,
+		},
+		CraftingTime: 1.0,
+		Category:     "chemistry",
+	}
+	recipes.Recipes["plastic-bar"] = plasticBar
+
+	// Electric pole recipes
+	mediumPole := &core.Recipe{
+		Name: "medium-electric-pole",
+		Inputs: map[string]float64{
+			"copper-plate": 2.0,
+			"iron-plate":   2.0,
+			"steel-plate":  2.0,
+		},
+		Outputs: map[string]float64{
+			"medium-electric-pole": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["medium-electric-pole"] = mediumPole
+
+	bigPole := &core.Recipe{
+		Name: "big-electric-pole",
+		Inputs: map[string]float64{
+			"copper-plate": 4.0,
+			"iron-plate":   4.0,
+			"steel-plate":  5.0,
+		},
+		Outputs: map[string]float64{
+			"big-electric-pole": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["big-electric-pole"] = bigPole
+
+	substation := &core.Recipe{
+		Name: "substation",
+		Inputs: map[string]float64{
+			"advanced-circuit": 5.0,
+			"copper-plate":     10.0,
+			"steel-plate":      10.0,
+		},
+		Outputs: map[string]float64{
+			"substation": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["substation"] = substation
+
+	// Early building recipes
+	burnerInserter := &core.Recipe{
+		Name: "burner-inserter",
+		Inputs: map[string]float64{
+			"iron-plate":      1.0,
+			"iron-gear-wheel": 1.0,
+		},
+		Outputs: map[string]float64{
+			"burner-inserter": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["burner-inserter"] = burnerInserter
+
+	stoneFurnace := &core.Recipe{
+		Name: "stone-furnace",
+		Inputs: map[string]float64{
+			"stone": 5.0,
+		},
+		Outputs: map[string]float64{
+			"stone-furnace": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["stone-furnace"] = stoneFurnace
+
+	burnerMiningDrill := &core.Recipe{
+		Name: "burner-mining-drill",
+		Inputs: map[string]float64{
+			"iron-gear-wheel": 3.0,
+			"iron-plate":      3.0,
+			"stone-furnace":   1.0,
+		},
+		Outputs: map[string]float64{
+			"burner-mining-drill": 1.0,
+		},
+		CraftingTime: 2.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["burner-mining-drill"] = burnerMiningDrill
+
+	electricMiningDrill := &core.Recipe{
+		Name: "electric-mining-drill",
+		Inputs: map[string]float64{
+			"electronic-circuit": 3.0,
+			"iron-gear-wheel":    5.0,
+			"iron-plate":         10.0,
+		},
+		Outputs: map[string]float64{
+			"electric-mining-drill": 1.0,
+		},
+		CraftingTime: 2.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["electric-mining-drill"] = electricMiningDrill
+
+	offshorePump := &core.Recipe{
+		Name: "offshore-pump",
+		Inputs: map[string]float64{
+			"electronic-circuit": 2.0,
+			"pipe":               1.0,
+			"iron-gear-wheel":    1.0,
+		},
+		Outputs: map[string]float64{
+			"offshore-pump": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["offshore-pump"] = offshorePump
+
+	boiler := &core.Recipe{
+		Name: "boiler",
+		Inputs: map[string]float64{
+			"stone-furnace": 1.0,
+			"pipe":          4.0,
+		},
+		Outputs: map[string]float64{
+			"boiler": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["boiler"] = boiler
+
+	smallElectricPole := &core.Recipe{
+		Name: "small-electric-pole",
+		Inputs: map[string]float64{
+			"wood":         1.0,
+			"copper-cable": 2.0,
+		},
+		Outputs: map[string]float64{
+			"small-electric-pole": 2.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["small-electric-pole"] = smallElectricPole
+
+	// Underground belt recipes
+	undergroundBelt := &core.Recipe{
+		Name: "underground-belt",
+		Inputs: map[string]float64{
+			"iron-plate":     10.0,
+			"transport-belt": 5.0,
+		},
+		Outputs: map[string]float64{
+			"underground-belt": 2.0,
+		},
+		CraftingTime: 1.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["underground-belt"] = undergroundBelt
+
+	fastUndergroundBelt := &core.Recipe{
+		Name: "fast-underground-belt",
+		Inputs: map[string]float64{
+			"iron-gear-wheel":  40.0,
+			"underground-belt": 2.0,
+		},
+		Outputs: map[string]float64{
+			"fast-underground-belt": 2.0,
+		},
+		CraftingTime: 2.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["fast-underground-belt"] = fastUndergroundBelt
+
+	// Furnace recipes
+	stoneBrick := &core.Recipe{
+		Name: "stone-brick",
+		Inputs: map[string]float64{
+			"stone": 2.0,
+		},
+		Outputs: map[string]float64{
+			"stone-brick": 1.0,
+		},
+		CraftingTime: 3.2,
+		Category:     "smelting",
+	}
+	recipes.Recipes["stone-brick"] = stoneBrick
+
+	steelFurnace := &core.Recipe{
+		Name: "steel-furnace",
+		Inputs: map[string]float64{
+			"steel-plate": 6.0,
+			"stone-brick": 10.0,
+		},
+		Outputs: map[string]float64{
+			"steel-furnace": 1.0,
+		},
+		CraftingTime: 3.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["steel-furnace"] = steelFurnace
+
+	electricFurnace := &core.Recipe{
+		Name: "electric-furnace",
+		Inputs: map[string]float64{
+			"advanced-circuit": 5.0,
+			"steel-plate":      10.0,
+			"stone-brick":      10.0,
+		},
+		Outputs: map[string]float64{
+			"electric-furnace": 1.0,
+		},
+		CraftingTime: 5.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["electric-furnace"] = electricFurnace
+
+	// Assembling machine upgrade recipes
+	assemblingMachine2 := &core.Recipe{
+		Name: "assembling-machine-2",
+		Inputs: map[string]float64{
+			"steel-plate":          2.0,
+			"electronic-circuit":   3.0,
+			"iron-gear-wheel":      5.0,
+			"assembling-machine-1": 1.0,
+		},
+		Outputs: map[string]float64{
+			"assembling-machine-2": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["assembling-machine-2"] = assemblingMachine2
+
+	assemblingMachine3 := &core.Recipe{
+		Name: "assembling-machine-3",
+		Inputs: map[string]float64{
+			"speed-module":         4.0,
+			"assembling-machine-2": 2.0,
+		},
+		Outputs: map[string]float64{
+			"assembling-machine-3": 1.0,
+		},
+		CraftingTime: 0.5,
+		Category:     "crafting",
+	}
+	recipes.Recipes["assembling-machine-3"] = assemblingMachine3
+
+	// Processing unit recipes
+	sulfuricAcid := &core.Recipe{
+		Name: "sulfuric-acid",
+		Inputs: map[string]float64{
+			"sulfur":     5.0,
+			"iron-plate": 1.0,
+			"water":      100.0,
+		},
+		Outputs: map[string]float64{
+			"sulfuric-acid": 50.0,
+		},
+		CraftingTime: 1.0,
+		Category:     "chemistry",
+	}
+	recipes.Recipes["sulfuric-acid"] = sulfuricAcid
+
+	processingUnit := &core.Recipe{
+		Name: "processing-unit",
+		Inputs: map[string]float64{
+			"electronic-circuit": 20.0,
+			"advanced-circuit":   2.0,
+			"sulfuric-acid":      5.0,
+		},
+		Outputs: map[string]float64{
+			"processing-unit": 1.0,
+		},
+		CraftingTime: 10.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["processing-unit"] = processingUnit
+
+	// Module recipes
+	speedModule := &core.Recipe{
+		Name: "speed-module",
+		Inputs: map[string]float64{
+			"advanced-circuit":   5.0,
+			"electronic-circuit": 5.0,
+		},
+		Outputs: map[string]float64{
+			"speed-module": 1.0,
+		},
+		CraftingTime: 15.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["speed-module"] = speedModule
+
+	productivityModule := &core.Recipe{
+		Name: "productivity-module",
+		Inputs: map[string]float64{
+			"advanced-circuit":   5.0,
+			"electronic-circuit": 5.0,
+		},
+		Outputs: map[string]float64{
+			"productivity-module": 1.0,
+		},
+		CraftingTime: 15.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["productivity-module"] = productivityModule
+
+	qualityModule2 := &core.Recipe{
+		Name: "quality-module-2",
+		Inputs: map[string]float64{
+			"quality-module":   4.0,
+			"advanced-circuit": 5.0,
+			"processing-unit":  5.0,
+		},
+		Outputs: map[string]float64{
+			"quality-module-2": 1.0,
+		},
+		CraftingTime: 30.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["quality-module-2"] = qualityModule2
+
+	qualityModule3 := &core.Recipe{
+		Name: "quality-module-3",
+		Inputs: map[string]float64{
+			"quality-module-2": 4.0,
+			"advanced-circuit": 5.0,
+			"processing-unit":  5.0,
+		},
+		Outputs: map[string]float64{
+			"quality-module-3": 1.0,
+		},
+		CraftingTime: 60.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["quality-module-3"] = qualityModule3
+
+	// Fuel recipes
+	solidFuel := &core.Recipe{
+		Name: "solid-fuel-from-petroleum-gas",
+		Inputs: map[string]float64{
+			"petroleum-gas": 20.0,
+		},
+		Outputs: map[string]float64{
+			"solid-fuel": 1.0,
+		},
+		CraftingTime: 2.0,
+		Category:     "chemistry",
+	}
+	recipes.Recipes["solid-fuel-from-petroleum-gas"] = solidFuel
+
+	// Production science pack recipe
+	sciencePack4 := &core.Recipe{
+		Name: "production-science-pack",
+		Inputs: map[string]float64{
+			"electric-furnace":    1.0,
+			"productivity-module": 1.0,
+			"rail":                30.0,
+		},
+		Outputs: map[string]float64{
+			"production-science-pack": 3.0,
+		},
+		CraftingTime: 21.0,
+		Category:     "crafting",
+	}
+	recipes.Recipes["production-science-pack"] = sciencePack4
+
 	return recipes, nil
 }
 
@@ -0,0 +1,242 @@
+// Package data contains research cost and time estimation.
+package data
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+// ResearchEstimate describes the cost and duration of a research queue.
+type ResearchEstimate struct {
+	Technologies    []string           // technologies to research, prerequisites first
+	TotalCost       map[string]int     // science pack -> total packs consumed
+	TechnologyTimes map[string]float64 // technology name -> research time in seconds
+	TotalTime       float64            // research time of the whole queue in seconds
+}
+
+// LabSettings describes the labs doing the research.
+type LabSettings struct {
+	Count      int     // number of labs
+	Speed      float64 // base research speed of one lab
+	SpeedBonus float64 // researched lab speed bonus, 0.2 = +20%
+}
+
+// ResearchClosure returns the given technologies and all their missing
+// prerequisites in an order where every technology follows its
+// prerequisites. Technologies already researched are left out.
+func (td *TechnologyData) ResearchClosure(targets []string, progress *ResearchProgress) ([]string, error) {
+	var order []string
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		if visited[name] || progress.IsTechnologyUnlocked(name) {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("technology %s has cyclic prerequisites", name)
+		}
+
+		tech, exists := td.Technologies[name]
+		if !exists {
+			return fmt.Errorf("unknown technology %s", name)
+		}
+
+		visiting[name] = true
+		prerequisites := append([]string(nil), tech.Prerequisites...)
+		sort.Strings(prerequisites)
+		for _, prerequisite := range prerequisites {
+			if err := visit(prerequisite); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+
+		visited[name] = true
+		order = append(order, name)
+		return nil
+	}
+
+	for _, target := range targets {
+		if err := visit(target); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// ResearchCost sums the science packs needed to research the given
+// technologies.
+func (td *TechnologyData) ResearchCost(technologies []string) map[string]int {
+	cost := make(map[string]int)
+	for _, name := range technologies {
+		if tech, exists := td.Technologies[name]; exists {
+			for pack, count := range tech.Research {
+				cost[pack] += count
+			}
+		}
+	}
+	return cost
+}
+
+// SciencePackTargets plans the science packs for the target technologies and
+// their missing prerequisites: each pack is made at the highest rate any
+// technology in the queue consumes it, so the labs never wait for packs.
+// Targets are sorted by pack name.
+func (td *TechnologyData) SciencePackTargets(targets []string, progress *ResearchProgress, labs LabSettings) ([]core.ProductionTarget, error) {
+	if labs.Count <= 0 || labs.Speed <= 0 {
+		return nil, fmt.Errorf("at least one lab with positive speed is required")
+	}
+
+	technologies, err := td.ResearchClosure(targets, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	labSpeed := float64(labs.Count) * labs.Speed * (1 + labs.SpeedBonus)
+	rates := make(map[string]float64)
+	for _, name := range technologies {
+		tech := td.Technologies[name]
+		units := researchUnits(tech)
+		if units == 0 || tech.Time <= 0 {
+			continue
+		}
+		for pack, count := range tech.Research {
+			rate := float64(count) / float64(units) * labSpeed / tech.Time * 60.0
+			rates[pack] = math.Max(rates[pack], rate)
+		}
+	}
+
+	packs := make([]string, 0, len(rates))
+	for pack := range rates {
+		packs = append(packs, pack)
+	}
+	sort.Strings(packs)
+
+	packTargets := make([]core.ProductionTarget, 0, len(packs))
+	for _, pack := range packs {
+		packTargets = append(packTargets, core.ProductionTarget{Item: pack, Rate: rates[pack]})
+	}
+	return packTargets, nil
+}
+
+// researchUnits returns the number of research units of a technology, the
+// largest science pack count it consumes.
+func researchUnits(tech *Technology) int {
+	units := 0
+	for _, count := range tech.Research {
+		if count > units {
+			units = count
+		}
+	}
+	return units
+}
+
+// EstimateResearchTime estimates how long the target technologies and their
+// missing prerequisites take to research. Each technology is limited either
+// by the labs, which need the technology's unit time per research unit, or
+// by the plan's production rate of each science pack it consumes.
+func (td *TechnologyData) EstimateResearchTime(plan *core.ProductionPlan, targets []string, progress *ResearchProgress, labs LabSettings) (*ResearchEstimate, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("production plan cannot be nil")
+	}
+	if labs.Count <= 0 || labs.Speed <= 0 {
+		return nil, fmt.Errorf("at least one lab with positive speed is required")
+	}
+
+	technologies, err := td.ResearchClosure(targets, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &ResearchEstimate{
+		Technologies:    technologies,
+		TotalCost:       td.ResearchCost(technologies),
+		TechnologyTimes: make(map[string]float64),
+	}
+
+	labSpeed := float64(labs.Count) * labs.Speed * (1 + labs.SpeedBonus)
+
+	for _, name := range technologies {
+		tech := td.Technologies[name]
+
+		seconds := float64(researchUnits(tech)) * tech.Time / labSpeed
+
+		for pack, count := range tech.Research {
+			packsPerSecond := plan.ResourceFlow[pack] / 60.0
+			if packsPerSecond <= 0 {
+				return nil, fmt.Errorf("technology %s needs %s, which the plan does not produce", name, pack)
+			}
+			seconds = math.Max(seconds, float64(count)/packsPerSecond)
+		}
+
+		estimate.TechnologyTimes[name] = seconds
+		estimate.TotalTime += seconds
+	}
+
+	return estimate, nil
+}
+
+// ResearchPath lists the technologies needed to unlock a recipe.
+type ResearchPath struct {
+	Recipe       string         // recipe to unlock
+	Technologies []string       // technologies to research, prerequisites first
+	Cost         map[string]int // science pack -> total packs consumed
+}
+
+// ResearchPathForRecipe finds the cheapest set of technologies that unlocks
+// a recipe from the current research progress. When several technologies
+// unlock the recipe, the one whose missing prerequisites cost the fewest
+// science packs is chosen. The path is empty if the recipe is already
+// available.
+func (td *TechnologyData) ResearchPathForRecipe(recipe string, progress *ResearchProgress) (*ResearchPath, error) {
+	path := &ResearchPath{
+		Recipe: recipe,
+		Cost:   make(map[string]int),
+	}
+
+	if td.UnlockedRecipes(progress)[recipe] {
+		return path, nil
+	}
+
+	var unlockers []string
+	for name, tech := range td.Technologies {
+		for _, effect := range tech.Effects {
+			if effect.Type == "unlock-recipe" && effect.Recipe == recipe {
+				unlockers = append(unlockers, name)
+				break
+			}
+		}
+	}
+	if len(unlockers) == 0 {
+		return nil, fmt.Errorf("no technology unlocks recipe %s", recipe)
+	}
+	sort.Strings(unlockers)
+
+	bestTotal := -1
+	for _, unlocker := range unlockers {
+		technologies, err := td.ResearchClosure([]string{unlocker}, progress)
+		if err != nil {
+			return nil, err
+		}
+
+		cost := td.ResearchCost(technologies)
+		total := 0
+		for _, count := range cost {
+			total += count
+		}
+
+		if bestTotal < 0 || total < bestTotal {
+			bestTotal = total
+			path.Technologies = technologies
+			path.Cost = cost
+		}
+	}
+
+	return path, nil
+}
@@ -0,0 +1,193 @@
+package data
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+// testTechnologies is a small tree: "basics" unlocks gears, and both
+// "belts" and the costlier "fast-belts" need it and unlock belts.
+func testTechnologies() *TechnologyData {
+	unlock := func(recipe string) []TechnologyEffect {
+		return []TechnologyEffect{{Type: "unlock-recipe", Recipe: recipe}}
+	}
+	return &TechnologyData{Technologies: map[string]*Technology{
+		"basics": {
+			Name:     "basics",
+			Research: map[string]int{"red": 10},
+			Time:     10,
+			Effects:  unlock("gear"),
+		},
+		"belts": {
+			Name:          "belts",
+			Prerequisites: []string{"basics"},
+			Research:      map[string]int{"red": 20, "green": 20},
+			Time:          5,
+			Effects:       unlock("belt"),
+		},
+		"fast-belts": {
+			Name:          "fast-belts",
+			Prerequisites: []string{"basics"},
+			Research:      map[string]int{"red": 100},
+			Time:          30,
+			Effects:       unlock("belt"),
+		},
+	}}
+}
+
+func TestEstimateResearchTime(t *testing.T) {
+	labs := LabSettings{Count: 2, Speed: 1}
+
+	tests := []struct {
+		name       string
+		targets    []string
+		researched []string
+		flow       map[string]float64 // packs per minute made by the plan
+		labs       LabSettings
+		want       map[string]float64 // technology -> seconds
+		wantErr    bool
+	}{
+		// 10 units of 10 s on 2 labs
+		{name: "lab limited", targets: []string{"basics"}, flow: map[string]float64{"red": 60}, labs: labs, want: map[string]float64{"basics": 50}},
+		{name: "lab speed bonus", targets: []string{"basics"}, flow: map[string]float64{"red": 60}, labs: LabSettings{Count: 2, Speed: 1, SpeedBonus: 0.25}, want: map[string]float64{"basics": 40}},
+		// 20 green packs at 12/min take 100 s, longer than the labs' 50 s
+		{
+			name: "pack limited", targets: []string{"belts"}, flow: map[string]float64{"red": 60, "green": 12}, labs: labs,
+			want: map[string]float64{"basics": 50, "belts": 100},
+		},
+		{
+			name: "prerequisite researched", targets: []string{"belts"}, researched: []string{"basics"}, flow: map[string]float64{"red": 60, "green": 60}, labs: labs,
+			want: map[string]float64{"belts": 50},
+		},
+		{name: "pack not planned", targets: []string{"belts"}, flow: map[string]float64{"red": 60}, labs: labs, wantErr: true},
+		{name: "no labs", targets: []string{"basics"}, flow: map[string]float64{"red": 60}, wantErr: true},
+		{name: "unknown technology", targets: []string{"rockets"}, flow: map[string]float64{"red": 60}, labs: labs, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := &ResearchProgress{UnlockedTechnologies: make(map[string]bool)}
+			for _, name := range tt.researched {
+				progress.UnlockedTechnologies[name] = true
+			}
+			plan := &core.ProductionPlan{ResourceFlow: tt.flow}
+
+			estimate, err := testTechnologies().EstimateResearchTime(plan, tt.targets, progress, tt.labs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("EstimateResearchTime succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EstimateResearchTime: %v", err)
+			}
+
+			total := 0.0
+			for name, want := range tt.want {
+				if got := estimate.TechnologyTimes[name]; math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s takes %v s, want %v s", name, got, want)
+				}
+				total += want
+			}
+			if len(estimate.Technologies) != len(tt.want) {
+				t.Errorf("technologies = %v, want %d", estimate.Technologies, len(tt.want))
+			}
+			if math.Abs(estimate.TotalTime-total) > 1e-9 {
+				t.Errorf("total time = %v s, want %v s", estimate.TotalTime, total)
+			}
+		})
+	}
+}
+
+func TestSciencePackTargets(t *testing.T) {
+	technologies := testTechnologies()
+	progress := &ResearchProgress{UnlockedTechnologies: make(map[string]bool)}
+	labs := LabSettings{Count: 2, Speed: 1}
+
+	targets, err := technologies.SciencePackTargets([]string{"belts"}, progress, labs)
+	if err != nil {
+		t.Fatalf("SciencePackTargets: %v", err)
+	}
+
+	// basics uses 12 red/min on 2 labs, belts 24 red and 24 green/min
+	want := []core.ProductionTarget{{Item: "green", Rate: 24}, {Item: "red", Rate: 24}}
+	if len(targets) != len(want) {
+		t.Fatalf("targets = %+v, want %+v", targets, want)
+	}
+	flow := make(map[string]float64)
+	for i := range want {
+		if targets[i].Item != want[i].Item || math.Abs(targets[i].Rate-want[i].Rate) > 1e-9 {
+			t.Errorf("target %d = %+v, want %+v", i, targets[i], want[i])
+		}
+		flow[targets[i].Item] = targets[i].Rate
+	}
+
+	// Planned packs never hold the labs back.
+	estimate, err := technologies.EstimateResearchTime(&core.ProductionPlan{ResourceFlow: flow}, []string{"belts"}, progress, labs)
+	if err != nil {
+		t.Fatalf("EstimateResearchTime: %v", err)
+	}
+	if !slices.Equal(estimate.Technologies, []string{"basics", "belts"}) || math.Abs(estimate.TotalTime-100) > 1e-9 {
+		t.Errorf("estimate = %v in %v s, want [basics belts] in 100 s", estimate.Technologies, estimate.TotalTime)
+	}
+}
+
+func TestResearchPathForRecipe(t *testing.T) {
+	tests := []struct {
+		name       string
+		recipe     string
+		researched []string
+		available  []string
+		want       []string
+		cost       map[string]int
+		wantErr    bool
+	}{
+		{name: "cheapest unlocker", recipe: "belt", want: []string{"basics", "belts"}, cost: map[string]int{"red": 30, "green": 20}},
+		{name: "prerequisite researched", recipe: "belt", researched: []string{"basics"}, want: []string{"belts"}, cost: map[string]int{"red": 20, "green": 20}},
+		{name: "unlocked by research", recipe: "gear", researched: []string{"basics"}},
+		{name: "available from the start", recipe: "gear", available: []string{"gear"}},
+		{name: "nothing unlocks it", recipe: "rocket", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := &ResearchProgress{
+				UnlockedTechnologies: make(map[string]bool),
+				AvailableRecipes:     make(map[string]bool),
+			}
+			for _, name := range tt.researched {
+				progress.UnlockedTechnologies[name] = true
+			}
+			for _, name := range tt.available {
+				progress.AvailableRecipes[name] = true
+			}
+
+			path, err := testTechnologies().ResearchPathForRecipe(tt.recipe, progress)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ResearchPathForRecipe succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResearchPathForRecipe: %v", err)
+			}
+
+			if !slices.Equal(path.Technologies, tt.want) {
+				t.Errorf("technologies = %v, want %v", path.Technologies, tt.want)
+			}
+			if len(path.Cost) != len(tt.cost) {
+				t.Errorf("cost = %v, want %v", path.Cost, tt.cost)
+			}
+			for pack, count := range tt.cost {
+				if path.Cost[pack] != count {
+					t.Errorf("%s cost = %d, want %d", pack, path.Cost[pack], count)
+				}
+			}
+		})
+	}
+}
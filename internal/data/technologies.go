@@ -1,20 +1,28 @@
 // Package data contains technology and research data structures.
 package data
 
+import (
+	"strings"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
 // Technology represents a Factorio research technology.
 type Technology struct {
 	Name          string             `json:"name"`
 	Prerequisites []string           `json:"prerequisites"`
 	Research      map[string]int     `json:"research"` // science pack -> count
+	Time          float64            `json:"time"`     // seconds per research unit
 	Effects       []TechnologyEffect `json:"effects"`
 }
 
 // TechnologyEffect represents what a technology unlocks.
 type TechnologyEffect struct {
-	Type     string  `json:"type"` // "unlock-recipe", "modifier", etc.
+	Type     string  `json:"type"` // "unlock-recipe", "modifier", "unlock-quality", etc.
 	Recipe   string  `json:"recipe,omitempty"`
 	Modifier string  `json:"modifier,omitempty"`
 	Change   float64 `json:"change,omitempty"`
+	Quality  string  `json:"quality,omitempty"` // quality unlocked by "unlock-quality"
 }
 
 // ResearchProgress tracks which technologies have been unlocked.
@@ -23,6 +31,28 @@ type ResearchProgress struct {
 	AvailableRecipes     map[string]bool `json:"recipes"`
 }
 
+// StartingRecipes are the recipes available before any research.
+var StartingRecipes = []string{
+	"iron-plate",
+	"copper-plate",
+	"iron-gear-wheel",
+	"copper-cable",
+	"iron-stick",
+	"stone-brick",
+	"automation-science-pack",
+	"transport-belt",
+	"splitter",
+	"burner-inserter",
+	"stone-furnace",
+	"burner-mining-drill",
+	"electric-mining-drill",
+	"offshore-pump",
+	"boiler",
+	"pipe",
+	"pipe-to-ground",
+	"small-electric-pole",
+}
+
 // TechnologyData holds all technology information.
 type TechnologyData struct {
 	Version      string                 `json:"version"`
@@ -46,6 +76,7 @@ func LoadTechnologies() (*TechnologyData, error) {
 		Research: map[string]int{
 			"automation-science-pack": 10,
 		},
+		Time: 10,
 		Effects: []TechnologyEffect{
 			{Type: "unlock-recipe", Recipe: "assembling-machine-1"},
 			{Type: "unlock-recipe", Recipe: "long-handed-inserter"},
@@ -60,6 +91,7 @@ func LoadTechnologies() (*TechnologyData, error) {
 		Research: map[string]int{
 			"automation-science-pack": 30,
 		},
+		Time: 15,
 		Effects: []TechnologyEffect{
 			{Type: "unlock-recipe", Recipe: "electronic-circuit"},
 			{Type: "unlock-recipe", Recipe: "inserter"},
@@ -67,10 +99,502 @@ func LoadTechnologies() (*TechnologyData, error) {
 	}
 	techData.Technologies["electronics"] = electronics
 
+	logisticScience := &Technology{
+		Name:          "logistic-science-pack",
+		Prerequisites: []string{"automation"},
+		Research: map[string]int{
+			"automation-science-pack": 75,
+		},
+		Time: 5,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "logistic-science-pack"},
+		},
+	}
+	techData.Technologies["logistic-science-pack"] = logisticScience
+
+	// Mining productivity research
+	miningProductivity := &Technology{
+		Name:          "mining-productivity-1",
+		Prerequisites: []string{"logistic-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 250,
+			"logistic-science-pack":   250,
+		},
+		Time: 60,
+		Effects: []TechnologyEffect{
+			{Type: "modifier", Modifier: core.ModifierMiningProductivity, Change: 0.1},
+		},
+	}
+	techData.Technologies["mining-productivity-1"] = miningProductivity
+
+	// Logistics technologies
+	logistics := &Technology{
+		Name:          "logistics",
+		Prerequisites: []string{},
+		Research: map[string]int{
+			"automation-science-pack": 75,
+		},
+		Time: 15,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "underground-belt"},
+		},
+	}
+	techData.Technologies["logistics"] = logistics
+
+	logistics2 := &Technology{
+		Name:          "logistics-2",
+		Prerequisites: []string{"logistics", "logistic-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 200,
+			"logistic-science-pack":   200,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "fast-transport-belt"},
+			{Type: "unlock-recipe", Recipe: "fast-splitter"},
+			{Type: "unlock-recipe", Recipe: "fast-underground-belt"},
+		},
+	}
+	techData.Technologies["logistics-2"] = logistics2
+
+	fastInserter := &Technology{
+		Name:          "fast-inserter",
+		Prerequisites: []string{"automation"},
+		Research: map[string]int{
+			"automation-science-pack": 30,
+		},
+		Time: 15,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "fast-inserter"},
+		},
+	}
+	techData.Technologies["fast-inserter"] = fastInserter
+
+	stackInserter := &Technology{
+		Name:          "stack-inserter",
+		Prerequisites: []string{"fast-inserter", "logistics-2", "chemical-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 150,
+			"logistic-science-pack":   150,
+			"chemical-science-pack":   150,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "stack-inserter"},
+			{Type: "modifier", Modifier: core.ModifierStackInserterCapacity, Change: 1},
+		},
+	}
+	techData.Technologies["stack-inserter"] = stackInserter
+
+	inserterCapacity := &Technology{
+		Name:          "inserter-capacity-bonus-1",
+		Prerequisites: []string{"stack-inserter"},
+		Research: map[string]int{
+			"automation-science-pack": 200,
+			"logistic-science-pack":   200,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "modifier", Modifier: core.ModifierInserterStackSize, Change: 1},
+			{Type: "modifier", Modifier: core.ModifierStackInserterCapacity, Change: 1},
+		},
+	}
+	techData.Technologies["inserter-capacity-bonus-1"] = inserterCapacity
+
+	// Oil technologies
+	oilProcessing := &Technology{
+		Name:          "oil-processing",
+		Prerequisites: []string{"logistics-2"},
+		Research: map[string]int{
+			"automation-science-pack": 100,
+			"logistic-science-pack":   100,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "basic-oil-processing"},
+			{Type: "unlock-recipe", Recipe: "solid-fuel-from-petroleum-gas"},
+		},
+	}
+	techData.Technologies["oil-processing"] = oilProcessing
+
+	plastics := &Technology{
+		Name:          "plastics",
+		Prerequisites: []string{"oil-processing"},
+		Research: map[string]int{
+			"automation-science-pack": 200,
+			"logistic-science-pack":   200,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "plastic-bar"},
+		},
+	}
+	techData.Technologies["plastics"] = plastics
+
+	advancedElectronics := &Technology{
+		Name:          "advanced-electronics",
+		Prerequisites: []string{"plastics"},
+		Research: map[string]int{
+			"automation-science-pack": 200,
+			"logistic-science-pack":   200,
+		},
+		Time: 15,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "advanced-circuit"},
+		},
+	}
+	techData.Technologies["advanced-electronics"] = advancedElectronics
+
+	// Chemical science technologies
+	steelProcessing := &Technology{
+		Name:          "steel-processing",
+		Prerequisites: []string{},
+		Research: map[string]int{
+			"automation-science-pack": 50,
+		},
+		Time: 5,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "steel-plate"},
+		},
+	}
+	techData.Technologies["steel-processing"] = steelProcessing
+
+	engine := &Technology{
+		Name:          "engine",
+		Prerequisites: []string{"steel-processing", "logistics-2"},
+		Research: map[string]int{
+			"automation-science-pack": 100,
+			"logistic-science-pack":   100,
+		},
+		Time: 15,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "engine-unit"},
+		},
+	}
+	techData.Technologies["engine"] = engine
+
+	sulfurProcessing := &Technology{
+		Name:          "sulfur-processing",
+		Prerequisites: []string{"oil-processing"},
+		Research: map[string]int{
+			"automation-science-pack": 150,
+			"logistic-science-pack":   150,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "sulfur"},
+			{Type: "unlock-recipe", Recipe: "sulfuric-acid"},
+		},
+	}
+	techData.Technologies["sulfur-processing"] = sulfurProcessing
+
+	chemicalScience := &Technology{
+		Name:          "chemical-science-pack",
+		Prerequisites: []string{"advanced-electronics", "engine", "sulfur-processing"},
+		Research: map[string]int{
+			"automation-science-pack": 75,
+			"logistic-science-pack":   75,
+		},
+		Time: 10,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "chemical-science-pack"},
+		},
+	}
+	techData.Technologies["chemical-science-pack"] = chemicalScience
+
+	railway := &Technology{
+		Name:          "railway",
+		Prerequisites: []string{"logistics-2"},
+		Research: map[string]int{
+			"automation-science-pack": 75,
+			"logistic-science-pack":   75,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "rail"},
+			{Type: "unlock-recipe", Recipe: "train-stop"},
+		},
+	}
+	techData.Technologies["railway"] = railway
+
+	// Production technologies
+	automation2 := &Technology{
+		Name:          "automation-2",
+		Prerequisites: []string{"electronics", "steel-processing", "logistic-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 40,
+			"logistic-science-pack":   40,
+		},
+		Time: 15,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "assembling-machine-2"},
+		},
+	}
+	techData.Technologies["automation-2"] = automation2
+
+	advancedMaterialProcessing := &Technology{
+		Name:          "advanced-material-processing",
+		Prerequisites: []string{"steel-processing", "logistic-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 75,
+			"logistic-science-pack":   75,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "steel-furnace"},
+		},
+	}
+	techData.Technologies["advanced-material-processing"] = advancedMaterialProcessing
+
+	advancedMaterialProcessing2 := &Technology{
+		Name:          "advanced-material-processing-2",
+		Prerequisites: []string{"advanced-material-processing", "chemical-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 250,
+			"logistic-science-pack":   250,
+			"chemical-science-pack":   250,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "electric-furnace"},
+		},
+	}
+	techData.Technologies["advanced-material-processing-2"] = advancedMaterialProcessing2
+
+	processingUnit := &Technology{
+		Name:          "processing-unit",
+		Prerequisites: []string{"chemical-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 300,
+			"logistic-science-pack":   300,
+			"chemical-science-pack":   300,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "processing-unit"},
+		},
+	}
+	techData.Technologies["processing-unit"] = processingUnit
+
+	speedModule := &Technology{
+		Name:          "speed-module",
+		Prerequisites: []string{"advanced-electronics"},
+		Research: map[string]int{
+			"automation-science-pack": 50,
+			"logistic-science-pack":   50,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "speed-module"},
+		},
+	}
+	techData.Technologies["speed-module"] = speedModule
+
+	productivityModule := &Technology{
+		Name:          "productivity-module",
+		Prerequisites: []string{"advanced-electronics"},
+		Research: map[string]int{
+			"automation-science-pack": 50,
+			"logistic-science-pack":   50,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "productivity-module"},
+		},
+	}
+	techData.Technologies["productivity-module"] = productivityModule
+
+	automation3 := &Technology{
+		Name:          "automation-3",
+		Prerequisites: []string{"speed-module", "chemical-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 150,
+			"logistic-science-pack":   150,
+			"chemical-science-pack":   150,
+		},
+		Time: 60,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "assembling-machine-3"},
+		},
+	}
+	techData.Technologies["automation-3"] = automation3
+
+	productionSciencePack := &Technology{
+		Name:          "production-science-pack",
+		Prerequisites: []string{"productivity-module", "advanced-material-processing-2", "railway"},
+		Research: map[string]int{
+			"automation-science-pack": 100,
+			"logistic-science-pack":   100,
+			"chemical-science-pack":   100,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "production-science-pack"},
+		},
+	}
+	techData.Technologies["production-science-pack"] = productionSciencePack
+
+	// Electric pole technologies
+	energyDistribution1 := &Technology{
+		Name:          "electric-energy-distribution-1",
+		Prerequisites: []string{"electronics", "logistics-2"},
+		Research: map[string]int{
+			"automation-science-pack": 120,
+			"logistic-science-pack":   120,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "medium-electric-pole"},
+			{Type: "unlock-recipe", Recipe: "big-electric-pole"},
+		},
+	}
+	techData.Technologies["electric-energy-distribution-1"] = energyDistribution1
+
+	energyDistribution2 := &Technology{
+		Name:          "electric-energy-distribution-2",
+		Prerequisites: []string{"electric-energy-distribution-1", "chemical-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 100,
+			"logistic-science-pack":   100,
+			"chemical-science-pack":   100,
+		},
+		Time: 45,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "substation"},
+		},
+	}
+	techData.Technologies["electric-energy-distribution-2"] = energyDistribution2
+
+	// Lab speed research
+	researchSpeed := &Technology{
+		Name:          "research-speed-1",
+		Prerequisites: []string{"automation", "logistic-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 100,
+			"logistic-science-pack":   100,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "modifier", Modifier: core.ModifierLabSpeed, Change: 0.2},
+		},
+	}
+	techData.Technologies["research-speed-1"] = researchSpeed
+
+	// Quality research
+	qualityModule := &Technology{
+		Name:          "quality-module",
+		Prerequisites: []string{"advanced-electronics"},
+		Research: map[string]int{
+			"automation-science-pack": 50,
+			"logistic-science-pack":   50,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "quality-module"},
+			{Type: "unlock-quality", Quality: "uncommon"},
+			{Type: "unlock-quality", Quality: "rare"},
+		},
+	}
+	techData.Technologies["quality-module"] = qualityModule
+
+	qualityModule2 := &Technology{
+		Name:          "quality-module-2",
+		Prerequisites: []string{"quality-module", "processing-unit"},
+		Research: map[string]int{
+			"automation-science-pack": 100,
+			"logistic-science-pack":   100,
+			"chemical-science-pack":   100,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "quality-module-2"},
+		},
+	}
+	techData.Technologies["quality-module-2"] = qualityModule2
+
+	qualityModule3 := &Technology{
+		Name:          "quality-module-3",
+		Prerequisites: []string{"quality-module-2", "production-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 300,
+			"logistic-science-pack":   300,
+			"chemical-science-pack":   300,
+			"production-science-pack": 300,
+		},
+		Time: 60,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-recipe", Recipe: "quality-module-3"},
+		},
+	}
+	techData.Technologies["quality-module-3"] = qualityModule3
+
+	epicQuality := &Technology{
+		Name:          "epic-quality",
+		Prerequisites: []string{"quality-module", "chemical-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 300,
+			"logistic-science-pack":   300,
+			"chemical-science-pack":   300,
+		},
+		Time: 60,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-quality", Quality: "epic"},
+		},
+	}
+	techData.Technologies["epic-quality"] = epicQuality
+
+	legendaryQuality := &Technology{
+		Name:          "legendary-quality",
+		Prerequisites: []string{"epic-quality"},
+		Research: map[string]int{
+			"automation-science-pack": 1000,
+			"logistic-science-pack":   1000,
+			"chemical-science-pack":   1000,
+		},
+		Time: 60,
+		Effects: []TechnologyEffect{
+			{Type: "unlock-quality", Quality: "legendary"},
+		},
+	}
+	techData.Technologies["legendary-quality"] = legendaryQuality
+
+	// Robot and train bonuses
+	robotSpeed := &Technology{
+		Name:          "worker-robots-speed-1",
+		Prerequisites: []string{"chemical-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 50,
+			"logistic-science-pack":   50,
+			"chemical-science-pack":   50,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "modifier", Modifier: "worker-robot-speed", Change: 0.35},
+		},
+	}
+	techData.Technologies["worker-robots-speed-1"] = robotSpeed
+
+	brakingForce := &Technology{
+		Name:          "braking-force-1",
+		Prerequisites: []string{"railway", "chemical-science-pack"},
+		Research: map[string]int{
+			"automation-science-pack": 100,
+			"logistic-science-pack":   100,
+			"chemical-science-pack":   100,
+		},
+		Time: 30,
+		Effects: []TechnologyEffect{
+			{Type: "modifier", Modifier: core.ModifierTrainBrakingForce, Change: 0.1},
+		},
+	}
+	techData.Technologies["braking-force-1"] = brakingForce
+
 	return techData, nil
 }
 
-// CreateResearchProgress creates a research progress tracker for a given level.
+// CreateResearchProgress creates a research progress tracker for a given
+// level. The level is a comma-separated list of predefined levels and
+// technology names, e.g. "basic-science,quality-module".
 func CreateResearchProgress(level string) *ResearchProgress {
 	progress := &ResearchProgress{
 		UnlockedTechnologies: make(map[string]bool),
@@ -78,22 +602,27 @@ func CreateResearchProgress(level string) *ResearchProgress {
 	}
 
 	// TODO: Implement proper research level parsing
-	// For now, handle basic predefined levels
-	switch level {
-	case "basic-science":
-		progress.UnlockedTechnologies["automation"] = true
-		progress.AvailableRecipes["automation-science-pack"] = true
-		progress.AvailableRecipes["iron-gear-wheel"] = true
-		progress.AvailableRecipes["iron-plate"] = true
-		progress.AvailableRecipes["copper-plate"] = true
-	case "early-game":
-		progress.UnlockedTechnologies["automation"] = true
-		progress.UnlockedTechnologies["electronics"] = true
-		progress.AvailableRecipes["automation-science-pack"] = true
-		progress.AvailableRecipes["iron-gear-wheel"] = true
-		progress.AvailableRecipes["iron-plate"] = true
-		progress.AvailableRecipes["copper-plate"] = true
-		progress.AvailableRecipes["electronic-circuit"] = true
+	// For now, handle basic predefined levels and single technologies
+	for _, part := range strings.Split(level, ",") {
+		switch part = strings.TrimSpace(part); part {
+		case "":
+		case "basic-science":
+			progress.UnlockedTechnologies["automation"] = true
+			progress.AvailableRecipes["automation-science-pack"] = true
+			progress.AvailableRecipes["iron-gear-wheel"] = true
+			progress.AvailableRecipes["iron-plate"] = true
+			progress.AvailableRecipes["copper-plate"] = true
+		case "early-game":
+			progress.UnlockedTechnologies["automation"] = true
+			progress.UnlockedTechnologies["electronics"] = true
+			progress.AvailableRecipes["automation-science-pack"] = true
+			progress.AvailableRecipes["iron-gear-wheel"] = true
+			progress.AvailableRecipes["iron-plate"] = true
+			progress.AvailableRecipes["copper-plate"] = true
+			progress.AvailableRecipes["electronic-circuit"] = true
+		default:
+			progress.UnlockedTechnologies[part] = true
+		}
 	}
 
 	return progress
@@ -108,3 +637,66 @@ func (rp *ResearchProgress) IsRecipeUnlocked(recipeName string) bool {
 func (rp *ResearchProgress) IsTechnologyUnlocked(techName string) bool {
 	return rp.UnlockedTechnologies[techName]
 }
+
+// UnlockedRecipes returns every recipe available with the given progress:
+// starting recipes, recipes marked available and recipes unlocked by
+// researched technologies.
+func (td *TechnologyData) UnlockedRecipes(progress *ResearchProgress) map[string]bool {
+	unlocked := make(map[string]bool)
+	for _, recipe := range StartingRecipes {
+		unlocked[recipe] = true
+	}
+	for recipe, available := range progress.AvailableRecipes {
+		if available {
+			unlocked[recipe] = true
+		}
+	}
+	for name, tech := range td.Technologies {
+		if !progress.IsTechnologyUnlocked(name) {
+			continue
+		}
+		for _, effect := range tech.Effects {
+			if effect.Type == "unlock-recipe" {
+				unlocked[effect.Recipe] = true
+			}
+		}
+	}
+	return unlocked
+}
+
+// MaxQuality returns the highest item quality unlocked by researched
+// technologies. Unknown quality names are ignored.
+func (td *TechnologyData) MaxQuality(progress *ResearchProgress) core.Quality {
+	highest := core.QualityNormal
+	for name, tech := range td.Technologies {
+		if !progress.IsTechnologyUnlocked(name) {
+			continue
+		}
+		for _, effect := range tech.Effects {
+			if effect.Type != "unlock-quality" {
+				continue
+			}
+			if quality, err := core.ParseQuality(effect.Quality); err == nil && quality > highest {
+				highest = quality
+			}
+		}
+	}
+	return highest
+}
+
+// ResearchBonuses collects the modifiers of all researched technologies
+// into a typed bonus set.
+func (td *TechnologyData) ResearchBonuses(progress *ResearchProgress) core.ResearchBonuses {
+	var bonuses core.ResearchBonuses
+	for name, tech := range td.Technologies {
+		if !progress.IsTechnologyUnlocked(name) {
+			continue
+		}
+		for _, effect := range tech.Effects {
+			if effect.Type == "modifier" {
+				bonuses.ApplyModifier(effect.Modifier, effect.Change)
+			}
+		}
+	}
+	return bonuses
+}
@@ -0,0 +1,105 @@
+package data
+
+import (
+	"math"
+	"testing"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+func TestResearchBonuses(t *testing.T) {
+	modifier := func(name string, change float64) []TechnologyEffect {
+		return []TechnologyEffect{{Type: "modifier", Modifier: name, Change: change}}
+	}
+	technologies := &TechnologyData{Technologies: map[string]*Technology{
+		"mining-productivity-1": {Name: "mining-productivity-1", Effects: modifier(core.ModifierMiningProductivity, 0.1)},
+		"mining-productivity-2": {Name: "mining-productivity-2", Effects: modifier(core.ModifierMiningProductivity, 0.1)},
+		"mining-productivity-3": {Name: "mining-productivity-3", Effects: modifier(core.ModifierMiningProductivity, 0.1)},
+		"inserter-capacity-1":   {Name: "inserter-capacity-1", Effects: modifier(core.ModifierInserterStackSize, 1)},
+		"inserter-capacity-2":   {Name: "inserter-capacity-2", Effects: modifier(core.ModifierInserterStackSize, 1)},
+		"braking-force-1":       {Name: "braking-force-1", Effects: modifier(core.ModifierTrainBrakingForce, 0.1)},
+		"artillery-range-1":     {Name: "artillery-range-1", Effects: modifier("artillery-range", 0.3)},
+	}}
+
+	tests := []struct {
+		name       string
+		researched []string
+		want       core.ResearchBonuses
+	}{
+		{name: "nothing researched"},
+		{
+			name:       "one level",
+			researched: []string{"mining-productivity-1"},
+			want:       core.ResearchBonuses{MiningProductivity: 0.1},
+		},
+		{
+			name:       "levels add up",
+			researched: []string{"mining-productivity-1", "mining-productivity-2", "mining-productivity-3", "inserter-capacity-1", "inserter-capacity-2"},
+			want:       core.ResearchBonuses{MiningProductivity: 0.3, InserterStackSize: 2},
+		},
+		{
+			name:       "unresearched levels left out",
+			researched: []string{"mining-productivity-1", "braking-force-1"},
+			want:       core.ResearchBonuses{MiningProductivity: 0.1, TrainBrakingForce: 0.1},
+		},
+		{
+			name:       "unknown modifier ignored",
+			researched: []string{"artillery-range-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := &ResearchProgress{UnlockedTechnologies: make(map[string]bool)}
+			for _, name := range tt.researched {
+				progress.UnlockedTechnologies[name] = true
+			}
+
+			got := technologies.ResearchBonuses(progress)
+			fields := []struct {
+				name      string
+				got, want float64
+			}{
+				{"MiningProductivity", got.MiningProductivity, tt.want.MiningProductivity},
+				{"InserterStackSize", got.InserterStackSize, tt.want.InserterStackSize},
+				{"StackInserterCapacity", got.StackInserterCapacity, tt.want.StackInserterCapacity},
+				{"LabSpeed", got.LabSpeed, tt.want.LabSpeed},
+				{"TrainBrakingForce", got.TrainBrakingForce, tt.want.TrainBrakingForce},
+			}
+			for _, field := range fields {
+				if math.Abs(field.got-field.want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", field.name, field.got, field.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTechnologiesModifiers(t *testing.T) {
+	tests := []struct {
+		modifier string
+		unknown  bool
+	}{
+		{core.ModifierMiningProductivity, false},
+		{"worker-robot-speed", false}, // known but ignored
+		{"artillery-range", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.modifier, func(t *testing.T) {
+			technologies := &TechnologyData{Technologies: map[string]*Technology{
+				"bonus": {Name: "bonus", Effects: []TechnologyEffect{{Type: "modifier", Modifier: tt.modifier, Change: 0.1}}},
+			}}
+
+			var found bool
+			for _, diagnostic := range technologies.ValidateTechnologies(&ItemDatabase{}, &RecipeData{}) {
+				if diagnostic.Code == "unknown-modifier" {
+					found = true
+				}
+			}
+			if found != tt.unknown {
+				t.Errorf("unknown-modifier reported = %v, want %v", found, tt.unknown)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+// Package data contains consistency checks for game data.
+package data
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+// Severity indicates how serious a diagnostic is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // data cannot be used as is
+	SeverityWarning Severity = "warning" // data is usable but likely wrong
+)
+
+// Diagnostic describes a single problem found while validating game data.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`    // machine-readable problem kind, e.g. "missing-prerequisite"
+	Subject  string   `json:"subject"` // technology, recipe or item with the problem
+	Message  string   `json:"message"`
+}
+
+// String formats the diagnostic for display.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", d.Severity, d.Subject, d.Message, d.Code)
+}
+
+// HasErrors reports whether any of the diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateData cross-checks items, recipes, entities and technologies. It
+// finds recipe ingredients and products missing from the item database,
+// recipe categories no machine can craft, starting recipes that do not
+// exist, recipes that can never be unlocked, items nothing produces except
+// unproducedItems, items that cannot be reached from raw materials and the
+// technology tree problems reported by ValidateTechnologies. Diagnostics are
+// sorted by subject.
+func ValidateData(items *ItemDatabase, recipes *RecipeData, entities *EntityData, technologies *TechnologyData) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	categories := make(map[string]bool)
+	for _, entity := range entities.Entities {
+		for _, category := range entity.Categories {
+			categories[category] = true
+		}
+	}
+
+	producers := make(map[string]int)
+	for _, name := range sortedKeys(recipes.Recipes) {
+		recipe := recipes.Recipes[name]
+
+		for _, item := range sortedKeys(recipe.Inputs) {
+			if _, exists := items.GetItem(item); !exists {
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityError,
+					Code:     "unknown-ingredient",
+					Subject:  name,
+					Message:  fmt.Sprintf("ingredient %s is not a known item", item),
+				})
+			}
+		}
+
+		for _, item := range sortedKeys(recipe.Outputs) {
+			producers[item]++
+			if _, exists := items.GetItem(item); !exists {
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityError,
+					Code:     "unknown-product",
+					Subject:  name,
+					Message:  fmt.Sprintf("product %s is not a known item", item),
+				})
+			}
+		}
+
+		if !categories[recipe.Category] {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityError,
+				Code:     "no-machine",
+				Subject:  name,
+				Message:  fmt.Sprintf("no machine can craft category %q", recipe.Category),
+			})
+		}
+	}
+
+	unlockable := make(map[string]bool)
+	for _, name := range StartingRecipes {
+		unlockable[name] = true
+		if _, exists := recipes.Recipes[name]; !exists {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityError,
+				Code:     "unknown-starting-recipe",
+				Subject:  name,
+				Message:  "starting recipe does not exist",
+			})
+		}
+	}
+	for _, tech := range technologies.Technologies {
+		for _, effect := range tech.Effects {
+			if effect.Type == "unlock-recipe" {
+				unlockable[effect.Recipe] = true
+			}
+		}
+	}
+	for _, name := range sortedKeys(recipes.Recipes) {
+		if !unlockable[name] {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     "locked-recipe",
+				Subject:  name,
+				Message:  "not a starting recipe and no technology unlocks it",
+			})
+		}
+	}
+
+	reachable := reachableItems(items, recipes)
+	for _, name := range sortedKeys(items.Items) {
+		if items.IsRawMaterial(name) {
+			continue
+		}
+		if producers[name] == 0 {
+			if unproducedItems[name] {
+				continue
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     "no-producer",
+				Subject:  name,
+				Message:  "no recipe produces this item",
+			})
+		} else if !reachable[name] {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     "unreachable-item",
+				Subject:  name,
+				Message:  "cannot be produced from raw materials",
+			})
+		}
+	}
+
+	diagnostics = append(diagnostics, technologies.ValidateTechnologies(items, recipes)...)
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Subject < diagnostics[j].Subject
+	})
+
+	return diagnostics
+}
+
+// reachableItems returns the items that can be produced starting from raw
+// materials, by repeatedly applying every recipe whose ingredients are all
+// reachable.
+func reachableItems(items *ItemDatabase, recipes *RecipeData) map[string]bool {
+	reachable := make(map[string]bool)
+	for name := range items.Items {
+		if items.IsRawMaterial(name) {
+			reachable[name] = true
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, recipe := range recipes.Recipes {
+			ready := true
+			for input := range recipe.Inputs {
+				if !reachable[input] {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+			for output := range recipe.Outputs {
+				if !reachable[output] {
+					reachable[output] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	return reachable
+}
+
+// unproducedItems are built-in items kept for their entities, such as the
+// express belt tier, whose recipes need fluids or intermediates the built-in
+// data leaves out, such as lubricant and light oil. ValidateData does not
+// warn that no recipe produces them.
+var unproducedItems = map[string]bool{
+	"express-transport-belt":   true,
+	"express-underground-belt": true,
+	"express-splitter":         true,
+	"rocket-fuel":              true,
+	"utility-science-pack":     true,
+}
+
+// ignoredModifiers are game modifiers the planner deliberately leaves out
+// of its calculations, such as robot bonuses for a planner that places no
+// robots. Validation does not warn about them.
+var ignoredModifiers = map[string]bool{
+	"worker-robot-speed": true,
+}
+
+// ValidateTechnologies checks the technology tree for missing prerequisites,
+// prerequisite cycles, unlocks of recipes that do not exist, modifiers the
+// planner does not use, science packs that are not known items, science
+// packs that no recipe can craft from raw materials and science packs whose
+// recipe none of the prerequisites unlocks. Diagnostics are sorted by subject.
+func (td *TechnologyData) ValidateTechnologies(items *ItemDatabase, recipes *RecipeData) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	producible := make(map[string]bool)
+	for _, recipe := range recipes.Recipes {
+		for output := range recipe.Outputs {
+			producible[output] = true
+		}
+	}
+	reachable := reachableItems(items, recipes)
+
+	packRecipes := make(map[string][]string)
+	for _, name := range sortedKeys(recipes.Recipes) {
+		for output := range recipes.Recipes[name].Outputs {
+			packRecipes[output] = append(packRecipes[output], name)
+		}
+	}
+
+	for _, name := range sortedKeys(td.Technologies) {
+		tech := td.Technologies[name]
+		available := td.prerequisiteRecipes(name)
+
+		for _, prerequisite := range tech.Prerequisites {
+			if _, exists := td.Technologies[prerequisite]; !exists {
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityError,
+					Code:     "missing-prerequisite",
+					Subject:  name,
+					Message:  fmt.Sprintf("prerequisite %s does not exist", prerequisite),
+				})
+			}
+		}
+
+		for _, effect := range tech.Effects {
+			switch effect.Type {
+			case "unlock-recipe":
+				if _, exists := recipes.Recipes[effect.Recipe]; !exists {
+					diagnostics = append(diagnostics, Diagnostic{
+						Severity: SeverityError,
+						Code:     "unknown-recipe",
+						Subject:  name,
+						Message:  fmt.Sprintf("unlocks recipe %s, which does not exist", effect.Recipe),
+					})
+				}
+			case "modifier":
+				var bonuses core.ResearchBonuses
+				if !bonuses.ApplyModifier(effect.Modifier, effect.Change) && !ignoredModifiers[effect.Modifier] {
+					diagnostics = append(diagnostics, Diagnostic{
+						Severity: SeverityWarning,
+						Code:     "unknown-modifier",
+						Subject:  name,
+						Message:  fmt.Sprintf("modifier %s is not used by the planner", effect.Modifier),
+					})
+				}
+			}
+		}
+
+		for _, pack := range sortedKeys(tech.Research) {
+			var code, message string
+			switch _, known := items.GetItem(pack); {
+			case !known:
+				code, message = "unknown-science-pack", fmt.Sprintf("science pack %s is not a known item", pack)
+			case !producible[pack]:
+				code, message = "uncraftable-science-pack", fmt.Sprintf("science pack %s cannot be crafted by any recipe", pack)
+			case !reachable[pack]:
+				code, message = "uncraftable-science-pack", fmt.Sprintf("science pack %s cannot be crafted from raw materials", pack)
+			default:
+				if !slices.ContainsFunc(packRecipes[pack], func(recipe string) bool { return available[recipe] }) {
+					diagnostics = append(diagnostics, Diagnostic{
+						Severity: SeverityError,
+						Code:     "locked-science-pack",
+						Subject:  name,
+						Message:  fmt.Sprintf("science pack %s is not unlocked by the prerequisites", pack),
+					})
+				}
+				continue
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityError,
+				Code:     code,
+				Subject:  name,
+				Message:  message,
+			})
+		}
+	}
+
+	diagnostics = append(diagnostics, td.findPrerequisiteCycles()...)
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Subject < diagnostics[j].Subject
+	})
+
+	return diagnostics
+}
+
+// prerequisiteRecipes returns the recipes available once every prerequisite
+// of a technology is researched: the starting recipes and the recipes
+// unlocked by its direct and indirect prerequisites.
+func (td *TechnologyData) prerequisiteRecipes(name string) map[string]bool {
+	available := make(map[string]bool)
+	for _, recipe := range StartingRecipes {
+		available[recipe] = true
+	}
+
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		tech, exists := td.Technologies[name]
+		if !exists {
+			return
+		}
+		for _, prerequisite := range tech.Prerequisites {
+			if visited[prerequisite] {
+				continue
+			}
+			visited[prerequisite] = true
+			if prerequisiteTech, exists := td.Technologies[prerequisite]; exists {
+				for _, effect := range prerequisiteTech.Effects {
+					if effect.Type == "unlock-recipe" {
+						available[effect.Recipe] = true
+					}
+				}
+			}
+			visit(prerequisite)
+		}
+	}
+	visit(name)
+
+	return available
+}
+
+// findPrerequisiteCycles reports each prerequisite cycle in the tree once.
+func (td *TechnologyData) findPrerequisiteCycles() []Diagnostic {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	var diagnostics []Diagnostic
+	state := make(map[string]int)
+	var stack []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		prerequisites := append([]string(nil), td.Technologies[name].Prerequisites...)
+		sort.Strings(prerequisites)
+		for _, prerequisite := range prerequisites {
+			if _, exists := td.Technologies[prerequisite]; !exists {
+				continue // reported as a missing prerequisite
+			}
+			switch state[prerequisite] {
+			case unvisited:
+				visit(prerequisite)
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != prerequisite {
+					start--
+				}
+				cycle := append(append([]string(nil), stack[start:]...), prerequisite)
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityError,
+					Code:     "prerequisite-cycle",
+					Subject:  prerequisite,
+					Message:  fmt.Sprintf("prerequisite cycle %s", strings.Join(cycle, " -> ")),
+				})
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+
+	for _, name := range sortedKeys(td.Technologies) {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+
+	return diagnostics
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
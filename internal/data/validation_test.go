@@ -0,0 +1,271 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blamarvt/factory-planner/internal/core"
+)
+
+// loadBuiltinData loads the built-in game data.
+func loadBuiltinData(t *testing.T) (*ItemDatabase, *RecipeData, *EntityData, *TechnologyData) {
+	t.Helper()
+	items, err := LoadItems()
+	if err != nil {
+		t.Fatalf("LoadItems: %v", err)
+	}
+	recipes, err := LoadRecipes()
+	if err != nil {
+		t.Fatalf("LoadRecipes: %v", err)
+	}
+	entities, err := LoadEntities()
+	if err != nil {
+		t.Fatalf("LoadEntities: %v", err)
+	}
+	technologies, err := LoadTechnologies()
+	if err != nil {
+		t.Fatalf("LoadTechnologies: %v", err)
+	}
+	return items, recipes, entities, technologies
+}
+
+func TestBuiltinDataValidates(t *testing.T) {
+	items, recipes, entities, technologies := loadBuiltinData(t)
+
+	tests := []struct {
+		name        string
+		diagnostics []Diagnostic
+	}{
+		{"ValidateTechnologies", technologies.ValidateTechnologies(items, recipes)},
+		{"ValidateData", ValidateData(items, recipes, entities, technologies)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, diagnostic := range tt.diagnostics {
+				t.Errorf("%s", diagnostic)
+			}
+		})
+	}
+}
+
+func TestValidateData(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData)
+		subject string
+		code    string
+	}{
+		{
+			name: "unknown ingredient",
+			change: func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) {
+				recipes.Recipes["iron-gear-wheel"].Inputs = map[string]float64{"iron-scrap": 2}
+			},
+			subject: "iron-gear-wheel",
+			code:    "unknown-ingredient",
+		},
+		{
+			name: "unknown product",
+			change: func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) {
+				recipes.Recipes["iron-gear-wheel"].Outputs["iron-scrap"] = 1
+			},
+			subject: "iron-gear-wheel",
+			code:    "unknown-product",
+		},
+		{
+			name: "no machine",
+			change: func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) {
+				recipes.Recipes["iron-gear-wheel"].Category = "metallurgy"
+			},
+			subject: "iron-gear-wheel",
+			code:    "no-machine",
+		},
+		{
+			name: "no producer",
+			change: func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) {
+				delete(recipes.Recipes, "iron-gear-wheel")
+			},
+			subject: "iron-gear-wheel",
+			code:    "no-producer",
+		},
+		{
+			name: "unreachable item",
+			change: func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) {
+				recipes.Recipes["iron-gear-wheel"].Inputs["iron-gear-wheel"] = 1
+			},
+			subject: "iron-gear-wheel",
+			code:    "unreachable-item",
+		},
+		{
+			name: "unknown science pack",
+			change: func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) {
+				delete(items.Items, "automation-science-pack")
+			},
+			subject: "automation",
+			code:    "unknown-science-pack",
+		},
+		{
+			name: "missing starting recipe",
+			change: func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) {
+				delete(recipes.Recipes, "stone-furnace")
+			},
+			subject: "stone-furnace",
+			code:    "unknown-starting-recipe",
+		},
+		{
+			name: "recipe nothing unlocks",
+			change: func(items *ItemDatabase, recipes *RecipeData, technologies *TechnologyData) {
+				recipes.Recipes["gear-from-plates"] = &core.Recipe{
+					Name:     "gear-from-plates",
+					Inputs:   map[string]float64{"iron-plate": 4},
+					Outputs:  map[string]float64{"iron-gear-wheel": 2},
+					Category: "crafting",
+				}
+			},
+			subject: "gear-from-plates",
+			code:    "locked-recipe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, recipes, entities, technologies := loadBuiltinData(t)
+			tt.change(items, recipes, technologies)
+
+			// each problem is reported once, even when both ValidateData
+			// and ValidateTechnologies look at it
+			var found []Diagnostic
+			for _, diagnostic := range ValidateData(items, recipes, entities, technologies) {
+				if diagnostic.Subject == tt.subject && (diagnostic.Code == tt.code || strings.HasSuffix(diagnostic.Code, "science-pack")) {
+					found = append(found, diagnostic)
+				}
+			}
+			if len(found) != 1 || found[0].Code != tt.code {
+				t.Errorf("got %v, want one %s diagnostic for %s", found, tt.code, tt.subject)
+			}
+		})
+	}
+}
+
+func TestValidateTechnologiesSciencePacks(t *testing.T) {
+	items := &ItemDatabase{Items: map[string]*Item{
+		"iron-ore":   {Name: "iron-ore", Type: ItemTypeRaw},
+		"iron-plate": {Name: "iron-plate", Type: ItemTypeIntermediate},
+		"gadget":     {Name: "gadget", Type: ItemTypeIntermediate},
+		"red-pack":   {Name: "red-pack", Type: ItemTypeConsumable},
+		"blue-pack":  {Name: "blue-pack", Type: ItemTypeConsumable},
+	}}
+	recipes := &RecipeData{Recipes: map[string]*core.Recipe{
+		"iron-plate": {Name: "iron-plate", Inputs: map[string]float64{"iron-ore": 1}, Outputs: map[string]float64{"iron-plate": 1}},
+		"red-pack":   {Name: "red-pack", Inputs: map[string]float64{"iron-plate": 1}, Outputs: map[string]float64{"red-pack": 1}},
+		"blue-pack":  {Name: "blue-pack", Inputs: map[string]float64{"gadget": 1}, Outputs: map[string]float64{"blue-pack": 1}},
+	}}
+
+	tests := []struct {
+		name          string
+		pack          string
+		prerequisites []string
+		code          string // empty when the pack is craftable
+		message       string
+	}{
+		{name: "craftable", pack: "red-pack", prerequisites: []string{"packs"}},
+		{name: "unlocked indirectly", pack: "red-pack", prerequisites: []string{"more-packs"}},
+		{
+			name: "not unlocked", pack: "red-pack",
+			code: "locked-science-pack", message: "science pack red-pack is not unlocked by the prerequisites",
+		},
+		{
+			name: "not from raw materials", pack: "blue-pack", prerequisites: []string{"packs"},
+			code: "uncraftable-science-pack", message: "science pack blue-pack cannot be crafted from raw materials",
+		},
+		{
+			name: "no recipe", pack: "gadget", prerequisites: []string{"packs"},
+			code: "uncraftable-science-pack", message: "science pack gadget cannot be crafted by any recipe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			technologies := &TechnologyData{Technologies: map[string]*Technology{
+				"packs": {Name: "packs", Effects: []TechnologyEffect{
+					{Type: "unlock-recipe", Recipe: "red-pack"},
+					{Type: "unlock-recipe", Recipe: "blue-pack"},
+				}},
+				"more-packs": {Name: "more-packs", Prerequisites: []string{"packs"}},
+				"research":   {Name: "research", Prerequisites: tt.prerequisites, Research: map[string]int{tt.pack: 10}},
+			}}
+			diagnostics := technologies.ValidateTechnologies(items, recipes)
+
+			if tt.code == "" {
+				if len(diagnostics) != 0 {
+					t.Fatalf("got %v, want no diagnostics", diagnostics)
+				}
+				return
+			}
+			if len(diagnostics) != 1 || diagnostics[0].Code != tt.code || diagnostics[0].Message != tt.message {
+				t.Fatalf("got %v, want one %s diagnostic %q", diagnostics, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestValidateTechnologies(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(technologies *TechnologyData)
+		subject string
+		code    string // empty for a valid tree
+		message string
+	}{
+		{name: "valid tree", change: func(technologies *TechnologyData) {}},
+		{
+			name: "missing prerequisite",
+			change: func(technologies *TechnologyData) {
+				technologies.Technologies["belts"].Prerequisites = []string{"basics", "logistics"}
+			},
+			subject: "belts", code: "missing-prerequisite", message: "prerequisite logistics does not exist",
+		},
+		{
+			name: "prerequisite cycle",
+			change: func(technologies *TechnologyData) {
+				technologies.Technologies["basics"].Prerequisites = []string{"fast-belts"}
+			},
+			subject: "basics", code: "prerequisite-cycle", message: "prerequisite cycle basics -> fast-belts -> basics",
+		},
+		{
+			name: "unknown recipe",
+			change: func(technologies *TechnologyData) {
+				technologies.Technologies["fast-belts"].Effects = []TechnologyEffect{{Type: "unlock-recipe", Recipe: "fast-belt"}}
+			},
+			subject: "fast-belts", code: "unknown-recipe", message: "unlocks recipe fast-belt, which does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipes := &RecipeData{Recipes: map[string]*core.Recipe{
+				"gear": {Name: "gear", Inputs: map[string]float64{"iron-plate": 2}, Outputs: map[string]float64{"gear": 1}},
+				"belt": {Name: "belt", Inputs: map[string]float64{"gear": 1}, Outputs: map[string]float64{"belt": 2}},
+			}}
+			technologies := testTechnologies()
+			for _, technology := range technologies.Technologies {
+				technology.Research = nil // science packs are covered above
+			}
+			tt.change(technologies)
+
+			diagnostics := technologies.ValidateTechnologies(&ItemDatabase{}, recipes)
+			if tt.code == "" {
+				if len(diagnostics) != 0 {
+					t.Fatalf("got %v, want no diagnostics", diagnostics)
+				}
+				return
+			}
+			if len(diagnostics) != 1 {
+				t.Fatalf("got %v, want one %s diagnostic", diagnostics, tt.code)
+			}
+			if got := diagnostics[0]; got.Severity != SeverityError || got.Subject != tt.subject || got.Code != tt.code || got.Message != tt.message {
+				t.Errorf("got %v, want %s error on %s: %s", got, tt.code, tt.subject, tt.message)
+			}
+		})
+	}
+}
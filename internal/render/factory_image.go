@@ -17,14 +17,19 @@ type ImageRenderer struct {
 	TileSize  int         // pixels per tile
 	GridColor color.Color // grid line color
 	BgColor   color.Color // background color
+	TextColor color.Color // title color
 }
 
+// titleScale is the size of a title font pixel in image pixels.
+const titleScale = 2
+
 // NewImageRenderer creates a new image renderer with default settings.
 func NewImageRenderer() *ImageRenderer {
 	return &ImageRenderer{
 		TileSize:  32,                             // 32x32 pixels per tile
 		GridColor: color.RGBA{200, 200, 200, 255}, // light gray
 		BgColor:   color.RGBA{50, 50, 50, 255},    // dark gray
+		TextColor: color.RGBA{255, 255, 255, 255}, // white
 	}
 }
 
@@ -52,10 +57,10 @@ func (ir *ImageRenderer) RenderLayout(layout *core.FactoryLayout, outputPath str
 		ir.drawBuilding(img, &building)
 	}
 
-	// Draw title if provided
+	// Draw title in the bottom-left corner, inside the layout padding
 	if layout.Title != "" {
-		// TODO: Add text rendering for title
-		// For now, we'll skip text rendering to avoid external dependencies
+		titleY := height - glyphHeight*titleScale - 4
+		drawText(img, 4, titleY, width, titleScale, layout.Title, ir.TextColor)
 	}
 
 	// Save to file
@@ -106,11 +111,12 @@ func (ir *ImageRenderer) drawBuilding(img *image.RGBA, building *core.Building)
 		buildingColor = color.RGBA{150, 150, 150, 255} // default gray
 	}
 
-	// Calculate pixel coordinates
-	x1 := building.Position.X*ir.TileSize + 2 // small padding
-	y1 := building.Position.Y*ir.TileSize + 2
-	x2 := x1 + ir.TileSize - 4 // leave border
-	y2 := y1 + ir.TileSize - 4
+	// Calculate pixel coordinates covering the whole footprint
+	bounds := building.Bounds()
+	x1 := bounds.Min.X*ir.TileSize + 2 // small padding
+	y1 := bounds.Min.Y*ir.TileSize + 2
+	x2 := bounds.Max.X*ir.TileSize - 2 // leave border
+	y2 := bounds.Max.Y*ir.TileSize - 2
 
 	// Draw building rectangle
 	buildingRect := image.Rect(x1, y1, x2, y2)
@@ -130,4 +136,9 @@ func (ir *ImageRenderer) drawBuilding(img *image.RGBA, building *core.Building)
 		img.Set(x1, y, borderColor)
 		img.Set(x2-1, y, borderColor)
 	}
+
+	// Draw label, clipped to the building
+	if building.Label != "" {
+		drawText(img, x1+2, y1+2, x2-2, 1, building.Label, borderColor)
+	}
 }
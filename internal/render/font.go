@@ -0,0 +1,112 @@
+// Package render provides a small built-in bitmap font for image labels.
+package render
+
+import (
+	"image"
+	"image/color"
+	"unicode"
+)
+
+// Glyph size of the built-in font in font pixels, and the gap between
+// glyphs.
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+	glyphGap    = 1
+)
+
+// glyphs holds the built-in font as rows of "#" (set) and "." (clear).
+// Letters are upper case only, including the German umlauts and sharp s;
+// lower case text is drawn in upper case and characters without a glyph
+// are drawn as "?".
+var glyphs = map[rune][glyphHeight]string{
+	'A': {".#.", "#.#", "###", "#.#", "#.#"},
+	'B': {"##.", "#.#", "##.", "#.#", "##."},
+	'C': {".##", "#..", "#..", "#..", ".##"},
+	'D': {"##.", "#.#", "#.#", "#.#", "##."},
+	'E': {"###", "#..", "##.", "#..", "###"},
+	'F': {"###", "#..", "##.", "#..", "#.."},
+	'G': {".##", "#..", "#.#", "#.#", ".##"},
+	'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I': {"###", ".#.", ".#.", ".#.", "###"},
+	'J': {"..#", "..#", "..#", "#.#", ".#."},
+	'K': {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L': {"#..", "#..", "#..", "#..", "###"},
+	'M': {"#.#", "###", "###", "#.#", "#.#"},
+	'N': {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O': {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P': {"##.", "#.#", "##.", "#..", "#.."},
+	'Q': {".#.", "#.#", "#.#", "##.", ".##"},
+	'R': {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S': {".##", "#..", ".#.", "..#", "##."},
+	'T': {"###", ".#.", ".#.", ".#.", ".#."},
+	'U': {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V': {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W': {"#.#", "#.#", "###", "###", "#.#"},
+	'X': {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y': {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z': {"###", "..#", ".#.", "#..", "###"},
+	'Ä': {"#.#", ".#.", "#.#", "###", "#.#"},
+	'Ö': {"#.#", ".#.", "#.#", "#.#", ".#."},
+	'Ü': {"#.#", "...", "#.#", "#.#", "###"},
+	'ß': {".#.", "#.#", "##.", "#.#", "##."},
+	'ẞ': {".#.", "#.#", "##.", "#.#", "##."},
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"##.", "..#", ".#.", "#..", "###"},
+	'3': {"##.", "..#", ".#.", "..#", "##."},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "##.", "..#", "##."},
+	'6': {".##", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "##."},
+	' ': {"...", "...", "...", "...", "..."},
+	'-': {"...", "...", "###", "...", "..."},
+	'.': {"...", "...", "...", "...", ".#."},
+	',': {"...", "...", "...", ".#.", "#.."},
+	':': {"...", ".#.", "...", ".#.", "..."},
+	'/': {"..#", "..#", ".#.", "#..", "#.."},
+	'(': {".#.", "#..", "#..", "#..", ".#."},
+	')': {".#.", "..#", "..#", "..#", ".#."},
+	'%': {"#.#", "..#", ".#.", "#..", "#.#"},
+	'?': {"##.", "..#", ".#.", "...", ".#."},
+}
+
+// textWidth returns the width in pixels of text drawn at the given scale.
+func textWidth(text string, scale int) int {
+	runes := len([]rune(text))
+	if runes == 0 {
+		return 0
+	}
+	return (runes*(glyphWidth+glyphGap) - glyphGap) * scale
+}
+
+// drawText draws text with its top-left corner at (x, y), scaling each
+// font pixel to scale×scale image pixels. Glyphs that would cross maxX are
+// left out so labels stay inside their building.
+func drawText(img *image.RGBA, x, y, maxX, scale int, text string, textColor color.Color) {
+	for _, r := range text {
+		if x+glyphWidth*scale > maxX {
+			return
+		}
+
+		glyph, exists := glyphs[unicode.ToUpper(r)]
+		if !exists {
+			glyph = glyphs['?']
+		}
+		for row, line := range glyph {
+			for column, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						img.Set(x+column*scale+dx, y+row*scale+dy, textColor)
+					}
+				}
+			}
+		}
+		x += (glyphWidth + glyphGap) * scale
+	}
+}
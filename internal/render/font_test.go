@@ -0,0 +1,83 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// drawnText draws text at scale 1 and returns the set pixels as rows of
+// "#" and ".".
+func drawnText(text string, maxX int) string {
+	img := image.NewRGBA(image.Rect(0, 0, 40, glyphHeight))
+	drawText(img, 0, 0, maxX, 1, text, color.Black)
+
+	var rows []string
+	for y := 0; y < glyphHeight; y++ {
+		var row strings.Builder
+		for x := 0; x < 40; x++ {
+			if img.RGBAAt(x, y).A > 0 {
+				row.WriteByte('#')
+			} else {
+				row.WriteByte('.')
+			}
+		}
+		rows = append(rows, row.String())
+	}
+	return strings.Join(rows, "\n")
+}
+
+func TestDrawText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string // text drawn the same way
+		maxX int
+	}{
+		{name: "lower case", text: "iron plate", want: "IRON PLATE", maxX: 40},
+		{name: "umlauts", text: "ölfeld", want: "ÖLFELD", maxX: 40},
+		{name: "sharp s", text: "straße", want: "STRAẞE", maxX: 40},
+		{name: "missing glyph", text: "€5", want: "?5", maxX: 40},
+		// 3 glyphs of 3 pixels and 2 gaps fit in 11 pixels
+		{name: "clipped", text: "COAL", want: "COA", maxX: 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, want := drawnText(tt.text, tt.maxX), drawnText(tt.want, 40)
+			if got != want {
+				t.Errorf("%q drawn as\n%s\nwant\n%s", tt.text, got, want)
+			}
+		})
+	}
+}
+
+func TestGlyphs(t *testing.T) {
+	for r, glyph := range glyphs {
+		for _, row := range glyph {
+			if len(row) != glyphWidth || strings.Trim(row, "#.") != "" {
+				t.Errorf("glyph %q has row %q, want %d of # and .", r, row, glyphWidth)
+			}
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	tests := []struct {
+		text  string
+		scale int
+		want  int
+	}{
+		{"", 2, 0},
+		{"A", 1, 3},
+		{"ÖL", 1, 7},
+		{"ÖL", 3, 21},
+	}
+
+	for _, tt := range tests {
+		if got := textWidth(tt.text, tt.scale); got != tt.want {
+			t.Errorf("textWidth(%q, %d) = %d, want %d", tt.text, tt.scale, got, tt.want)
+		}
+	}
+}
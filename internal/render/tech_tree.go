@@ -0,0 +1,201 @@
+// Package render provides DOT and SVG export of the technology tree.
+package render
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+
+	"github.com/blamarvt/factory-planner/internal/data"
+)
+
+// Tech tree node colors and SVG layout sizes.
+const (
+	techResearchedColor = "#8fd18f" // green for researched technologies
+	techAvailableColor  = "#f2d56b" // yellow for technologies ready to research
+	techPendingColor    = "#dddddd" // light gray for everything else
+	techNodeWidth       = 200
+	techNodeHeight      = 30
+	techColumnGap       = 60
+	techRowGap          = 15
+	techMargin          = 20
+)
+
+// TechTreeExporter writes the technology tree as a graph, highlighting
+// researched technologies and those whose prerequisites are all researched.
+type TechTreeExporter struct {
+	Technologies *data.TechnologyData
+	Progress     *data.ResearchProgress
+	Locale       *data.Locale // display names for labels, nil for internal names
+}
+
+// NewTechTreeExporter creates a new tech tree exporter.
+func NewTechTreeExporter(technologies *data.TechnologyData, progress *data.ResearchProgress) *TechTreeExporter {
+	return &TechTreeExporter{
+		Technologies: technologies,
+		Progress:     progress,
+	}
+}
+
+// WriteDOT writes the tech tree in Graphviz DOT format, with an edge from
+// each prerequisite to the technology that needs it.
+func (te *TechTreeExporter) WriteDOT(w io.Writer) error {
+	if te.Technologies == nil {
+		return fmt.Errorf("technology data cannot be nil")
+	}
+
+	fmt.Fprintln(w, "digraph technologies {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintf(w, "  node [shape=box, style=filled, fillcolor=%q];\n", techPendingColor)
+
+	names := te.sortedNames()
+	for _, name := range names {
+		label := te.Locale.TechnologyName(name)
+		if fill := te.fillColor(name); fill != techPendingColor {
+			fmt.Fprintf(w, "  %q [label=%q, fillcolor=%q];\n", name, label, fill)
+		} else {
+			fmt.Fprintf(w, "  %q [label=%q];\n", name, label)
+		}
+	}
+
+	for _, name := range names {
+		prerequisites := append([]string(nil), te.Technologies.Technologies[name].Prerequisites...)
+		sort.Strings(prerequisites)
+		for _, prerequisite := range prerequisites {
+			fmt.Fprintf(w, "  %q -> %q;\n", prerequisite, name)
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// WriteSVG writes the tech tree as an SVG image. Technologies are placed in
+// columns by the length of their longest prerequisite chain, so every
+// prerequisite appears to the left of the technologies that need it.
+func (te *TechTreeExporter) WriteSVG(w io.Writer) error {
+	if te.Technologies == nil {
+		return fmt.Errorf("technology data cannot be nil")
+	}
+
+	depths := te.depths()
+	columns := make(map[int][]string)
+	maxDepth, maxRows := 0, 0
+	for _, name := range te.sortedNames() {
+		depth := depths[name]
+		columns[depth] = append(columns[depth], name)
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		if len(columns[depth]) > maxRows {
+			maxRows = len(columns[depth])
+		}
+	}
+
+	type point struct{ x, y int }
+	positions := make(map[string]point)
+	for depth, names := range columns {
+		for row, name := range names {
+			positions[name] = point{
+				x: techMargin + depth*(techNodeWidth+techColumnGap),
+				y: techMargin + row*(techNodeHeight+techRowGap),
+			}
+		}
+	}
+
+	width := 2*techMargin + (maxDepth+1)*techNodeWidth + maxDepth*techColumnGap
+	height := 2*techMargin + maxRows*techNodeHeight + max(maxRows-1, 0)*techRowGap
+
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\">\n", width, height)
+
+	for _, name := range te.sortedNames() {
+		to := positions[name]
+		for _, prerequisite := range te.Technologies.Technologies[name].Prerequisites {
+			from, exists := positions[prerequisite]
+			if !exists {
+				continue
+			}
+			fmt.Fprintf(w, "  <line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"#555555\"/>\n",
+				from.x+techNodeWidth, from.y+techNodeHeight/2, to.x, to.y+techNodeHeight/2)
+		}
+	}
+
+	for _, name := range te.sortedNames() {
+		position := positions[name]
+		fmt.Fprintf(w, "  <rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"#000000\"/>\n",
+			position.x, position.y, techNodeWidth, techNodeHeight, te.fillColor(name))
+		fmt.Fprintf(w, "  <text x=\"%d\" y=\"%d\" font-family=\"sans-serif\" font-size=\"12\">%s</text>\n",
+			position.x+6, position.y+techNodeHeight/2+4, html.EscapeString(te.Locale.TechnologyName(name)))
+	}
+
+	_, err := fmt.Fprintln(w, "</svg>")
+	return err
+}
+
+// depths returns the length of the longest prerequisite chain of each
+// technology. Missing prerequisites are ignored and cycles are cut.
+func (te *TechTreeExporter) depths() map[string]int {
+	depths := make(map[string]int)
+	visiting := make(map[string]bool)
+
+	var depth func(name string) int
+	depth = func(name string) int {
+		if d, known := depths[name]; known {
+			return d
+		}
+		if visiting[name] {
+			return 0
+		}
+		visiting[name] = true
+
+		d := 0
+		for _, prerequisite := range te.Technologies.Technologies[name].Prerequisites {
+			if _, exists := te.Technologies.Technologies[prerequisite]; exists {
+				d = max(d, depth(prerequisite)+1)
+			}
+		}
+
+		visiting[name] = false
+		depths[name] = d
+		return d
+	}
+
+	for name := range te.Technologies.Technologies {
+		depth(name)
+	}
+	return depths
+}
+
+// fillColor returns the node color of a technology: researched, available
+// when all its prerequisites are researched, or pending. Without research
+// progress every technology is pending.
+func (te *TechTreeExporter) fillColor(name string) string {
+	if te.Progress == nil {
+		return techPendingColor
+	}
+	if te.isResearched(name) {
+		return techResearchedColor
+	}
+	for _, prerequisite := range te.Technologies.Technologies[name].Prerequisites {
+		if !te.isResearched(prerequisite) {
+			return techPendingColor
+		}
+	}
+	return techAvailableColor
+}
+
+// isResearched reports whether a technology has been researched.
+func (te *TechTreeExporter) isResearched(name string) bool {
+	return te.Progress != nil && te.Progress.IsTechnologyUnlocked(name)
+}
+
+// sortedNames returns the technology names in sorted order.
+func (te *TechTreeExporter) sortedNames() []string {
+	names := make([]string, 0, len(te.Technologies.Technologies))
+	for name := range te.Technologies.Technologies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blamarvt/factory-planner/internal/data"
+)
+
+// testTechTree has "basics" researched, "belts" and "fast-belts" ready to
+// research after it, and "express-belts" needing both of those.
+func testTechTree() *TechTreeExporter {
+	technologies := &data.TechnologyData{Technologies: map[string]*data.Technology{
+		"basics":        {Name: "basics"},
+		"belts":         {Name: "belts", Prerequisites: []string{"basics"}},
+		"fast-belts":    {Name: "fast-belts", Prerequisites: []string{"basics"}},
+		"express-belts": {Name: "express-belts", Prerequisites: []string{"fast-belts", "belts"}},
+	}}
+	progress := &data.ResearchProgress{UnlockedTechnologies: map[string]bool{"basics": true}}
+	return NewTechTreeExporter(technologies, progress)
+}
+
+func TestWriteDOT(t *testing.T) {
+	tests := []struct {
+		name     string
+		progress bool
+		want     string
+	}{
+		{
+			name:     "researched and available",
+			progress: true,
+			want: `digraph technologies {
+  rankdir=LR;
+  node [shape=box, style=filled, fillcolor="#dddddd"];
+  "basics" [label="basics", fillcolor="#8fd18f"];
+  "belts" [label="belts", fillcolor="#f2d56b"];
+  "express-belts" [label="express-belts"];
+  "fast-belts" [label="fast-belts", fillcolor="#f2d56b"];
+  "basics" -> "belts";
+  "belts" -> "express-belts";
+  "fast-belts" -> "express-belts";
+  "basics" -> "fast-belts";
+}
+`,
+		},
+		{
+			name: "no research progress",
+			want: `digraph technologies {
+  rankdir=LR;
+  node [shape=box, style=filled, fillcolor="#dddddd"];
+  "basics" [label="basics"];
+  "belts" [label="belts"];
+  "express-belts" [label="express-belts"];
+  "fast-belts" [label="fast-belts"];
+  "basics" -> "belts";
+  "belts" -> "express-belts";
+  "fast-belts" -> "express-belts";
+  "basics" -> "fast-belts";
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := testTechTree()
+			if !tt.progress {
+				exporter.Progress = nil
+			}
+
+			var out strings.Builder
+			if err := exporter.WriteDOT(&out); err != nil {
+				t.Fatalf("WriteDOT: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("WriteDOT wrote\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	var out strings.Builder
+	if err := testTechTree().WriteSVG(&out); err != nil {
+		t.Fatalf("WriteSVG: %v", err)
+	}
+	svg := out.String()
+
+	// three columns of 200 with gaps of 60, two rows of 30 with a gap of 15
+	wants := []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="760" height="115">`,
+		`<rect x="20" y="20" width="200" height="30" fill="#8fd18f" stroke="#000000"/>`,
+		`<rect x="280" y="20" width="200" height="30" fill="#f2d56b" stroke="#000000"/>`,
+		`<rect x="280" y="65" width="200" height="30" fill="#f2d56b" stroke="#000000"/>`,
+		`<rect x="540" y="20" width="200" height="30" fill="#dddddd" stroke="#000000"/>`,
+		`<line x1="220" y1="35" x2="280" y2="80" stroke="#555555"/>`,
+		`<line x1="480" y1="80" x2="540" y2="35" stroke="#555555"/>`,
+		`>express-belts</text>`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG does not contain %s:\n%s", want, svg)
+		}
+	}
+	if !strings.HasSuffix(svg, "</svg>\n") {
+		t.Errorf("SVG does not end with </svg>:\n%s", svg)
+	}
+}
+
+func TestWriteTechTreeWithoutData(t *testing.T) {
+	exporter := NewTechTreeExporter(nil, nil)
+	if err := exporter.WriteDOT(&strings.Builder{}); err == nil {
+		t.Error("WriteDOT succeeded without technology data")
+	}
+	if err := exporter.WriteSVG(&strings.Builder{}); err == nil {
+		t.Error("WriteSVG succeeded without technology data")
+	}
+}
@@ -0,0 +1,55 @@
+// Package layout is the public API for adding layout strategies to the
+// factory planner. A strategy registered here can be selected by name with
+// the --layout-style flag:
+//
+//	func init() {
+//		layout.Register(layout.NewStrategy("single-row", "all machines in one row",
+//			func(g *layout.Generator, plan *layout.ProductionPlan) (*layout.FactoryLayout, error) {
+//				result := &layout.FactoryLayout{Title: "Single row"}
+//				// place machines with g.MachineBuilding and size the layout
+//				g.Connect(plan, result)
+//				return result, nil
+//			}))
+//	}
+package layout
+
+import "github.com/blamarvt/factory-planner/internal/core"
+
+// Types shared with the planner's layout generation.
+type (
+	Strategy       = core.LayoutStrategy
+	Generator      = core.LayoutGenerator
+	ProductionPlan = core.ProductionPlan
+	FactoryLayout  = core.FactoryLayout
+	Building       = core.Building
+	Position       = core.Position
+	Size           = core.Size
+)
+
+// Built-in layout styles.
+const (
+	StyleCompact   = core.LayoutStyleCompact
+	StyleBus       = core.LayoutStyleBus
+	StyleCityBlock = core.LayoutStyleCity
+)
+
+// NewStrategy returns a strategy generating layouts with a function.
+func NewStrategy(name, description string, generate func(g *Generator, plan *ProductionPlan) (*FactoryLayout, error)) Strategy {
+	return core.NewLayoutStrategy(name, description, generate)
+}
+
+// Register adds a layout strategy under its name, which must not be taken
+// by a built-in or another registered strategy.
+func Register(strategy Strategy) error {
+	return core.RegisterLayoutStrategy(strategy)
+}
+
+// Lookup retrieves a registered layout strategy by name.
+func Lookup(name string) (Strategy, bool) {
+	return core.GetLayoutStrategy(name)
+}
+
+// Strategies returns the registered layout strategies, sorted by name.
+func Strategies() []Strategy {
+	return core.LayoutStrategies()
+}